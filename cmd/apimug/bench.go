@@ -0,0 +1,184 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/doganarif/ApiMug/internal/api"
+	"github.com/doganarif/ApiMug/internal/bench"
+	"github.com/doganarif/ApiMug/internal/collection"
+	"github.com/doganarif/ApiMug/internal/contract"
+	"github.com/spf13/cobra"
+)
+
+var (
+	benchOpts   bench.Options
+	benchConfig string
+	benchJSON   string
+	benchCmd    = &cobra.Command{
+		Use:   "bench [spec-file-or-url] [collection/request | operation]",
+		Short: "Replay one request under load and report latencies",
+		Long: `Sends a saved request, or the request "apimug test" would build for an
+operation, repeatedly with the given concurrency and rate until the duration
+is over or the request count is reached. Reports throughput, latency
+percentiles, a latency histogram, the status code distribution and errors.
+
+Stops early on Ctrl+C and reports what was recorded.`,
+		Args:          cobra.ExactArgs(2),
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		RunE:          runBench,
+	}
+)
+
+func init() {
+	benchCmd.Flags().IntVarP(&benchOpts.Concurrency, "concurrency", "c", 10, "Requests in flight at once")
+	benchCmd.Flags().Float64Var(&benchOpts.Rate, "rate", 0, "Maximum requests per second (0 = no limit)")
+	benchCmd.Flags().DurationVarP(&benchOpts.Duration, "duration", "d", 10*time.Second, "How long to run (0 = until --requests are sent)")
+	benchCmd.Flags().IntVarP(&benchOpts.Requests, "requests", "n", 0, "Number of requests to send (0 = until --duration is over)")
+	benchCmd.Flags().StringVar(&benchConfig, "config", "", "YAML file with parameter values for operations, as for test")
+	benchCmd.Flags().StringVar(&benchJSON, "json", "", "Write a JSON report to a file (\"-\" for stdout instead of the text report)")
+	addLenientFlag(benchCmd)
+	rootCmd.AddCommand(benchCmd)
+}
+
+func runBench(cmd *cobra.Command, args []string) error {
+	if benchOpts.Duration <= 0 && benchOpts.Requests <= 0 {
+		return fmt.Errorf("set --duration or --requests")
+	}
+	if err := bench.CheckRate(benchOpts.Rate); err != nil {
+		return fmt.Errorf("--rate: %w", err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	doc, env, client, err := newRunClient(ctx, cmd, args[0])
+	if err != nil {
+		return err
+	}
+	req, err := benchRequest(doc, env, args[0], args[1])
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "Benchmarking %s %s with %d worker(s)", req.Method, client.URL(req), benchOpts.Concurrency)
+	if benchOpts.Rate > 0 {
+		fmt.Fprintf(os.Stderr, " at up to %g req/s", benchOpts.Rate)
+	}
+	fmt.Fprintln(os.Stderr)
+
+	rec := bench.NewRecorder()
+	client = bench.NewClient(client, benchOpts)
+	done := make(chan struct{})
+	go func() {
+		bench.Run(ctx, client, req, benchOpts, rec)
+		close(done)
+	}()
+	if isTerminal(os.Stderr) {
+		ticker := time.NewTicker(250 * time.Millisecond)
+	progress:
+		for {
+			select {
+			case <-done:
+				break progress
+			case <-ticker.C:
+				r := rec.Result()
+				fmt.Fprintf(os.Stderr, "\r%s: %d requests, %.1f req/s, p50 %s ", r.Elapsed.Round(time.Second), r.Requests, r.Throughput, r.P50.Round(time.Microsecond))
+			}
+		}
+		ticker.Stop()
+		fmt.Fprintln(os.Stderr)
+	}
+	<-done
+
+	result := rec.Result()
+	if benchJSON != "-" {
+		printBenchResult(result)
+	}
+	return writeReport(benchJSON, func(w io.Writer) error { return bench.WriteJSON(w, result) })
+}
+
+// benchRequest resolves a saved request, falling back to an operation
+func benchRequest(doc *api.Spec, env *api.Environment, source, ref string) (*api.Request, error) {
+	endpoints := doc.GetEndpoints()
+
+	collections, err := collection.Load(collectionsPath(source))
+	if err != nil {
+		return nil, err
+	}
+	_, saved, findErr := collections.Find(ref)
+	if findErr == nil {
+		endpoint, err := saved.Endpoint(endpoints)
+		if err != nil {
+			return nil, err
+		}
+		return saved.Build(endpoint, env.Variables), nil
+	}
+
+	for i := range endpoints {
+		if endpoints[i].Matches(ref) {
+			cfg, err := contract.LoadConfig(benchConfig)
+			if err != nil {
+				return nil, err
+			}
+			return cfg.Request(&endpoints[i], env.Variables), nil
+		}
+	}
+	return nil, findErr
+}
+
+func printBenchResult(r *bench.Result) {
+	fmt.Printf("Requests:    %d in %s (%d errors)\n", r.Requests, r.Elapsed.Round(time.Millisecond), r.Errors)
+	fmt.Printf("Throughput:  %.1f req/s\n", r.Throughput)
+	if len(r.Histogram) > 0 {
+		fmt.Printf("Latency:     min %s  mean %s  max %s\n", round(r.Min), round(r.Mean), round(r.Max))
+		fmt.Printf("             p50 %s  p90 %s  p99 %s\n", round(r.P50), round(r.P90), round(r.P99))
+
+		fmt.Println("\nHistogram:")
+		most := 0
+		for _, b := range r.Histogram {
+			most = max(most, b.Count)
+		}
+		for _, b := range r.Histogram {
+			bar := strings.Repeat("█", b.Count*40/max(most, 1))
+			fmt.Printf("  %10s  %-40s %d\n", round(b.From), bar, b.Count)
+		}
+	}
+
+	if len(r.Statuses) > 0 {
+		fmt.Println("\nStatus codes:")
+		codes := make([]int, 0, len(r.Statuses))
+		for code := range r.Statuses {
+			codes = append(codes, code)
+		}
+		sort.Ints(codes)
+		for _, code := range codes {
+			fmt.Printf("  %d: %d\n", code, r.Statuses[code])
+		}
+	}
+
+	if len(r.ErrorKinds) > 0 {
+		fmt.Println("\nErrors:")
+		for msg, n := range r.ErrorKinds {
+			fmt.Printf("  %d × %s\n", n, msg)
+		}
+	}
+}
+
+// round shortens a latency for display
+func round(d time.Duration) time.Duration {
+	switch {
+	case d >= time.Second:
+		return d.Round(time.Millisecond)
+	case d >= time.Millisecond:
+		return d.Round(10 * time.Microsecond)
+	}
+	return d.Round(time.Microsecond)
+}
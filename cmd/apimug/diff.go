@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/doganarif/ApiMug/internal/diff"
+	"github.com/spf13/cobra"
+)
+
+var (
+	diffFormat string
+	diffOutput string
+	diffCmd    = &cobra.Command{
+		Use:   "diff [old-spec] [new-spec]",
+		Short: "List the changes between two versions of a spec",
+		Long: `Compares two versions of a spec, each a file or URL, and lists the operations,
+parameters, request bodies and responses that were added, removed or changed.
+
+Each change is classified as breaking when clients written against the old
+spec may fail against the new one: a removed operation or response property,
+a new required parameter, a narrowed type or enum, and so on. Exits non-zero
+when there are breaking changes.`,
+		Args:          cobra.ExactArgs(2),
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		RunE:          runDiff,
+	}
+)
+
+func init() {
+	diffCmd.Flags().StringVar(&diffFormat, "format", "text", "Output format: text, json or markdown")
+	diffCmd.Flags().StringVarP(&diffOutput, "output", "o", "-", "Write the report to a file (\"-\" for stdout)")
+	rootCmd.AddCommand(diffCmd)
+}
+
+func runDiff(cmd *cobra.Command, args []string) error {
+	var write func(io.Writer, *diff.Report) error
+	switch diffFormat {
+	case "text":
+		write = diff.WriteText
+	case "json":
+		write = diff.WriteJSON
+	case "markdown", "md":
+		write = diff.WriteMarkdown
+	default:
+		return fmt.Errorf("unknown format %q: expected text, json or markdown", diffFormat)
+	}
+
+	ctx := context.Background()
+	from, err := loadDocument(ctx, args[0])
+	if err != nil {
+		return fmt.Errorf("%s: %w", args[0], err)
+	}
+	to, err := loadDocument(ctx, args[1])
+	if err != nil {
+		return fmt.Errorf("%s: %w", args[1], err)
+	}
+
+	report := diff.Compare(from, to)
+	report.Old, report.New = args[0], args[1]
+	if err := writeReport(diffOutput, func(w io.Writer) error { return write(w, report) }); err != nil {
+		return err
+	}
+
+	if n := report.Breaking(); n > 0 {
+		return fmt.Errorf("%d breaking change(s)", n)
+	}
+	return nil
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"github.com/doganarif/ApiMug/internal/api"
+	"github.com/doganarif/ApiMug/internal/contract"
+	"github.com/doganarif/ApiMug/internal/fuzz"
+	"github.com/spf13/cobra"
+)
+
+var (
+	fuzzConfig      string
+	fuzzTags        []string
+	fuzzOperations  []string
+	fuzzConcurrency int
+	fuzzTimeout     time.Duration
+	fuzzMaxCases    int
+	fuzzNoMinimize  bool
+	fuzzJSON        string
+	fuzzCmd         = &cobra.Command{
+		Use:   "fuzz [spec-file-or-url]",
+		Short: "Send invalid and edge-case requests to chosen operations",
+		Long: `Builds a valid request for each chosen operation like "apimug test" does, then
+sends variations of it with one parameter or body property replaced by a
+boundary number, overlong or unicode string, injection string, wrong type or
+null, or with a required value left out.
+
+Reports server errors, timeouts and documented responses that do not match
+their schema, each with the smallest request found to reproduce it. Exits
+non-zero when there are findings.
+
+Only fuzz servers you are allowed to, preferably a local instance: the
+requests include writes and deliberately malformed input.`,
+		Args:          cobra.ExactArgs(1),
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		RunE:          runFuzz,
+	}
+)
+
+func init() {
+	fuzzCmd.Flags().StringVar(&fuzzConfig, "config", "", "YAML file with parameter values, headers and per-operation overrides, as for test")
+	fuzzCmd.Flags().StringSliceVar(&fuzzTags, "tag", nil, "Fuzz operations with these tags")
+	fuzzCmd.Flags().StringSliceVar(&fuzzOperations, "operation", nil, "Fuzz these operations (operationId or \"METHOD /path\")")
+	fuzzCmd.Flags().IntVar(&fuzzConcurrency, "concurrency", 4, "Requests in flight at once")
+	fuzzCmd.Flags().DurationVar(&fuzzTimeout, "timeout", 10*time.Second, "Time a request may take before it counts as a timeout")
+	fuzzCmd.Flags().IntVar(&fuzzMaxCases, "max-cases", 0, "Maximum number of mutated requests per operation (0 = all)")
+	fuzzCmd.Flags().BoolVar(&fuzzNoMinimize, "no-minimize", false, "Report failing requests as sent, without reducing them")
+	fuzzCmd.Flags().StringVar(&fuzzJSON, "json", "", "Write a JSON report to a file (\"-\" for stdout instead of the text report)")
+	addLenientFlag(fuzzCmd)
+	rootCmd.AddCommand(fuzzCmd)
+}
+
+func runFuzz(cmd *cobra.Command, args []string) error {
+	if len(fuzzTags) == 0 && len(fuzzOperations) == 0 {
+		return fmt.Errorf("choose the operations to fuzz with --operation or --tag")
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	cfg, err := contract.LoadConfig(fuzzConfig)
+	if err != nil {
+		return err
+	}
+	doc, env, client, err := newRunClient(ctx, cmd, args[0])
+	if err != nil {
+		return err
+	}
+
+	include := endpointFilter(fuzzTags, fuzzOperations, false)
+	endpoints := doc.GetEndpoints()
+	var selected []*api.Endpoint
+	for i := range endpoints {
+		if include(&endpoints[i]) && !endpoints[i].WebSocket {
+			selected = append(selected, &endpoints[i])
+		}
+	}
+	if len(selected) == 0 {
+		return fmt.Errorf("no operations selected")
+	}
+
+	opts := fuzz.Options{
+		Concurrency: fuzzConcurrency,
+		Timeout:     fuzzTimeout,
+		MaxCases:    fuzzMaxCases,
+		Minimize:    !fuzzNoMinimize,
+	}
+	base := func(e *api.Endpoint) *api.Request {
+		return cfg.Request(e, env.Variables)
+	}
+	var progress func(done, total int)
+	if isTerminal(os.Stderr) {
+		progress = func(done, total int) {
+			fmt.Fprintf(os.Stderr, "\rFuzzing %d operation(s): %d/%d requests", len(selected), done, total)
+		}
+	}
+
+	report := fuzz.Run(ctx, client, selected, base, opts, progress)
+	report.Spec = args[0]
+	if progress != nil {
+		fmt.Fprintln(os.Stderr)
+	}
+
+	if fuzzJSON != "-" {
+		printFuzzReport(report)
+	}
+	if err := writeReport(fuzzJSON, func(w io.Writer) error { return fuzz.WriteJSON(w, report) }); err != nil {
+		return err
+	}
+
+	if ctx.Err() != nil {
+		return fmt.Errorf("fuzzing interrupted")
+	}
+	if len(report.Findings) > 0 {
+		return fmt.Errorf("%d finding(s)", len(report.Findings))
+	}
+	return nil
+}
+
+func printFuzzReport(report *fuzz.Report) {
+	for _, f := range report.Findings {
+		fmt.Printf("✗ %s  %s  %s: %s\n", f.Kind, f.Operation, f.Target, f.Mutation)
+		fmt.Printf("  %s\n", f.Message)
+		if len(f.Others) > 0 {
+			fmt.Printf("  also: %s\n", strings.Join(f.Others, ", "))
+		}
+		fmt.Printf("\n%s\n", indent(f.Request.String(), "    "))
+	}
+
+	fmt.Printf("%d request(s) to %d operation(s) in %s: %d finding(s)\n",
+		report.Sent, len(report.Operations), report.Duration.Round(time.Millisecond), len(report.Findings))
+	if report.Errors > 0 {
+		fmt.Printf("%d request(s) failed without a response, last: %s\n", report.Errors, report.LastError)
+	}
+}
+
+func indent(s, prefix string) string {
+	lines := strings.Split(strings.TrimRight(s, "\n"), "\n")
+	return prefix + strings.Join(lines, "\n"+prefix) + "\n"
+}
@@ -0,0 +1,244 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/doganarif/ApiMug/internal/api"
+	"github.com/doganarif/ApiMug/internal/collection"
+	"github.com/doganarif/ApiMug/internal/importer"
+	"github.com/spf13/cobra"
+)
+
+var (
+	importCollection string
+	importDryRun     bool
+	importReplace    bool
+	importStoreCreds bool
+	importCmd        = &cobra.Command{
+		Use:   "import [spec-file-or-url] [postman-or-har-file]...",
+		Short: "Import Postman collections and HAR files as saved requests",
+		Long: `Imports the requests of Postman v2.1 collections and HAR captures into the
+saved requests of a spec. Each request is matched to an operation by method
+and path template, and its path, query and header values become the
+operation's parameters. Cookies are not parameters; a Cookie header is kept as
+a header. Requests that match no operation are skipped, as are HAR requests
+sent to hosts other than those of the spec's servers, --base-url and the
+environment's base URL.
+
+Postman folders become collections of their own. Collection variables,
+exported Postman environments and credentials found in headers are added to
+the environment selected with --env, so that secrets stay out of the saved
+requests. Variables the environment already sets to other values are kept
+unless --replace is given.
+
+With --store-credentials, credentials of the spec's security schemes are
+stored encrypted with the passphrase in APIMUG_PASSPHRASE, as the auth
+settings do, and left out of both the requests and the environment.`,
+		Args:          cobra.MinimumNArgs(2),
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		RunE:          runImport,
+	}
+)
+
+func init() {
+	importCmd.Flags().StringVar(&importCollection, "collection", "", "Collection to import into (default: the Postman collection's name or the HAR file's)")
+	importCmd.Flags().BoolVar(&importDryRun, "dry-run", false, "Show what would be imported without writing anything")
+	importCmd.Flags().BoolVar(&importReplace, "replace", false, "Replace environment variables, the base URL and stored credentials already set to other values")
+	importCmd.Flags().BoolVar(&importStoreCreds, "store-credentials", false, "Store credentials of the spec's security schemes encrypted instead of in the environment")
+	rootCmd.AddCommand(importCmd)
+}
+
+func runImport(cmd *cobra.Command, args []string) error {
+	source := args[0]
+	passphrase := os.Getenv(api.PassphraseEnvVar)
+	if importStoreCreds && passphrase == "" {
+		return fmt.Errorf("--store-credentials needs the passphrase in %s", api.PassphraseEnvVar)
+	}
+	doc, err := loadSpec(context.Background(), source, true)
+	if err != nil {
+		return err
+	}
+	collections, err := collection.Load(collectionsPath(source))
+	if err != nil {
+		return err
+	}
+	envPath := envFile
+	if envPath == "" {
+		if envPath, err = api.DefaultEnvironmentsPath(); err != nil {
+			return err
+		}
+	}
+
+	envs, err := api.LoadEnvironments(envPath)
+	if err != nil {
+		return err
+	}
+	known := make(api.Variables)
+	apiURL := baseURL
+	if env, ok := envs[envName]; ok {
+		known = env.Variables.Clone()
+		if apiURL == "" {
+			apiURL = env.BaseURL
+		}
+	}
+
+	vars := make(api.Variables)
+	secrets := 0
+	var creds map[string]*api.AuthConfig
+	if importStoreCreds {
+		creds = make(map[string]*api.AuthConfig)
+	}
+	importedTo := make(map[string]int)
+	imported, base := 0, ""
+	for _, file := range args[1:] {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", file, err)
+		}
+		result, err := importer.Import(doc, file, data, importer.Options{
+			Collection:  importCollection,
+			Variables:   known,
+			BaseURL:     apiURL,
+			Credentials: creds,
+			Saved:       collections,
+		})
+		if err != nil {
+			return err
+		}
+
+		fmt.Printf("%s (%s): %d request(s) imported, %d skipped\n", file, result.Format, len(result.Requests), len(result.Skipped)+len(result.OtherHosts))
+		printSome("Skipped, no matching operation:", result.Skipped)
+		printSome("Skipped, sent to another host:", result.OtherHosts)
+		printSome("Warnings:", result.Warnings)
+
+		for _, r := range result.Requests {
+			collections.Put(r.Collection, r.Request)
+			importedTo[r.Collection]++
+		}
+		imported += len(result.Requests)
+		for name, value := range result.Variables {
+			vars[name] = value
+			known[name] = value
+		}
+		secrets += len(result.Secrets)
+		for scheme, config := range result.Credentials {
+			creds[scheme] = config
+		}
+		if base == "" {
+			base = result.BaseURL
+		}
+	}
+
+	names := make([]string, 0, len(importedTo))
+	for name := range importedTo {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	fmt.Println()
+	for _, name := range names {
+		fmt.Printf("  %s: %d request(s)\n", name, importedTo[name])
+	}
+
+	if importDryRun {
+		fmt.Printf("Dry run: %d request(s), %d variable(s) and %d credential(s) not written\n", imported, len(vars), len(creds))
+		return nil
+	}
+	if imported > 0 {
+		if err := collections.Save(); err != nil {
+			return err
+		}
+		fmt.Printf("Saved %d request(s) to %s\n", imported, collections.Path())
+	}
+	if len(vars) > 0 || base != "" {
+		conflicts, err := api.MergeEnvironment(envPath, envName, base, vars, importReplace)
+		if err != nil {
+			return err
+		}
+		written := len(vars)
+		if !importReplace {
+			for _, name := range conflicts {
+				if _, ok := vars[name]; ok {
+					written--
+				}
+			}
+		}
+		fmt.Printf("Updated environment %q in %s with %d variable(s)\n", envName, envPath, written)
+		if importReplace {
+			printSome("Replaced:", conflicts)
+		} else {
+			printSome("Kept, already set to another value (--replace to overwrite):", conflicts)
+		}
+		if secrets > 0 && !importStoreCreds {
+			fmt.Printf("%d credential(s) written in plain text, --store-credentials stores those of the spec's security schemes encrypted\n", secrets)
+		}
+	}
+	if len(creds) > 0 {
+		if err := storeCredentials(doc, creds, passphrase); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// storeCredentials adds imported credentials to those stored for the spec and
+// environment, keeping stored ones that differ unless --replace is given
+func storeCredentials(doc *api.Spec, creds map[string]*api.AuthConfig, passphrase string) error {
+	path, err := api.DefaultCredentialStorePath()
+	if err != nil {
+		return err
+	}
+	store := api.NewCredentialStore(path)
+	stored := make(map[string]*api.AuthConfig)
+	if store.Has(api.SpecKey(doc), envName) {
+		if stored, err = store.Load(api.SpecKey(doc), envName, passphrase); err != nil {
+			return err
+		}
+	}
+
+	var conflicts []string
+	for scheme, config := range creds {
+		if current, ok := stored[scheme]; ok && *current != *config {
+			conflicts = append(conflicts, scheme)
+			if !importReplace {
+				continue
+			}
+		}
+		stored[scheme] = config
+	}
+	if err := store.Save(api.SpecKey(doc), envName, passphrase, stored); err != nil {
+		return err
+	}
+
+	sort.Strings(conflicts)
+	written := len(creds)
+	if !importReplace {
+		written -= len(conflicts)
+	}
+	fmt.Printf("Stored credentials of %d security scheme(s) for environment %q in %s\n", written, envName, store.Path())
+	if importReplace {
+		printSome("Replaced:", conflicts)
+	} else {
+		printSome("Kept, already stored with other values (--replace to overwrite):", conflicts)
+	}
+	return nil
+}
+
+// printSome prints up to ten items under a title
+func printSome(title string, items []string) {
+	if len(items) == 0 {
+		return
+	}
+	const limit = 10
+	fmt.Println("  " + title)
+	for i, item := range items {
+		if i == limit {
+			fmt.Printf("    … %d more\n", len(items)-limit)
+			break
+		}
+		fmt.Println("    " + item)
+	}
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/doganarif/ApiMug/internal/lint"
+	"github.com/spf13/cobra"
+)
+
+var (
+	lintConfig    string
+	lintRules     []string
+	lintFormat    string
+	lintOutput    string
+	lintFailOn    string
+	lintListRules bool
+	lintCmd       = &cobra.Command{
+		Use:   "lint [spec-file-or-url]",
+		Short: "Check a spec against style and correctness rules",
+		Long: `Checks a spec for validation errors, missing and duplicate operationIds,
+undocumented path parameters, missing descriptions, examples and error
+responses, unused components, inconsistent naming and insecure servers.
+
+Rule severities can be changed or rules turned off in a YAML config:
+
+  rules:
+    examples: off
+    operation-id: error
+
+By default <spec>.lint.yaml next to the spec is used when it exists. Exits
+non-zero when there are findings at or above --fail-on.`,
+		Args:          cobra.MaximumNArgs(1),
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		RunE:          runLint,
+	}
+)
+
+func init() {
+	lintCmd.Flags().StringVar(&lintConfig, "config", "", "Lint config file (default: <spec>.lint.yaml next to the spec)")
+	lintCmd.Flags().StringSliceVar(&lintRules, "rule", nil, "Override a rule's severity, e.g. examples=off (repeatable)")
+	lintCmd.Flags().StringVar(&lintFormat, "format", "text", "Output format: text, json or sarif")
+	lintCmd.Flags().StringVarP(&lintOutput, "output", "o", "-", "Write the report to a file (\"-\" for stdout)")
+	lintCmd.Flags().StringVar(&lintFailOn, "fail-on", "error", "Exit non-zero on findings of this severity or worse: error, warning, info or off")
+	lintCmd.Flags().BoolVar(&lintListRules, "list-rules", false, "List the rules and their default severities")
+	rootCmd.AddCommand(lintCmd)
+}
+
+func runLint(cmd *cobra.Command, args []string) error {
+	if lintListRules {
+		tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		for _, rule := range lint.Rules {
+			fmt.Fprintf(tw, "%s\t%s\t%s\n", rule.ID, rule.Severity, rule.Description)
+		}
+		return tw.Flush()
+	}
+	if len(args) == 0 {
+		return fmt.Errorf("a spec file or URL is required")
+	}
+	source := args[0]
+
+	var write func(io.Writer, *lint.Report) error
+	switch lintFormat {
+	case "text":
+		write = lint.WriteText
+	case "json":
+		write = lint.WriteJSON
+	case "sarif":
+		write = lint.WriteSARIF
+	default:
+		return fmt.Errorf("unknown format %q: expected text, json or sarif", lintFormat)
+	}
+	failOn, err := lint.ParseSeverity(lintFailOn)
+	if err != nil {
+		return fmt.Errorf("invalid --fail-on: %w", err)
+	}
+
+	cfg, err := lintConfigFor(source)
+	if err != nil {
+		return err
+	}
+
+	// Validation errors are reported as findings instead of stopping the load
+	doc, err := loadDocument(context.Background(), source)
+	if err != nil {
+		return err
+	}
+
+	report := lint.Run(doc, cfg)
+	report.Spec = source
+	if !isURL(source) {
+		if data, err := os.ReadFile(source); err == nil {
+			report.Locate(data)
+		}
+	}
+
+	if err := writeReport(lintOutput, func(w io.Writer) error { return write(w, report) }); err != nil {
+		return err
+	}
+	if failOn != lint.Off && report.Has(failOn) {
+		return fmt.Errorf("lint found problems at or above %s severity", failOn)
+	}
+	return nil
+}
+
+// lintConfigFor loads --config, or the config next to the spec, and applies
+// the --rule overrides
+func lintConfigFor(source string) (*lint.Config, error) {
+	path, required := lintConfig, true
+	if path == "" {
+		path, required = lint.PathFor(source), false
+	}
+	cfg, err := lint.LoadConfig(path, required)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, override := range lintRules {
+		id, severity, ok := strings.Cut(override, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid --rule %q: expected rule=severity", override)
+		}
+		if err := cfg.Set(strings.TrimSpace(id), severity); err != nil {
+			return nil, err
+		}
+	}
+	return cfg, nil
+}
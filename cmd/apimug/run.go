@@ -0,0 +1,188 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/doganarif/ApiMug/internal/api"
+	"github.com/doganarif/ApiMug/internal/collection"
+	"github.com/doganarif/ApiMug/internal/scenario"
+	"github.com/spf13/cobra"
+)
+
+var (
+	includeHeaders bool
+	junitReport    string
+	jsonReport     string
+	noTUI          bool
+	lenient        bool // Shared by the commands that send requests
+	runCmd         = &cobra.Command{
+		Use:   "run [scenario-file | spec-file-or-url [collection/request | collection]...]",
+		Short: "Run a scenario or send saved requests",
+		Long: `With a scenario file, runs its steps and their assertions, showing progress
+in a TUI unless --no-tui is given or stdout is not a terminal. Reports can be
+written as JUnit XML and JSON ("-" for stdout).
+
+With a spec and saved requests, sends them in order, printing each response
+body to stdout and its status to stderr. A collection name sends all of its
+requests.
+
+Stored credentials are used when APIMUG_PASSPHRASE is set.`,
+		Args:          cobra.MinimumNArgs(1),
+		RunE:          runSaved,
+		SilenceUsage:  true,
+		SilenceErrors: true,
+	}
+)
+
+func init() {
+	runCmd.Flags().BoolVarP(&includeHeaders, "include", "i", false, "Print response headers")
+	runCmd.Flags().StringVar(&junitReport, "junit", "", "Write a JUnit XML report of the scenario to a file")
+	runCmd.Flags().StringVar(&jsonReport, "json", "", "Write a JSON report of the scenario to a file")
+	runCmd.Flags().BoolVar(&noTUI, "no-tui", false, "Print scenario progress as plain text")
+	addLenientFlag(runCmd)
+	rootCmd.AddCommand(runCmd)
+}
+
+func runSaved(cmd *cobra.Command, args []string) error {
+	source := args[0]
+	ctx := context.Background()
+
+	if len(args) == 1 {
+		if !scenario.IsScenario(source) {
+			return fmt.Errorf("%s is not a scenario; to send saved requests, name them after the spec", source)
+		}
+		return runScenario(ctx, cmd, source)
+	}
+
+	doc, env, client, err := newRunClient(ctx, cmd, source)
+	if err != nil {
+		return err
+	}
+
+	collections, err := collection.Load(collectionsPath(source))
+	if err != nil {
+		return err
+	}
+	requests, err := collections.Select(args[1:])
+	if err != nil {
+		return err
+	}
+
+	endpoints := doc.GetEndpoints()
+	vars := env.Variables.Clone()
+	failed := 0
+	for _, saved := range requests {
+		endpoint, err := saved.Endpoint(endpoints)
+		if err != nil {
+			return err
+		}
+
+		req := saved.Build(endpoint, vars)
+		fmt.Fprintf(os.Stderr, "→ %s: %s %s\n", saved.Name, req.Method, req.Path)
+
+		resp := client.Send(ctx, req)
+		if resp.Error != nil {
+			fmt.Fprintf(os.Stderr, "  %v\n", resp.Error)
+			failed++
+			continue
+		}
+		fmt.Fprintf(os.Stderr, "  %s (%s)\n", resp.Status, resp.Duration.Round(time.Millisecond))
+		if resp.StatusCode >= 400 {
+			failed++
+		}
+
+		save := make(api.Variables)
+		for _, rule := range saved.Extract {
+			value, err := rule.Extract(resp)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "  extract %v\n", err)
+				failed++
+				continue
+			}
+			vars[rule.Var] = value
+			fmt.Fprintf(os.Stderr, "  %s = %s\n", rule.Var, value)
+			if rule.Save {
+				save[rule.Var] = value
+			}
+		}
+		if len(save) > 0 {
+			if err := env.SaveVariables(save); err != nil {
+				return err
+			}
+			fmt.Fprintf(os.Stderr, "  saved %d variable(s) to environment %q\n", len(save), env.Name)
+		}
+
+		if includeHeaders {
+			names := make([]string, 0, len(resp.Headers))
+			for name := range resp.Headers {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			for _, name := range names {
+				fmt.Printf("%s: %s\n", name, strings.Join(resp.Headers[name], ", "))
+			}
+			fmt.Println()
+		}
+		fmt.Println(resp.FormatResponseBody())
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d request(s) failed", failed, len(requests))
+	}
+	return nil
+}
+
+// addLenientFlag adds --lenient to a command that loads the spec with newRunClient
+func addLenientFlag(cmd *cobra.Command) {
+	cmd.Flags().BoolVar(&lenient, "lenient", false, "Use a spec that fails validation, printing the problems as warnings")
+}
+
+// newRunClient loads the spec and creates a client for the selected environment,
+// with stored credentials when APIMUG_PASSPHRASE is set
+func newRunClient(ctx context.Context, cmd *cobra.Command, source string) (*api.Spec, *api.Environment, *api.Client, error) {
+	env, err := loadEnvironment(cmd)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if baseURL == "" {
+		baseURL = env.BaseURL
+	}
+
+	doc, err := loadSpec(ctx, source, lenient)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	for _, warning := range doc.Warnings {
+		fmt.Fprintf(os.Stderr, "warning: %s\n", warning)
+	}
+	if servers := doc.GetServers(); baseURL == "" && len(servers) > 0 {
+		baseURL = servers[0].Resolve(nil)
+	}
+
+	authMgr := api.NewAuthManager(doc)
+	if passphrase := os.Getenv(api.PassphraseEnvVar); passphrase != "" {
+		if path, err := api.DefaultCredentialStorePath(); err == nil {
+			store := api.NewCredentialStore(path)
+			if store.Has(api.SpecKey(doc), env.Name) {
+				if err := authMgr.LoadCredentials(store, env.Name, passphrase); err != nil {
+					return nil, nil, nil, fmt.Errorf("failed to load stored credentials: %w", err)
+				}
+			}
+		}
+	}
+
+	client := api.NewClient(baseURL, authMgr)
+	client.PinBaseURL(doc.BaseURL != "")
+	if err := client.SetTLSConfig(env.TLS); err != nil {
+		return nil, nil, nil, err
+	}
+	if err := client.SetProxyConfig(env.Proxy); err != nil {
+		return nil, nil, nil, err
+	}
+	return doc, env, client, nil
+}
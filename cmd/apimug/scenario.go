@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/doganarif/ApiMug/internal/scenario"
+	"github.com/doganarif/ApiMug/internal/tui"
+	"github.com/spf13/cobra"
+)
+
+// runScenario runs a scenario file and writes the requested reports
+func runScenario(ctx context.Context, cmd *cobra.Command, path string) error {
+	sc, err := scenario.Load(path)
+	if err != nil {
+		return err
+	}
+
+	doc, env, client, err := newRunClient(ctx, cmd, sc.Spec)
+	if err != nil {
+		return err
+	}
+
+	run := func(ctx context.Context, progress func(int, *scenario.StepResult)) *scenario.Report {
+		return scenario.Run(ctx, client, doc, sc, env.Variables, progress)
+	}
+
+	var report *scenario.Report
+	if noTUI || !isTerminal(os.Stdout) || junitReport == "-" || jsonReport == "-" {
+		fmt.Fprintf(os.Stderr, "Scenario: %s\n", sc.Name)
+		report = run(ctx, printStep)
+		passed, failed, skipped := report.Counts()
+		fmt.Fprintf(os.Stderr, "\n%d passed, %d failed, %d skipped in %s\n", passed, failed, skipped, report.Duration.Round(time.Millisecond))
+	} else {
+		model := tui.NewScenarioModel(sc, run)
+		if _, err := tea.NewProgram(model, tea.WithAltScreen()).Run(); err != nil {
+			return fmt.Errorf("failed to start TUI: %w", err)
+		}
+		if report = model.Report(); report == nil {
+			return fmt.Errorf("scenario interrupted")
+		}
+		passed, failed, skipped := report.Counts()
+		fmt.Printf("%s: %d passed, %d failed, %d skipped\n", sc.Name, passed, failed, skipped)
+	}
+
+	if err := writeReport(junitReport, func(w io.Writer) error { return scenario.WriteJUnit(w, report) }); err != nil {
+		return err
+	}
+	if err := writeReport(jsonReport, func(w io.Writer) error { return scenario.WriteJSON(w, report) }); err != nil {
+		return err
+	}
+
+	if !report.Passed() {
+		return fmt.Errorf("scenario %s failed", sc.Name)
+	}
+	return nil
+}
+
+// printStep reports a completed step on stderr
+func printStep(_ int, result *scenario.StepResult) {
+	switch {
+	case result.Skipped:
+		fmt.Fprintf(os.Stderr, "- %s (skipped)\n", result.Name)
+		return
+	case result.Passed():
+		fmt.Fprintf(os.Stderr, "✓ %s", result.Name)
+	default:
+		fmt.Fprintf(os.Stderr, "✗ %s", result.Name)
+	}
+	if result.Status != 0 {
+		fmt.Fprintf(os.Stderr, "  %s %s → %d (%s)", result.Method, result.Path, result.Status, result.Duration.Round(time.Millisecond))
+	}
+	fmt.Fprintln(os.Stderr)
+
+	for _, a := range result.Assertions {
+		if !a.Passed {
+			fmt.Fprintf(os.Stderr, "    ✗ %s: %s\n", a.Assertion, a.Message)
+		}
+	}
+	if result.Error != "" {
+		fmt.Fprintf(os.Stderr, "    ✗ %s\n", result.Error)
+	}
+}
+
+// writeReport writes a report to path, "-" for stdout; an empty path writes nothing
+func writeReport(path string, write func(io.Writer) error) error {
+	switch path {
+	case "":
+		return nil
+	case "-":
+		return write(os.Stdout)
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create report: %w", err)
+	}
+	if err := write(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// isTerminal reports whether f is an interactive terminal
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/doganarif/ApiMug/internal/api"
+	"github.com/doganarif/ApiMug/internal/contract"
+	"github.com/spf13/cobra"
+)
+
+var (
+	testConfig     string
+	testTags       []string
+	testOperations []string
+	testReadOnly   bool
+	testJSON       string
+	testCmd        = &cobra.Command{
+		Use:   "test [spec-file-or-url]",
+		Short: "Check every operation against its documented responses",
+		Long: `Sends a request to each operation, built from the values in the config file,
+documented examples and the parameter and body schemas, and checks that the
+response status and body match the spec. Prints a matrix of operations and
+status codes, and exits non-zero when an operation fails.
+
+Every method is sent, including POST and DELETE; use --read-only against
+servers holding data you care about.`,
+		Args:          cobra.ExactArgs(1),
+		SilenceUsage:  true,
+		SilenceErrors: true,
+		RunE:          runContractTests,
+	}
+)
+
+func init() {
+	testCmd.Flags().StringVar(&testConfig, "config", "", "YAML file with parameter values, headers and per-operation overrides")
+	testCmd.Flags().StringSliceVar(&testTags, "tag", nil, "Only test operations with these tags")
+	testCmd.Flags().StringSliceVar(&testOperations, "operation", nil, "Only test these operations (operationId or \"METHOD /path\")")
+	testCmd.Flags().BoolVar(&testReadOnly, "read-only", false, "Only test GET, HEAD and OPTIONS operations")
+	testCmd.Flags().StringVar(&testJSON, "json", "", "Write a JSON report to a file (\"-\" for stdout instead of the matrix)")
+	addLenientFlag(testCmd)
+	rootCmd.AddCommand(testCmd)
+}
+
+func runContractTests(cmd *cobra.Command, args []string) error {
+	ctx := context.Background()
+
+	cfg, err := contract.LoadConfig(testConfig)
+	if err != nil {
+		return err
+	}
+	doc, env, client, err := newRunClient(ctx, cmd, args[0])
+	if err != nil {
+		return err
+	}
+
+	include := endpointFilter(testTags, testOperations, testReadOnly)
+	report := contract.Run(ctx, client, doc.GetEndpoints(), cfg, env.Variables, include, nil)
+	report.Spec = args[0]
+	if len(report.Results) == 0 {
+		return fmt.Errorf("no operations selected")
+	}
+
+	if testJSON != "-" {
+		if err := contract.WriteMatrix(os.Stdout, report); err != nil {
+			return err
+		}
+	}
+	if err := writeReport(testJSON, func(w io.Writer) error { return contract.WriteJSON(w, report) }); err != nil {
+		return err
+	}
+
+	if _, failed, _ := report.Counts(); failed > 0 {
+		return fmt.Errorf("%d operation(s) failed", failed)
+	}
+	return nil
+}
+
+// endpointFilter selects endpoints by tag and operation, and optionally only
+// those with safe methods. Empty lists select everything.
+func endpointFilter(tags, operations []string, readOnly bool) func(*api.Endpoint) bool {
+	return func(e *api.Endpoint) bool {
+		if readOnly {
+			switch strings.ToUpper(e.Method) {
+			case "GET", "HEAD", "OPTIONS":
+			default:
+				return false
+			}
+		}
+		if len(operations) > 0 && !matchesAny(e, operations) {
+			return false
+		}
+		if len(tags) > 0 {
+			for _, tag := range e.Tags {
+				for _, want := range tags {
+					if tag == want {
+						return true
+					}
+				}
+			}
+			return false
+		}
+		return true
+	}
+}
+
+func matchesAny(e *api.Endpoint, refs []string) bool {
+	for _, ref := range refs {
+		if e.Matches(ref) {
+			return true
+		}
+	}
+	return false
+}
@@ -1,8 +1,15 @@
 package api
 
 import (
+	"crypto/tls"
 	"fmt"
 	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/getkin/kin-openapi/openapi3"
 )
 
 // AuthType represents the type of authentication
@@ -14,6 +21,10 @@ const (
 	AuthTypeAPIKey     AuthType = "apikey"
 	AuthTypeBasic      AuthType = "basic"
 	AuthTypeOAuth2     AuthType = "oauth2"
+	AuthTypeDigest     AuthType = "digest"
+	AuthTypeSigV4      AuthType = "sigv4"
+	AuthTypeHMAC       AuthType = "hmac"
+	AuthTypeMutualTLS  AuthType = "mutualTLS"
 )
 
 // AuthConfig holds authentication configuration
@@ -23,100 +34,376 @@ type AuthConfig struct {
 	APIKey   string // For API keys
 	APIKeyIn string // header, query, cookie
 	KeyName  string // Name of the header/query param
-	Username string // For basic auth
-	Password string // For basic auth
+	Username string // For basic and digest auth
+	Password string // For basic and digest auth
+
+	// AWS Signature Version 4
+	AccessKey    string
+	SecretKey    string
+	SessionToken string
+	Region       string
+	Service      string
+
+	// HMAC request signing, see hmac.go for template placeholders
+	SigningSecret    string
+	SigningTemplate  string // Canonical string template
+	SigningAlgorithm string // sha256, sha512, sha1, md5
+	SigningEncoding  string // hex, base64, base64url
+	SigningHeaders   string // "Name: template; Name: template"
+	SigningTimestamp string // unix, unix_ms, rfc3339, rfc1123
+
+	// Mutual TLS, the certificate is presented during the TLS handshake
+	CertFile string
+	KeyFile  string
+
+	// Secret fields may be env: or exec: references, see secrets.go. Only set
+	// for credentials entered in the auth settings, never for imported ones.
+	SecretRefs bool
 }
 
-// AuthManager manages authentication for API requests
+// Signature records the string a signing scheme signed, for debugging
+type Signature struct {
+	Scheme    string
+	Canonical string
+}
+
+// AuthManager manages authentication for API requests. Credentials are held per
+// security scheme so that requirements combining several schemes can be met.
 type AuthManager struct {
-	config *AuthConfig
-	spec   *Spec
+	spec    *Spec
+	secrets *secretResolver
+
+	mu      sync.Mutex
+	configs map[string]*AuthConfig      // Replaced rather than changed, see snapshot
+	digest  map[string]*digestChallenge // Latest Digest challenge per scheme
+	certs   map[string]*tls.Certificate // Loaded client certificates by file pair
 }
 
 // NewAuthManager creates a new auth manager
 func NewAuthManager(spec *Spec) *AuthManager {
 	return &AuthManager{
-		spec:   spec,
-		config: &AuthConfig{Type: AuthTypeNone},
+		spec:    spec,
+		configs: make(map[string]*AuthConfig),
+		secrets: newSecretResolver(),
+		digest:  make(map[string]*digestChallenge),
+		certs:   make(map[string]*tls.Certificate),
 	}
 }
 
-// GetAvailableAuthSchemes returns available auth schemes from the spec
+// LoadCredentials replaces the configured credentials with those stored for the
+// spec in the given environment
+func (am *AuthManager) LoadCredentials(store *CredentialStore, env, passphrase string) error {
+	configs, err := store.Load(SpecKey(am.spec), env, passphrase)
+	if err != nil {
+		return err
+	}
+
+	loaded := make(map[string]*AuthConfig, len(configs))
+	for name, config := range configs {
+		if config != nil && config.Type != AuthTypeNone {
+			loaded[name] = config
+		}
+	}
+
+	am.mu.Lock()
+	defer am.mu.Unlock()
+	am.configs = loaded
+	am.digest = make(map[string]*digestChallenge)
+	am.certs = make(map[string]*tls.Certificate)
+	return nil
+}
+
+// SaveCredentials stores the configured credentials for the spec in the given environment
+func (am *AuthManager) SaveCredentials(store *CredentialStore, env, passphrase string) error {
+	return store.Save(SpecKey(am.spec), env, passphrase, am.snapshot())
+}
+
+// snapshot returns the configured credentials. Requests read them from other
+// goroutines while the TUI edits them, so the map is never changed in place
+// and can be read without holding the lock.
+func (am *AuthManager) snapshot() map[string]*AuthConfig {
+	am.mu.Lock()
+	defer am.mu.Unlock()
+	return am.configs
+}
+
+// GetAvailableAuthSchemes returns the security scheme names defined in the spec, sorted
 func (am *AuthManager) GetAvailableAuthSchemes() []string {
 	if am.spec.Doc == nil || am.spec.Doc.Components == nil || am.spec.Doc.Components.SecuritySchemes == nil {
-		return []string{"none"}
+		return nil
 	}
 
-	schemes := []string{"none"}
+	schemes := make([]string, 0, len(am.spec.Doc.Components.SecuritySchemes))
 	for name := range am.spec.Doc.Components.SecuritySchemes {
 		schemes = append(schemes, name)
 	}
+	sort.Strings(schemes)
 	return schemes
 }
 
-// SetAuth configures authentication
-func (am *AuthManager) SetAuth(config *AuthConfig) {
-	am.config = config
+// SetAuth configures credentials for a security scheme, a nil or none config removes them
+func (am *AuthManager) SetAuth(schemeName string, config *AuthConfig) {
+	am.mu.Lock()
+	defer am.mu.Unlock()
+	delete(am.digest, schemeName)
+	am.certs = make(map[string]*tls.Certificate)
+
+	configs := make(map[string]*AuthConfig, len(am.configs)+1)
+	for name, c := range am.configs {
+		configs[name] = c
+	}
+	if config == nil || config.Type == AuthTypeNone {
+		delete(configs, schemeName)
+	} else {
+		configs[schemeName] = config
+	}
+	am.configs = configs
+}
+
+// GetAuth returns the credentials configured for a security scheme, or nil
+func (am *AuthManager) GetAuth(schemeName string) *AuthConfig {
+	return am.snapshot()[schemeName]
+}
+
+// ClearAuth removes all configured credentials
+func (am *AuthManager) ClearAuth() {
+	am.mu.Lock()
+	defer am.mu.Unlock()
+	am.configs = make(map[string]*AuthConfig)
+	am.digest = make(map[string]*digestChallenge)
+	am.certs = make(map[string]*tls.Certificate)
+}
+
+// IsConfigured reports whether credentials are set for a security scheme
+func (am *AuthManager) IsConfigured(schemeName string) bool {
+	_, ok := am.snapshot()[schemeName]
+	return ok
+}
+
+// Requirements returns the alternative scheme combinations required by an operation.
+// security is the operation's own requirement, nil to inherit the document's.
+// declared is false when neither the operation nor the document declares security.
+func (am *AuthManager) Requirements(security *openapi3.SecurityRequirements) (alternatives [][]string, declared bool) {
+	var reqs openapi3.SecurityRequirements
+	switch {
+	case security != nil:
+		reqs = *security
+	case am.spec.Doc != nil && am.spec.Doc.Security != nil:
+		reqs = am.spec.Doc.Security
+	default:
+		return nil, false
+	}
+
+	alternatives = [][]string{}
+	for _, req := range reqs {
+		names := make([]string, 0, len(req))
+		for name := range req {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		alternatives = append(alternatives, names)
+	}
+	return alternatives, true
+}
+
+// Satisfied reports whether every scheme of a requirement has credentials
+func (am *AuthManager) Satisfied(requirement []string) bool {
+	return satisfied(am.snapshot(), requirement)
+}
+
+func satisfied(configs map[string]*AuthConfig, requirement []string) bool {
+	for _, name := range requirement {
+		if _, ok := configs[name]; !ok {
+			return false
+		}
+	}
+	return true
 }
 
-// GetAuth returns current auth config
-func (am *AuthManager) GetAuth() *AuthConfig {
-	return am.config
+// ApplyAuth applies authentication to an HTTP request. The first alternative of the
+// operation's (or document's) security requirement whose schemes are all configured
+// is applied. An empty requirement list marks a public operation. When no security
+// is declared at all, every configured scheme is applied. The strings signed by
+// request signing schemes are returned for debugging.
+func (am *AuthManager) ApplyAuth(req *http.Request, security *openapi3.SecurityRequirements) ([]Signature, error) {
+	var signers []*AuthConfig
+	var signerNames []string
+
+	configs := am.snapshot()
+	for _, name := range am.selectSchemes(configs, security) {
+		config, err := am.secrets.resolveSecrets(configs[name])
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+
+		switch config.Type {
+		case AuthTypeSigV4, AuthTypeHMAC:
+			// Signatures cover the final request, so sign after everything else
+			signers = append(signers, config)
+			signerNames = append(signerNames, name)
+			continue
+		case AuthTypeDigest:
+			err = am.applyDigest(req, name, config)
+		default:
+			err = applyConfig(req, config)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+	}
+
+	var signatures []Signature
+	for i, config := range signers {
+		var canonical string
+		var err error
+		if config.Type == AuthTypeSigV4 {
+			canonical, err = signSigV4(req, config, time.Now())
+		} else {
+			canonical, err = signHMAC(req, config, time.Now())
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", signerNames[i], err)
+		}
+		signatures = append(signatures, Signature{Scheme: signerNames[i], Canonical: canonical})
+	}
+
+	return signatures, nil
 }
 
-// ApplyAuth applies authentication to an HTTP request
-func (am *AuthManager) ApplyAuth(req *http.Request) error {
-	if am.config == nil || am.config.Type == AuthTypeNone {
+// selectSchemes returns the configured schemes ApplyAuth uses for a requirement
+func (am *AuthManager) selectSchemes(configs map[string]*AuthConfig, security *openapi3.SecurityRequirements) []string {
+	alternatives, declared := am.Requirements(security)
+
+	if !declared {
+		schemes := make([]string, 0, len(configs))
+		for name := range configs {
+			schemes = append(schemes, name)
+		}
+		sort.Strings(schemes)
+		return schemes
+	}
+
+	var schemes []string
+	for _, alternative := range alternatives {
+		if satisfied(configs, alternative) {
+			schemes = append(schemes, alternative...)
+			break
+		}
+	}
+
+	// Custom signing is not part of the spec, so no requirement can name it
+	if _, ok := configs[CustomSigningScheme]; ok && len(alternatives) > 0 {
+		schemes = append(schemes, CustomSigningScheme)
+	}
+	return schemes
+}
+
+// applyConfig applies a single scheme's credentials to an HTTP request
+func applyConfig(req *http.Request, config *AuthConfig) error {
+	if config == nil || config.Type == AuthTypeNone {
 		return nil
 	}
 
-	switch am.config.Type {
+	switch config.Type {
 	case AuthTypeBearer:
-		if am.config.Token == "" {
+		if config.Token == "" {
 			return fmt.Errorf("bearer token is required")
 		}
-		req.Header.Set("Authorization", "Bearer "+am.config.Token)
+		req.Header.Set("Authorization", "Bearer "+config.Token)
 
 	case AuthTypeAPIKey:
-		if am.config.APIKey == "" {
+		if config.APIKey == "" {
 			return fmt.Errorf("API key is required")
 		}
-		switch am.config.APIKeyIn {
+		switch config.APIKeyIn {
 		case "header":
-			req.Header.Set(am.config.KeyName, am.config.APIKey)
+			req.Header.Set(config.KeyName, config.APIKey)
 		case "query":
 			q := req.URL.Query()
-			q.Set(am.config.KeyName, am.config.APIKey)
+			q.Set(config.KeyName, config.APIKey)
 			req.URL.RawQuery = q.Encode()
 		case "cookie":
 			req.AddCookie(&http.Cookie{
-				Name:  am.config.KeyName,
-				Value: am.config.APIKey,
+				Name:  config.KeyName,
+				Value: config.APIKey,
 			})
 		}
 
 	case AuthTypeBasic:
-		if am.config.Username == "" || am.config.Password == "" {
+		if config.Username == "" || config.Password == "" {
 			return fmt.Errorf("username and password are required")
 		}
-		req.SetBasicAuth(am.config.Username, am.config.Password)
+		req.SetBasicAuth(config.Username, config.Password)
 
 	case AuthTypeOAuth2:
-		if am.config.Token == "" {
+		if config.Token == "" {
 			return fmt.Errorf("OAuth2 token is required")
 		}
-		req.Header.Set("Authorization", "Bearer "+am.config.Token)
+		req.Header.Set("Authorization", "Bearer "+config.Token)
+
+	case AuthTypeMutualTLS:
+		// Nothing to add to the request, see ClientCertificate
+		if config.CertFile == "" {
+			return fmt.Errorf("client certificate is required")
+		}
 	}
 
 	return nil
 }
 
+// ClientCertificate returns the certificate of the first configured mutualTLS
+// scheme, or nil if there is none. TLS connections are shared between
+// requests, so the certificate is presented regardless of the operation.
+func (am *AuthManager) ClientCertificate() (*tls.Certificate, error) {
+	configs := am.snapshot()
+	names := make([]string, 0, len(configs))
+	for name, config := range configs {
+		if config.Type == AuthTypeMutualTLS {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return nil, nil
+	}
+	sort.Strings(names)
+
+	config, err := am.secrets.resolveSecrets(configs[names[0]])
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", names[0], err)
+	}
+
+	am.mu.Lock()
+	defer am.mu.Unlock()
+
+	key := config.CertFile + "\x00" + config.KeyFile
+	if cert, ok := am.certs[key]; ok {
+		return cert, nil
+	}
+	cert, err := loadClientCertificate(config.CertFile, config.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", names[0], err)
+	}
+	am.certs[key] = cert
+	return cert, nil
+}
+
 // ParseAuthScheme parses an auth scheme from the OpenAPI spec
 func (am *AuthManager) ParseAuthScheme(schemeName string) (*AuthConfig, error) {
 	if schemeName == "none" {
 		return &AuthConfig{Type: AuthTypeNone}, nil
 	}
 
+	if schemeName == CustomSigningScheme {
+		return &AuthConfig{
+			Type:             AuthTypeHMAC,
+			SigningTemplate:  DefaultSigningTemplate,
+			SigningAlgorithm: DefaultSigningAlgorithm,
+			SigningEncoding:  DefaultSigningEncoding,
+			SigningHeaders:   DefaultSigningHeaders,
+			SigningTimestamp: DefaultSigningTimestamp,
+		}, nil
+	}
+
 	if am.spec.Doc == nil || am.spec.Doc.Components == nil || am.spec.Doc.Components.SecuritySchemes == nil {
 		return nil, fmt.Errorf("no security schemes defined")
 	}
@@ -131,13 +418,23 @@ func (am *AuthManager) ParseAuthScheme(schemeName string) (*AuthConfig, error) {
 
 	switch scheme.Type {
 	case "http":
-		if scheme.Scheme == "bearer" {
+		switch strings.ToLower(scheme.Scheme) {
+		case "bearer":
 			config.Type = AuthTypeBearer
-		} else if scheme.Scheme == "basic" {
+		case "basic":
 			config.Type = AuthTypeBasic
+		case "digest":
+			config.Type = AuthTypeDigest
 		}
 
 	case "apiKey":
+		if authType, _ := scheme.Extensions[apiGatewayAuthTypeExtension].(string); strings.EqualFold(authType, "awsSigv4") {
+			config.Type = AuthTypeSigV4
+			if servers := am.spec.GetServers(); len(servers) > 0 {
+				config.Region, config.Service = sigV4Defaults(servers[0].Resolve(nil))
+			}
+			break
+		}
 		config.Type = AuthTypeAPIKey
 		config.KeyName = scheme.Name
 		config.APIKeyIn = scheme.In
@@ -145,6 +442,9 @@ func (am *AuthManager) ParseAuthScheme(schemeName string) (*AuthConfig, error) {
 	case "oauth2":
 		config.Type = AuthTypeOAuth2
 
+	case "mutualTLS":
+		config.Type = AuthTypeMutualTLS
+
 	default:
 		return nil, fmt.Errorf("unsupported auth type: %s", scheme.Type)
 	}
@@ -0,0 +1,164 @@
+package api
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+)
+
+// MediaType returns the response media type without parameters
+func (r *Response) MediaType() string {
+	contentType := r.Headers.Get("Content-Type")
+	if contentType == "" {
+		return ""
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return strings.ToLower(strings.TrimSpace(strings.Split(contentType, ";")[0]))
+	}
+	return mediaType
+}
+
+// IsBinary reports whether the response body should not be displayed as text
+func (r *Response) IsBinary() bool {
+	if len(r.BodyBytes) == 0 {
+		return false
+	}
+
+	mediaType := r.MediaType()
+	if mediaType == "" {
+		mediaType, _, _ = mime.ParseMediaType(http.DetectContentType(r.BodyBytes))
+	}
+
+	if isTextMediaType(mediaType) {
+		return !utf8.Valid(r.BodyBytes)
+	}
+	return true
+}
+
+func isTextMediaType(mediaType string) bool {
+	if strings.HasPrefix(mediaType, "text/") {
+		return true
+	}
+	if strings.HasSuffix(mediaType, "+json") || strings.HasSuffix(mediaType, "+xml") {
+		return true
+	}
+	switch mediaType {
+	case "application/json",
+		"application/xml",
+		"application/javascript",
+		"application/ecmascript",
+		"application/x-www-form-urlencoded",
+		"application/yaml",
+		"application/x-yaml",
+		"application/graphql",
+		"application/x-ndjson":
+		return true
+	}
+	return false
+}
+
+// BodySHA256 returns the hex encoded SHA-256 digest of the response body
+func (r *Response) BodySHA256() string {
+	sum := sha256.Sum256(r.BodyBytes)
+	return hex.EncodeToString(sum[:])
+}
+
+// BodySummary describes a binary body by type, size and hash
+func (r *Response) BodySummary() string {
+	mediaType := r.MediaType()
+	if mediaType == "" {
+		mediaType = "unknown type"
+	}
+
+	var b strings.Builder
+	b.WriteString(fmt.Sprintf("Binary content (%s)\n", mediaType))
+	b.WriteString(fmt.Sprintf("Size:   %s (%d bytes)\n", FormatBytes(int64(len(r.BodyBytes))), len(r.BodyBytes)))
+	b.WriteString(fmt.Sprintf("SHA256: %s\n", r.BodySHA256()))
+	if name := r.SuggestedFilename(); name != "" {
+		b.WriteString(fmt.Sprintf("File:   %s", name))
+	}
+	return b.String()
+}
+
+// SuggestedFilename returns a file name for saving the body, preferring Content-Disposition
+func (r *Response) SuggestedFilename() string {
+	if disposition := r.Headers.Get("Content-Disposition"); disposition != "" {
+		if _, params, err := mime.ParseMediaType(disposition); err == nil {
+			// mime.ParseMediaType decodes RFC 2231 filename* into filename
+			if name := sanitizeFilename(params["filename"]); name != "" {
+				return name
+			}
+		}
+	}
+
+	ext := ""
+	if mediaType := r.MediaType(); mediaType != "" {
+		if exts, err := mime.ExtensionsByType(mediaType); err == nil && len(exts) > 0 {
+			ext = exts[0]
+		}
+	}
+	return "response" + ext
+}
+
+// sanitizeFilename strips directories so a server cannot choose where files are written
+func sanitizeFilename(name string) string {
+	name = strings.ReplaceAll(name, "\\", "/")
+	name = filepath.Base(name)
+	if name == "." || name == "/" || name == ".." {
+		return ""
+	}
+	return name
+}
+
+// SaveBody writes the original response bytes to path and returns the written path.
+// An empty path or a directory uses the suggested file name. An existing file
+// is only replaced when overwrite is set, otherwise the error wraps os.ErrExist.
+func (r *Response) SaveBody(path string, overwrite bool) (string, error) {
+	if path == "" {
+		path = r.SuggestedFilename()
+	} else if info, err := os.Stat(path); err == nil && info.IsDir() {
+		path = filepath.Join(path, r.SuggestedFilename())
+	}
+
+	flags := os.O_WRONLY | os.O_CREATE | os.O_EXCL
+	if overwrite {
+		flags = os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	}
+	f, err := os.OpenFile(path, flags, 0o644)
+	if err != nil {
+		return path, fmt.Errorf("failed to create file: %w", err)
+	}
+
+	if _, err := io.Copy(f, bytes.NewReader(r.BodyBytes)); err != nil {
+		f.Close()
+		return path, fmt.Errorf("failed to write file: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return path, fmt.Errorf("failed to write file: %w", err)
+	}
+
+	return path, nil
+}
+
+// FormatBytes formats a byte count in human readable units
+func FormatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for v := n / unit; v >= unit; v /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
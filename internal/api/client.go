@@ -3,22 +3,65 @@ package api
 import (
 	"bytes"
 	"context"
+	"crypto/tls"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"net/http/httptrace"
+	"net/url"
 	"strings"
+	"sync/atomic"
 	"time"
+
+	"github.com/getkin/kin-openapi/openapi3"
 )
 
+// DefaultTimeout is used when neither the request nor the client sets a timeout
+const DefaultTimeout = 30 * time.Second
+
+// ErrTimeout is returned when a request does not complete within its timeout
+var ErrTimeout = errors.New("request timed out")
+
 // Request represents an API request
 type Request struct {
 	Method      string
-	Path        string
+	Path        string            // Escaped, with the path parameters filled in
+	Template    string            // Path with {name} placeholders for PathParams, when known
+	PathParams  map[string]string // Values substituted into Path, by parameter name
 	Headers     map[string]string
 	QueryParams map[string]string
 	Body        string
 	ContentType string
+	Servers     []Server                       // Path or operation level servers, overriding the base URL
+	Security    *openapi3.SecurityRequirements // Operation security, nil uses the document's
+	Timeout     time.Duration                  // Overrides the client timeout when non-zero
+	Progress    *Progress                      // Optional, tracks bytes received
+	Redirects   *RedirectPolicy                // Overrides the client policy when set
+	Stream      *StreamHandler                 // Receives SSE and NDJSON responses incrementally
+}
+
+// Progress reports how many response bytes have been received so far
+type Progress struct {
+	bytes atomic.Int64
+}
+
+// BytesRead returns the number of response body bytes read
+func (p *Progress) BytesRead() int64 {
+	return p.bytes.Load()
+}
+
+// progressReader counts bytes as they are read from the response body
+type progressReader struct {
+	r        io.Reader
+	progress *Progress
+}
+
+func (pr *progressReader) Read(b []byte) (int, error) {
+	n, err := pr.r.Read(b)
+	pr.progress.bytes.Add(int64(n))
+	return n, err
 }
 
 // Response represents an API response
@@ -26,56 +69,326 @@ type Response struct {
 	StatusCode int
 	Status     string
 	Headers    http.Header
-	Body       string
+	BodyBytes  []byte
 	Duration   time.Duration
+	Timing     *Timing
+	Signatures []Signature   // Strings signed by request signing schemes
+	Redirects  []RedirectHop // Redirects followed before the final response
+	Truncated  bool          // The hop limit was reached, the response is the last redirect
+	URL        string        // URL of the final response
+	Events     []StreamEvent // Events of a streamed response
 	Error      error
 }
 
 // Client handles HTTP requests to the API
 type Client struct {
 	baseURL    string
+	pinned     bool // The user chose baseURL, operation and path servers are ignored
 	httpClient *http.Client
+	transport  *http.Transport
 	authMgr    *AuthManager
+	timeout    time.Duration
+	serverVars map[string]map[string]string // By server URL template
+	tlsConfig  TLSConfig
+	clientCert *tls.Certificate
+	proxy      ProxyConfig
+	redirects  RedirectPolicy
 }
 
 // NewClient creates a new API client
 func NewClient(baseURL string, authMgr *AuthManager) *Client {
-	return &Client{
-		baseURL: strings.TrimSuffix(baseURL, "/"),
-		httpClient: &http.Client{
-			Timeout: 30 * time.Second,
-		},
-		authMgr: authMgr,
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	c := &Client{
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		httpClient: &http.Client{Transport: transport, CheckRedirect: checkRedirect},
+		transport:  transport,
+		authMgr:    authMgr,
+		timeout:    DefaultTimeout,
+		redirects:  DefaultRedirectPolicy,
+	}
+	transport.TLSClientConfig = &tls.Config{GetClientCertificate: c.getClientCertificate}
+	c.SetProxyConfig(ProxyConfig{})
+	return c
+}
+
+// SetProxyConfig changes the proxy used for new connections
+func (c *Client) SetProxyConfig(config ProxyConfig) error {
+	proxy, err := config.proxyFunc()
+	if err != nil {
+		return err
+	}
+
+	c.proxy = config
+	c.transport.Proxy = func(req *http.Request) (*url.URL, error) {
+		proxyURL, err := proxy(req)
+		if recorder, ok := req.Context().Value(timingRecorderKey{}).(*timingRecorder); ok && proxyURL != nil {
+			recorder.setProxy(proxyURL)
+		}
+		return proxyURL, err
+	}
+	c.transport.CloseIdleConnections()
+	return nil
+}
+
+// ProxyConfig returns the proxy settings in use
+func (c *Client) ProxyConfig() ProxyConfig {
+	return c.proxy
+}
+
+// SetTLSConfig applies TLS options to new connections
+func (c *Client) SetTLSConfig(config TLSConfig) error {
+	tlsConfig, err := config.ClientConfig()
+	if err != nil {
+		return err
+	}
+
+	c.clientCert = nil
+	if len(tlsConfig.Certificates) > 0 {
+		c.clientCert = &tlsConfig.Certificates[0]
+		tlsConfig.Certificates = nil
+	}
+	tlsConfig.GetClientCertificate = c.getClientCertificate
+
+	c.tlsConfig = config
+	c.transport.TLSClientConfig = tlsConfig
+	c.transport.CloseIdleConnections()
+	return nil
+}
+
+// TLSConfig returns the TLS options in use
+func (c *Client) TLSConfig() TLSConfig {
+	return c.tlsConfig
+}
+
+// Clone returns a client with the same settings and a connection pool of its
+// own that keeps up to idleConns idle connections per host, e.g. for a
+// benchmark whose workers should not share connections with other requests
+func (c *Client) Clone(idleConns int) *Client {
+	clone := *c
+	clone.transport = c.transport.Clone()
+	clone.transport.MaxIdleConnsPerHost = max(idleConns, http.DefaultMaxIdleConnsPerHost)
+	clone.transport.TLSClientConfig.GetClientCertificate = clone.getClientCertificate
+	clone.httpClient = &http.Client{Transport: clone.transport, CheckRedirect: checkRedirect}
+	clone.serverVars = make(map[string]map[string]string, len(c.serverVars))
+	for server, values := range c.serverVars {
+		clone.serverVars[server] = values
 	}
+	return &clone
 }
 
-// Send sends an HTTP request
+// CloseIdleConnections closes kept-alive connections, e.g. after the client
+// certificate of a mutualTLS scheme changed
+func (c *Client) CloseIdleConnections() {
+	c.transport.CloseIdleConnections()
+}
+
+// getClientCertificate presents the certificate of a configured mutualTLS scheme,
+// falling back to the one from the TLS options
+func (c *Client) getClientCertificate(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	if c.authMgr != nil {
+		cert, err := c.authMgr.ClientCertificate()
+		if err != nil {
+			return nil, err
+		}
+		if cert != nil {
+			return cert, nil
+		}
+	}
+	if c.clientCert != nil {
+		return c.clientCert, nil
+	}
+	// An empty certificate tells the server that none is available
+	return &tls.Certificate{}, nil
+}
+
+// SetBaseURL changes the base URL requests are sent to
+func (c *Client) SetBaseURL(baseURL string) {
+	c.baseURL = strings.TrimSuffix(baseURL, "/")
+}
+
+// PinBaseURL sends every request to the base URL, ignoring operation and path
+// level servers. It is set when the user chose the base URL, e.g. to point at
+// staging or a local mock.
+func (c *Client) PinBaseURL(pinned bool) {
+	c.pinned = pinned
+}
+
+// SetServerVariables sets the values used to resolve the variables of the
+// server with the given URL template
+func (c *Client) SetServerVariables(server string, values map[string]string) {
+	if c.serverVars == nil {
+		c.serverVars = make(map[string]map[string]string)
+	}
+	c.serverVars[server] = values
+}
+
+// SetTimeout sets the default timeout for requests, zero disables it
+func (c *Client) SetTimeout(timeout time.Duration) {
+	c.timeout = timeout
+}
+
+// Timeout returns the default request timeout
+func (c *Client) Timeout() time.Duration {
+	return c.timeout
+}
+
+// SetRedirectPolicy sets whether and how far redirects are followed by default
+func (c *Client) SetRedirectPolicy(policy RedirectPolicy) {
+	c.redirects = policy
+}
+
+// RedirectPolicy returns the default redirect policy
+func (c *Client) RedirectPolicy() RedirectPolicy {
+	return c.redirects
+}
+
+// Send sends an HTTP request. Cancelling ctx aborts the request.
 func (c *Client) Send(ctx context.Context, req *Request) *Response {
 	start := time.Now()
 	resp := &Response{}
 
-	// Build URL
-	url := c.baseURL + req.Path
+	timeout := c.timeout
+	if req.Timeout > 0 {
+		timeout = req.Timeout
+	}
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+	var deadline *time.Timer
+	if timeout > 0 {
+		// A timer rather than a context deadline, so that it can be stopped once a stream opens
+		deadline = time.AfterFunc(timeout, func() { cancel(context.DeadlineExceeded) })
+		defer deadline.Stop()
+	}
+
+	url := c.URL(req)
+
+	// Create HTTP request
+	recorder := newTimingRecorder(start)
+	ctx = httptrace.WithClientTrace(ctx, recorder.trace())
+	ctx = context.WithValue(ctx, timingRecorderKey{}, recorder)
+
+	redirects := &redirectState{policy: c.redirects}
+	if req.Redirects != nil {
+		redirects.policy = *req.Redirects
+	}
+	ctx = context.WithValue(ctx, redirectStateKey{}, redirects)
+
+	httpReq, signatures, err := c.newHTTPRequest(ctx, req, url)
+	if err != nil {
+		resp.Error = err
+		return resp
+	}
+	resp.Signatures = signatures
+
+	// Send request
+	httpResp, err := c.httpClient.Do(httpReq)
+
+	// Retry once when the server issued a new authentication challenge
+	if err == nil && c.authMgr != nil && c.authMgr.HandleChallenge(httpResp, req.Security) {
+		io.Copy(io.Discard, httpResp.Body)
+		httpResp.Body.Close()
+
+		httpReq, resp.Signatures, err = c.newHTTPRequest(ctx, req, url)
+		if err != nil {
+			resp.Error = err
+			return resp
+		}
+		httpResp, err = c.httpClient.Do(httpReq)
+	}
+
+	resp.Redirects, resp.Truncated = redirects.redirects()
+	if err != nil {
+		resp.Error = requestError(ctx, err, timeout)
+		resp.Duration = time.Since(start)
+		resp.Timing = recorder.finish(time.Now())
+		return resp
+	}
+	defer httpResp.Body.Close()
+
+	resp.StatusCode = httpResp.StatusCode
+	resp.Status = httpResp.Status
+	resp.Headers = httpResp.Header
+	resp.URL = httpResp.Request.URL.String()
+
+	if kind := streamKindOf(httpResp.Header.Get("Content-Type")); req.Stream != nil && kind != streamNone {
+		// Streams stay open as long as the caller wants, the timeout only covered the headers
+		if deadline != nil {
+			deadline.Stop()
+		}
+		c.readStream(ctx, req, url, httpResp, resp, kind)
+	} else {
+		// Read response
+		var body io.Reader = httpResp.Body
+		if req.Progress != nil {
+			body = &progressReader{r: body, progress: req.Progress}
+		}
+		bodyBytes, err := io.ReadAll(body)
+		if err != nil {
+			if ctx.Err() != nil {
+				resp.Error = requestError(ctx, err, timeout)
+			} else {
+				resp.Error = fmt.Errorf("failed to read response: %w", err)
+			}
+		} else {
+			resp.BodyBytes = bodyBytes
+		}
+	}
+
+	end := time.Now()
+	resp.Duration = end.Sub(start)
+	resp.Timing = recorder.finish(end)
+
+	return resp
+}
+
+// URL builds the full URL of a request
+func (c *Client) URL(req *Request) string {
+	baseURL := c.baseURL
+	if len(req.Servers) > 0 && !c.pinned {
+		baseURL = strings.TrimSuffix(req.Servers[0].Resolve(c.serverVars[req.Servers[0].URL]), "/")
+	}
+	u := baseURL + req.Path
 	if len(req.QueryParams) > 0 {
-		url += "?"
-		params := []string{}
+		query := make(url.Values, len(req.QueryParams))
 		for k, v := range req.QueryParams {
-			params = append(params, fmt.Sprintf("%s=%s", k, v))
+			query.Set(k, v)
 		}
-		url += strings.Join(params, "&")
+		u += "?" + query.Encode()
+	}
+	return u
+}
+
+// SetPathParam sets a path parameter and fills it into the path
+func (r *Request) SetPathParam(name, value string) {
+	if r.PathParams == nil {
+		r.PathParams = make(map[string]string)
+	}
+	r.PathParams[name] = value
+	r.fillPath()
+}
+
+// fillPath builds Path from Template with the escaped path parameters
+func (r *Request) fillPath() {
+	if r.Template == "" {
+		return
 	}
+	r.Path = r.Template
+	for name, value := range r.PathParams {
+		r.Path = strings.ReplaceAll(r.Path, "{"+name+"}", url.PathEscape(value))
+	}
+}
 
+// newHTTPRequest builds an authenticated HTTP request, it is called again when retrying
+func (c *Client) newHTTPRequest(ctx context.Context, req *Request, url string) (*http.Request, []Signature, error) {
 	// Create request body
 	var bodyReader io.Reader
 	if req.Body != "" {
 		bodyReader = bytes.NewBufferString(req.Body)
 	}
 
-	// Create HTTP request
 	httpReq, err := http.NewRequestWithContext(ctx, req.Method, url, bodyReader)
 	if err != nil {
-		resp.Error = fmt.Errorf("failed to create request: %w", err)
-		return resp
+		return nil, nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	// Apply headers
@@ -87,54 +400,49 @@ func (c *Client) Send(ctx context.Context, req *Request) *Response {
 	}
 
 	// Apply authentication
+	var signatures []Signature
 	if c.authMgr != nil {
-		if err := c.authMgr.ApplyAuth(httpReq); err != nil {
-			resp.Error = fmt.Errorf("auth error: %w", err)
-			return resp
+		signatures, err = c.authMgr.ApplyAuth(httpReq, req.Security)
+		if err != nil {
+			return nil, nil, fmt.Errorf("auth error: %w", err)
 		}
 	}
 
-	// Send request
-	httpResp, err := c.httpClient.Do(httpReq)
-	if err != nil {
-		resp.Error = fmt.Errorf("request failed: %w", err)
-		resp.Duration = time.Since(start)
-		return resp
-	}
-	defer httpResp.Body.Close()
+	return httpReq, signatures, nil
+}
 
-	// Read response
-	bodyBytes, err := io.ReadAll(httpResp.Body)
-	if err != nil {
-		resp.Error = fmt.Errorf("failed to read response: %w", err)
-	} else {
-		resp.Body = string(bodyBytes)
+// requestError explains failures caused by cancellation or timeout
+func requestError(ctx context.Context, err error, timeout time.Duration) error {
+	switch {
+	case errors.Is(context.Cause(ctx), context.DeadlineExceeded):
+		return fmt.Errorf("%w after %s", ErrTimeout, timeout)
+	case errors.Is(ctx.Err(), context.Canceled):
+		return fmt.Errorf("request cancelled")
+	default:
+		return fmt.Errorf("request failed: %w", err)
 	}
-
-	resp.StatusCode = httpResp.StatusCode
-	resp.Status = httpResp.Status
-	resp.Headers = httpResp.Header
-	resp.Duration = time.Since(start)
-
-	return resp
 }
 
 // FormatResponseBody formats the response body for display
 func (r *Response) FormatResponseBody() string {
-	if r.Body == "" {
+	if len(r.BodyBytes) == 0 {
 		return "(empty)"
 	}
 
+	if r.IsBinary() {
+		return r.BodySummary()
+	}
+
 	// Try to pretty-print JSON
 	contentType := r.Headers.Get("Content-Type")
 	if strings.Contains(contentType, "application/json") {
 		var parsed interface{}
-		if err := json.Unmarshal([]byte(r.Body), &parsed); err == nil {
+		if err := json.Unmarshal(r.BodyBytes, &parsed); err == nil {
 			if formatted, err := json.MarshalIndent(parsed, "", "  "); err == nil {
 				return string(formatted)
 			}
 		}
 	}
 
-	return r.Body
+	return string(r.BodyBytes)
 }
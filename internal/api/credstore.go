@@ -0,0 +1,208 @@
+package api
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+const (
+	credentialKDFIterations = 600000
+	credentialSaltSize      = 16
+)
+
+// PassphraseEnvVar unlocks stored credentials without prompting
+const PassphraseEnvVar = "APIMUG_PASSPHRASE"
+
+// ErrNoStoredCredentials is returned when nothing is stored for a spec and environment
+var ErrNoStoredCredentials = errors.New("no stored credentials")
+
+// CredentialStore persists auth configs on disk, encrypted with a key derived
+// from a passphrase. Entries are kept per spec and environment.
+type CredentialStore struct {
+	path string
+}
+
+type credentialFile struct {
+	Version int                         `json:"version"`
+	Entries map[string]*credentialEntry `json:"entries"`
+}
+
+type credentialEntry struct {
+	Salt       []byte `json:"salt"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// NewCredentialStore creates a store backed by the file at path
+func NewCredentialStore(path string) *CredentialStore {
+	return &CredentialStore{path: path}
+}
+
+// DefaultCredentialStorePath returns the credentials file in the user config directory
+func DefaultCredentialStorePath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate config directory: %w", err)
+	}
+	return filepath.Join(dir, "apimug", "credentials.json"), nil
+}
+
+// Path returns the location of the credentials file
+func (cs *CredentialStore) Path() string {
+	return cs.path
+}
+
+func credentialKey(specKey, env string) string {
+	return specKey + "#" + env
+}
+
+// Has reports whether credentials are stored for a spec and environment
+func (cs *CredentialStore) Has(specKey, env string) bool {
+	file, err := cs.read()
+	if err != nil {
+		return false
+	}
+	_, ok := file.Entries[credentialKey(specKey, env)]
+	return ok
+}
+
+// Load decrypts the credentials stored for a spec and environment
+func (cs *CredentialStore) Load(specKey, env, passphrase string) (map[string]*AuthConfig, error) {
+	file, err := cs.read()
+	if err != nil {
+		return nil, err
+	}
+
+	entry, ok := file.Entries[credentialKey(specKey, env)]
+	if !ok {
+		return nil, ErrNoStoredCredentials
+	}
+
+	gcm, err := credentialCipher(passphrase, entry.Salt)
+	if err != nil {
+		return nil, err
+	}
+
+	plaintext, err := gcm.Open(nil, entry.Nonce, entry.Ciphertext, []byte(credentialKey(specKey, env)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt credentials: wrong passphrase?")
+	}
+
+	var configs map[string]*AuthConfig
+	if err := json.Unmarshal(plaintext, &configs); err != nil {
+		return nil, fmt.Errorf("failed to parse stored credentials: %w", err)
+	}
+	return configs, nil
+}
+
+// Save encrypts and stores credentials for a spec and environment
+func (cs *CredentialStore) Save(specKey, env, passphrase string, configs map[string]*AuthConfig) error {
+	if passphrase == "" {
+		return fmt.Errorf("a passphrase is required to store credentials")
+	}
+
+	file, err := cs.read()
+	if err != nil {
+		return err
+	}
+
+	plaintext, err := json.Marshal(configs)
+	if err != nil {
+		return fmt.Errorf("failed to encode credentials: %w", err)
+	}
+
+	salt := make([]byte, credentialSaltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return fmt.Errorf("failed to generate salt: %w", err)
+	}
+
+	gcm, err := credentialCipher(passphrase, salt)
+	if err != nil {
+		return err
+	}
+
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	file.Entries[credentialKey(specKey, env)] = &credentialEntry{
+		Salt:       salt,
+		Nonce:      nonce,
+		Ciphertext: gcm.Seal(nil, nonce, plaintext, []byte(credentialKey(specKey, env))),
+	}
+
+	return cs.write(file)
+}
+
+// Delete removes the credentials stored for a spec and environment
+func (cs *CredentialStore) Delete(specKey, env string) error {
+	file, err := cs.read()
+	if err != nil {
+		return err
+	}
+	delete(file.Entries, credentialKey(specKey, env))
+	return cs.write(file)
+}
+
+func (cs *CredentialStore) read() (*credentialFile, error) {
+	file := &credentialFile{Version: 1, Entries: make(map[string]*credentialEntry)}
+
+	data, err := os.ReadFile(cs.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return file, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read credential store: %w", err)
+	}
+
+	if err := json.Unmarshal(data, file); err != nil {
+		return nil, fmt.Errorf("failed to parse credential store: %w", err)
+	}
+	if file.Entries == nil {
+		file.Entries = make(map[string]*credentialEntry)
+	}
+	return file, nil
+}
+
+func (cs *CredentialStore) write(file *credentialFile) error {
+	if err := os.MkdirAll(filepath.Dir(cs.path), 0o700); err != nil {
+		return fmt.Errorf("failed to create credential store directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(file, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode credential store: %w", err)
+	}
+
+	// Write to a temporary file first so a crash cannot truncate existing credentials
+	tmp := cs.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write credential store: %w", err)
+	}
+	if err := os.Rename(tmp, cs.path); err != nil {
+		return fmt.Errorf("failed to write credential store: %w", err)
+	}
+	return nil
+}
+
+func credentialCipher(passphrase string, salt []byte) (cipher.AEAD, error) {
+	key, err := pbkdf2.Key(sha256.New, passphrase, salt, credentialKDFIterations, 32)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive key: %w", err)
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,90 @@
+package api
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCredentialStore(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "apimug", "credentials.json")
+	store := NewCredentialStore(path)
+	configs := map[string]*AuthConfig{
+		"bearerAuth": {Type: AuthTypeBearer, Token: "tok123", SecretRefs: true},
+		"basicAuth":  {Type: AuthTypeBasic, Username: "alice", Password: "s3cret"},
+	}
+
+	if store.Has("spec", "dev") {
+		t.Fatal("Has() = true before anything was saved")
+	}
+	if err := store.Save("spec", "dev", "correct horse", configs); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+	if !store.Has("spec", "dev") {
+		t.Error("Has() = false after Save()")
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "tok123") || strings.Contains(string(data), "s3cret") {
+		t.Error("credentials file holds a secret in plain text")
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if mode := info.Mode().Perm(); mode != 0o600 {
+		t.Errorf("credentials file mode = %v, want 0600", mode)
+	}
+
+	tests := []struct {
+		name       string
+		specKey    string
+		env        string
+		passphrase string
+		want       map[string]*AuthConfig
+		err        string
+	}{
+		{"round trip", "spec", "dev", "correct horse", configs, ""},
+		{"wrong passphrase", "spec", "dev", "wrong horse", nil, "wrong passphrase"},
+		{"empty passphrase", "spec", "dev", "", nil, "wrong passphrase"},
+		{"other environment", "spec", "prod", "correct horse", nil, ErrNoStoredCredentials.Error()},
+		{"other spec", "other", "dev", "correct horse", nil, ErrNoStoredCredentials.Error()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := store.Load(tt.specKey, tt.env, tt.passphrase)
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("Load() error = %v, want %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Load() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+
+	if err := store.Delete("spec", "dev"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if _, err := store.Load("spec", "dev", "correct horse"); !errors.Is(err, ErrNoStoredCredentials) {
+		t.Errorf("Load() after Delete() error = %v, want %v", err, ErrNoStoredCredentials)
+	}
+}
+
+func TestCredentialStoreSaveNeedsPassphrase(t *testing.T) {
+	store := NewCredentialStore(filepath.Join(t.TempDir(), "credentials.json"))
+	if err := store.Save("spec", "dev", "", map[string]*AuthConfig{}); err == nil {
+		t.Error("Save() with an empty passphrase succeeded")
+	}
+}
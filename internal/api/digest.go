@@ -0,0 +1,291 @@
+package api
+
+import (
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// digestChallenge is a parsed WWW-Authenticate Digest challenge. The nonce is
+// reused for following requests with an increasing nonce count until the
+// server marks it stale.
+type digestChallenge struct {
+	mu        sync.Mutex
+	realm     string
+	nonce     string
+	opaque    string
+	algorithm string
+	qop       string
+	userhash  bool
+	nc        uint32
+}
+
+// parseDigestChallenges returns the strongest Digest challenge among the
+// WWW-Authenticate headers, or nil if the server did not offer Digest
+func parseDigestChallenges(headers []string) (*digestChallenge, bool) {
+	var best *digestChallenge
+	stale := false
+
+	for _, header := range headers {
+		scheme, rest, _ := strings.Cut(strings.TrimSpace(header), " ")
+		if !strings.EqualFold(scheme, "Digest") {
+			continue
+		}
+
+		params := parseAuthParams(rest)
+		challenge := &digestChallenge{
+			realm:     params["realm"],
+			nonce:     params["nonce"],
+			opaque:    params["opaque"],
+			algorithm: strings.ToUpper(params["algorithm"]),
+			userhash:  strings.EqualFold(params["userhash"], "true"),
+		}
+		if challenge.algorithm == "" {
+			challenge.algorithm = "MD5"
+		}
+		if digestHash(challenge.algorithm) == nil || challenge.nonce == "" {
+			continue
+		}
+
+		// Prefer qop=auth; auth-int is used only when it is the sole option
+		if qop, ok := params["qop"]; ok {
+			options := strings.Split(qop, ",")
+			for _, option := range options {
+				if strings.TrimSpace(option) == "auth" {
+					challenge.qop = "auth"
+				}
+			}
+			if challenge.qop == "" {
+				for _, option := range options {
+					if strings.TrimSpace(option) == "auth-int" {
+						challenge.qop = "auth-int"
+					}
+				}
+			}
+		}
+
+		if best == nil || digestStrength(challenge.algorithm) > digestStrength(best.algorithm) {
+			best = challenge
+			stale = strings.EqualFold(params["stale"], "true")
+		}
+	}
+
+	return best, stale
+}
+
+// parseAuthParams parses comma separated key=value pairs with optional quoting
+func parseAuthParams(s string) map[string]string {
+	params := make(map[string]string)
+
+	for len(s) > 0 {
+		s = strings.TrimLeft(s, " ,\t")
+		key, rest, ok := strings.Cut(s, "=")
+		if !ok {
+			break
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		rest = strings.TrimLeft(rest, " \t")
+
+		var value string
+		if strings.HasPrefix(rest, `"`) {
+			var b strings.Builder
+			i := 1
+			for ; i < len(rest); i++ {
+				if rest[i] == '\\' && i+1 < len(rest) {
+					i++
+					b.WriteByte(rest[i])
+					continue
+				}
+				if rest[i] == '"' {
+					break
+				}
+				b.WriteByte(rest[i])
+			}
+			value = b.String()
+			if i < len(rest) {
+				i++
+			}
+			s = rest[i:]
+		} else {
+			end := strings.IndexByte(rest, ',')
+			if end < 0 {
+				end = len(rest)
+			}
+			value = strings.TrimSpace(rest[:end])
+			s = rest[end:]
+		}
+
+		params[key] = value
+	}
+
+	return params
+}
+
+func digestHash(algorithm string) func() hash.Hash {
+	switch strings.TrimSuffix(algorithm, "-SESS") {
+	case "MD5":
+		return md5.New
+	case "SHA-256":
+		return sha256.New
+	}
+	return nil
+}
+
+func digestStrength(algorithm string) int {
+	if strings.HasPrefix(algorithm, "SHA-256") {
+		return 2
+	}
+	return 1
+}
+
+// authorization computes the Authorization header for the next request using this challenge
+func (dc *digestChallenge) authorization(username, password, method, uri string, body []byte) (string, error) {
+	cnonceBytes := make([]byte, 16)
+	if _, err := rand.Read(cnonceBytes); err != nil {
+		return "", fmt.Errorf("failed to generate cnonce: %w", err)
+	}
+	return dc.authorizationWithCnonce(username, password, method, uri, body, hex.EncodeToString(cnonceBytes)), nil
+}
+
+// authorizationWithCnonce computes the Authorization header with the given client nonce
+func (dc *digestChallenge) authorizationWithCnonce(username, password, method, uri string, body []byte, cnonce string) string {
+	dc.mu.Lock()
+	dc.nc++
+	nc := fmt.Sprintf("%08x", dc.nc)
+	dc.mu.Unlock()
+
+	newHash := digestHash(dc.algorithm)
+	h := func(parts ...string) string {
+		hasher := newHash()
+		io.WriteString(hasher, strings.Join(parts, ":"))
+		return hex.EncodeToString(hasher.Sum(nil))
+	}
+
+	ha1 := h(username, dc.realm, password)
+	if strings.HasSuffix(dc.algorithm, "-SESS") {
+		ha1 = h(ha1, dc.nonce, cnonce)
+	}
+
+	ha2 := h(method, uri)
+	if dc.qop == "auth-int" {
+		bodyHasher := newHash()
+		bodyHasher.Write(body)
+		ha2 = h(method, uri, hex.EncodeToString(bodyHasher.Sum(nil)))
+	}
+
+	var response string
+	if dc.qop == "" {
+		response = h(ha1, dc.nonce, ha2)
+	} else {
+		response = h(ha1, dc.nonce, nc, cnonce, dc.qop, ha2)
+	}
+
+	user := username
+	if dc.userhash {
+		user = h(username, dc.realm)
+	}
+
+	parts := []string{
+		fmt.Sprintf(`username="%s"`, quoteDigest(user)),
+		fmt.Sprintf(`realm="%s"`, quoteDigest(dc.realm)),
+		fmt.Sprintf(`nonce="%s"`, quoteDigest(dc.nonce)),
+		fmt.Sprintf(`uri="%s"`, quoteDigest(uri)),
+		fmt.Sprintf(`algorithm=%s`, dc.algorithm),
+		fmt.Sprintf(`response="%s"`, response),
+	}
+	if dc.qop != "" {
+		parts = append(parts, "qop="+dc.qop, "nc="+nc, fmt.Sprintf(`cnonce="%s"`, cnonce))
+	}
+	if dc.opaque != "" {
+		parts = append(parts, fmt.Sprintf(`opaque="%s"`, quoteDigest(dc.opaque)))
+	}
+	if dc.userhash {
+		parts = append(parts, "userhash=true")
+	}
+
+	return "Digest " + strings.Join(parts, ", ")
+}
+
+func quoteDigest(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s)
+}
+
+// applyDigest authorizes req with a previously received challenge. Without one
+// the request is sent unauthenticated and the server's 401 provides it.
+func (am *AuthManager) applyDigest(req *http.Request, schemeName string, config *AuthConfig) error {
+	if config.Username == "" || config.Password == "" {
+		return fmt.Errorf("username and password are required")
+	}
+
+	am.mu.Lock()
+	challenge := am.digest[schemeName]
+	am.mu.Unlock()
+	if challenge == nil {
+		return nil
+	}
+
+	body, err := readRequestBody(req)
+	if err != nil {
+		return err
+	}
+
+	header, err := challenge.authorization(config.Username, config.Password, req.Method, req.URL.RequestURI(), body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", header)
+	return nil
+}
+
+// HandleChallenge records a Digest challenge from a 401 response. It reports
+// whether the request should be retried because a new nonce is available.
+func (am *AuthManager) HandleChallenge(resp *http.Response, security *openapi3.SecurityRequirements) bool {
+	if resp.StatusCode != http.StatusUnauthorized {
+		return false
+	}
+
+	challenge, stale := parseDigestChallenges(resp.Header.Values("WWW-Authenticate"))
+	if challenge == nil {
+		return false
+	}
+
+	configs := am.snapshot()
+	for _, name := range am.selectSchemes(configs, security) {
+		config := configs[name]
+		if config == nil || config.Type != AuthTypeDigest {
+			continue
+		}
+
+		am.mu.Lock()
+		previous := am.digest[name]
+		am.digest[name] = challenge
+		am.mu.Unlock()
+
+		// A rejected request that already used a fresh nonce means wrong credentials
+		return previous == nil || stale || previous.nonce != challenge.nonce
+	}
+
+	return false
+}
+
+// readRequestBody returns the request body without consuming it
+func readRequestBody(req *http.Request) ([]byte, error) {
+	if req.GetBody == nil || req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read request body: %w", err)
+	}
+	defer body.Close()
+	return io.ReadAll(body)
+}
@@ -0,0 +1,82 @@
+package api
+
+import (
+	"strings"
+	"testing"
+)
+
+// The examples of RFC 7616 section 3.9.1
+const (
+	rfc7616Realm  = "http-auth@example.org"
+	rfc7616Nonce  = "7ypf/xlj9XXwfDPEoM4URrv/xwf94BcCAzFZH4GiTo0v"
+	rfc7616Opaque = "FQhe/qaU925kfnzjCev0ciny7QMkPqMAFRtzCUYo5tdS"
+	rfc7616Cnonce = "f2/wE4q74E6zIJEtWaHKaf5wv/H5QzzpXusqGemxURZJ"
+)
+
+func rfc7616Challenge(algorithm string) string {
+	return `Digest realm="` + rfc7616Realm + `", qop="auth, auth-int", algorithm=` + algorithm +
+		`, nonce="` + rfc7616Nonce + `", opaque="` + rfc7616Opaque + `"`
+}
+
+func TestDigestAuthorization(t *testing.T) {
+	tests := []struct {
+		name      string
+		algorithm string
+		response  string
+	}{
+		{"MD5", "MD5", "8ca523f5e9506fed4657c9700eebdbec"},
+		{"SHA-256", "SHA-256", "753927fa0e85d155564e2e272a28d1802ca10daf4496794697cf8db5856cb6c1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			challenge, stale := parseDigestChallenges([]string{rfc7616Challenge(tt.algorithm)})
+			if challenge == nil || stale {
+				t.Fatalf("parseDigestChallenges() = %v, %v", challenge, stale)
+			}
+			if challenge.qop != "auth" {
+				t.Errorf("qop = %q, want auth", challenge.qop)
+			}
+
+			header := challenge.authorizationWithCnonce("Mufasa", "Circle of Life", "GET", "/dir/index.html", nil, rfc7616Cnonce)
+			want := []string{
+				`username="Mufasa"`,
+				`realm="` + rfc7616Realm + `"`,
+				`uri="/dir/index.html"`,
+				`algorithm=` + tt.algorithm,
+				`response="` + tt.response + `"`,
+				`qop=auth`,
+				`nc=00000001`,
+				`cnonce="` + rfc7616Cnonce + `"`,
+				`opaque="` + rfc7616Opaque + `"`,
+			}
+			for _, part := range want {
+				if !strings.Contains(header, part) {
+					t.Errorf("header %q lacks %s", header, part)
+				}
+			}
+		})
+	}
+}
+
+func TestParseDigestChallengesPrefersSHA256(t *testing.T) {
+	challenge, _ := parseDigestChallenges([]string{
+		`Basic realm="other"`,
+		rfc7616Challenge("MD5"),
+		rfc7616Challenge("SHA-256"),
+	})
+	if challenge == nil || challenge.algorithm != "SHA-256" {
+		t.Fatalf("parseDigestChallenges() = %+v, want the SHA-256 challenge", challenge)
+	}
+	if challenge.realm != rfc7616Realm || challenge.nonce != rfc7616Nonce || challenge.opaque != rfc7616Opaque {
+		t.Errorf("parseDigestChallenges() = %+v", challenge)
+	}
+}
+
+func TestDigestNonceCount(t *testing.T) {
+	challenge, _ := parseDigestChallenges([]string{rfc7616Challenge("MD5")})
+	for _, want := range []string{"nc=00000001", "nc=00000002"} {
+		if header := challenge.authorizationWithCnonce("Mufasa", "Circle of Life", "GET", "/", nil, rfc7616Cnonce); !strings.Contains(header, want) {
+			t.Errorf("header %q lacks %s", header, want)
+		}
+	}
+}
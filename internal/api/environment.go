@@ -0,0 +1,200 @@
+package api
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultEnvironment is used when no environment is selected
+const DefaultEnvironment = "default"
+
+// Environment holds the connection settings for one deployment of an API,
+// e.g. staging or production
+type Environment struct {
+	Name      string      `yaml:"-"`
+	Path      string      `yaml:"-"` // Environments file the environment is saved to
+	BaseURL   string      `yaml:"base_url"`
+	TLS       TLSConfig   `yaml:"tls"`
+	Proxy     ProxyConfig `yaml:"proxy"`
+	Variables Variables   `yaml:"variables"` // Referenced as {{name}} in requests
+}
+
+// DefaultEnvironmentsPath returns the environments file in the user config directory
+func DefaultEnvironmentsPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate config directory: %w", err)
+	}
+	return filepath.Join(dir, "apimug", "environments.yaml"), nil
+}
+
+// LoadEnvironments reads environments keyed by name from a YAML file. A missing
+// file yields no environments. Relative file paths are resolved against the
+// directory of the environments file.
+func LoadEnvironments(path string) (map[string]*Environment, error) {
+	envs := make(map[string]*Environment)
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return envs, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read environments: %w", err)
+	}
+
+	if err := yaml.Unmarshal(data, &envs); err != nil {
+		return nil, fmt.Errorf("failed to parse environments: %w", err)
+	}
+
+	dir := filepath.Dir(path)
+	for name, env := range envs {
+		if env == nil {
+			env = &Environment{}
+			envs[name] = env
+		}
+		env.Name = name
+		env.Path = path
+		for _, p := range []*string{&env.TLS.CertFile, &env.TLS.KeyFile, &env.TLS.CAFile} {
+			*p = resolveConfigPath(dir, *p)
+		}
+	}
+
+	return envs, nil
+}
+
+// SaveVariables sets variables of the environment and writes them to its
+// environments file, replacing earlier values
+func (e *Environment) SaveVariables(vars Variables) error {
+	if e.Path == "" {
+		return fmt.Errorf("environment %q has no environments file", e.Name)
+	}
+	if _, err := MergeEnvironment(e.Path, e.Name, "", vars, true); err != nil {
+		return err
+	}
+	if e.Variables == nil {
+		e.Variables = make(Variables)
+	}
+	for name, value := range vars {
+		e.Variables[name] = value
+	}
+	return nil
+}
+
+// resolveConfigPath expands ~ and makes a path relative to dir absolute
+func resolveConfigPath(dir, path string) string {
+	if path == "" {
+		return ""
+	}
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			path = filepath.Join(home, strings.TrimPrefix(path, "~"))
+		}
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(dir, path)
+	}
+	return path
+}
+
+// MergeEnvironment adds variables to an environment in the environments file,
+// creating either when missing, and sets its base URL when it has none.
+// Variables and a base URL already set to other values are kept unless replace
+// is set; either way their names are returned. Other settings and comments in
+// the file are kept.
+func MergeEnvironment(path, name, baseURL string, vars Variables, replace bool) ([]string, error) {
+	var doc yaml.Node
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("failed to read environments: %w", err)
+	}
+	if err == nil {
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return nil, fmt.Errorf("failed to parse environments: %w", err)
+		}
+	}
+	if len(doc.Content) == 0 {
+		doc = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode}}}
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("failed to parse environments: expected environments keyed by name")
+	}
+
+	var conflicts []string
+	set := func(node *yaml.Node, key, value string) {
+		if current := scalarValue(node, key); current != nil {
+			if *current == value {
+				return
+			}
+			conflicts = append(conflicts, key)
+			if !replace {
+				return
+			}
+		}
+		*mappingValue(node, key) = yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value}
+	}
+
+	env := mappingValue(root, name)
+	if baseURL != "" {
+		set(env, "base_url", baseURL)
+	}
+	variables := mappingValue(env, "variables")
+	names := make([]string, 0, len(vars))
+	for name := range vars {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		set(variables, name, vars[name])
+	}
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return nil, fmt.Errorf("failed to encode environments: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return nil, fmt.Errorf("failed to write environments: %w", err)
+	}
+	// Variables often hold credentials
+	if err := os.WriteFile(path, buf.Bytes(), 0o600); err != nil {
+		return nil, fmt.Errorf("failed to write environments: %w", err)
+	}
+	return conflicts, nil
+}
+
+// scalarValue returns the value of key in a mapping node, nil when it is
+// missing or empty
+func scalarValue(node *yaml.Node, key string) *string {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if value := node.Content[i+1]; node.Content[i].Value == key && value.Kind == yaml.ScalarNode && value.Tag != "!!null" && value.Value != "" {
+			return &value.Value
+		}
+	}
+	return nil
+}
+
+// mappingValue returns the value of key in a mapping node, adding an empty
+// mapping when missing. An empty value is turned into a mapping too.
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			value := node.Content[i+1]
+			if value.Kind == yaml.ScalarNode && value.Tag == "!!null" {
+				*value = yaml.Node{Kind: yaml.MappingNode}
+			}
+			return value
+		}
+	}
+	value := &yaml.Node{Kind: yaml.MappingNode}
+	node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: key}, value)
+	return value
+}
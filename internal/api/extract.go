@@ -0,0 +1,200 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Extraction copies a value from a response into a variable
+type Extraction struct {
+	Var    string `yaml:"var"`
+	From   string `yaml:"from,omitempty"`   // body (default), header or status
+	Path   string `yaml:"path,omitempty"`   // JSON path into the body, e.g. $.items[0].id
+	Header string `yaml:"header,omitempty"` // Header name when From is header
+	Regex  string `yaml:"regex,omitempty"`  // Applied last; the first group, or the whole match
+	Save   bool   `yaml:"save,omitempty"`   // Also write the value to the environments file
+}
+
+// Extract evaluates the rule against a response
+func (e Extraction) Extract(resp *Response) (string, error) {
+	var value string
+
+	switch strings.ToLower(e.From) {
+	case "", "body":
+		value = string(resp.BodyBytes)
+		if e.Path != "" {
+			data, err := DecodeJSON(resp.BodyBytes)
+			if err != nil {
+				return "", fmt.Errorf("%s: response body is not JSON: %w", e.Var, err)
+			}
+			found, err := JSONPath(data, e.Path)
+			if err != nil {
+				return "", fmt.Errorf("%s: %w", e.Var, err)
+			}
+			value = jsonString(found)
+		}
+	case "header":
+		value = resp.Headers.Get(e.Header)
+		if value == "" {
+			return "", fmt.Errorf("%s: response has no %s header", e.Var, e.Header)
+		}
+	case "status":
+		value = strconv.Itoa(resp.StatusCode)
+	default:
+		return "", fmt.Errorf("%s: unknown source %q, expected body, header or status", e.Var, e.From)
+	}
+
+	if e.Regex != "" {
+		re, err := regexp.Compile(e.Regex)
+		if err != nil {
+			return "", fmt.Errorf("%s: invalid regex: %w", e.Var, err)
+		}
+		match := re.FindStringSubmatch(value)
+		switch {
+		case match == nil:
+			return "", fmt.Errorf("%s: %q does not match", e.Var, e.Regex)
+		case len(match) > 1:
+			value = match[1]
+		default:
+			value = match[0]
+		}
+	}
+	return value, nil
+}
+
+// DecodeJSON decodes a JSON document keeping numbers as written
+func DecodeJSON(data []byte) (any, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// jsonString renders a JSON value as a variable: strings unquoted, the rest as JSON
+func jsonString(v any) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case nil:
+		return "null"
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
+
+// JSONPath evaluates a JSONPath subset: $ followed by .name, ['name'] and [index]
+// selectors, with negative indexes counting from the end
+func JSONPath(data any, path string) (any, error) {
+	rest := strings.TrimSpace(path)
+	if !strings.HasPrefix(rest, "$") {
+		return nil, fmt.Errorf("invalid JSON path %q: must start with $", path)
+	}
+	rest = rest[1:]
+	current := data
+
+	for rest != "" {
+		var key string
+		index, isIndex := 0, false
+
+		switch {
+		case strings.HasPrefix(rest, "."):
+			rest = rest[1:]
+			end := strings.IndexAny(rest, ".[")
+			if end < 0 {
+				end = len(rest)
+			}
+			key, rest = rest[:end], rest[end:]
+			if key == "" {
+				return nil, fmt.Errorf("invalid JSON path %q", path)
+			}
+
+		case strings.HasPrefix(rest, "['"), strings.HasPrefix(rest, `["`):
+			quote := rest[1:2]
+			end := strings.Index(rest[2:], quote+"]")
+			if end < 0 {
+				return nil, fmt.Errorf("invalid JSON path %q: unterminated selector", path)
+			}
+			key, rest = rest[2:2+end], rest[2+end+2:]
+
+		case strings.HasPrefix(rest, "["):
+			end := strings.Index(rest, "]")
+			if end < 0 {
+				return nil, fmt.Errorf("invalid JSON path %q: unterminated selector", path)
+			}
+			n, err := strconv.Atoi(strings.TrimSpace(rest[1:end]))
+			if err != nil {
+				return nil, fmt.Errorf("invalid JSON path %q: bad index %q", path, rest[1:end])
+			}
+			index, isIndex, rest = n, true, rest[end+1:]
+
+		default:
+			return nil, fmt.Errorf("invalid JSON path %q", path)
+		}
+
+		if isIndex {
+			arr, ok := current.([]any)
+			if !ok {
+				return nil, fmt.Errorf("%s: [%d] applied to a non-array", path, index)
+			}
+			if index < 0 {
+				index += len(arr)
+			}
+			if index < 0 || index >= len(arr) {
+				return nil, fmt.Errorf("%s: index %d out of range", path, index)
+			}
+			current = arr[index]
+			continue
+		}
+
+		obj, ok := current.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("%s: %q applied to a non-object", path, key)
+		}
+		if current, ok = obj[key]; !ok {
+			return nil, fmt.Errorf("%s: no %q field", path, key)
+		}
+	}
+	return current, nil
+}
+
+// jsonPointer resolves an RFC 6901 pointer such as /items/0/id
+func jsonPointer(data any, pointer string) (any, error) {
+	if pointer == "" {
+		return data, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("invalid JSON pointer %q", pointer)
+	}
+
+	current := data
+	for _, token := range strings.Split(pointer[1:], "/") {
+		token = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+		switch v := current.(type) {
+		case map[string]any:
+			next, ok := v[token]
+			if !ok {
+				return nil, fmt.Errorf("%s: no %q field", pointer, token)
+			}
+			current = next
+		case []any:
+			i, err := strconv.Atoi(token)
+			if err != nil || i < 0 || i >= len(v) {
+				return nil, fmt.Errorf("%s: index %q out of range", pointer, token)
+			}
+			current = v[i]
+		default:
+			return nil, fmt.Errorf("%s: %q applied to a scalar", pointer, token)
+		}
+	}
+	return current, nil
+}
@@ -0,0 +1,170 @@
+package api
+
+import (
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CustomSigningScheme is the name of the HMAC signing scheme, which is not part
+// of the spec and is therefore applied to every request once configured
+const CustomSigningScheme = "hmac-signing"
+
+// Defaults for HMAC signing
+const (
+	DefaultSigningTemplate  = `{method}\n{path}\n{timestamp}\n{body_sha256}`
+	DefaultSigningHeaders   = "X-Signature: {signature}; X-Timestamp: {timestamp}"
+	DefaultSigningAlgorithm = "sha256"
+	DefaultSigningEncoding  = "hex"
+	DefaultSigningTimestamp = "unix"
+)
+
+// signingPlaceholder matches {name} and {header:Name} placeholders
+var signingPlaceholder = regexp.MustCompile(`\{([a-z0-9_]+)(?::([^}]+))?\}`)
+
+// signHMAC computes a signature over a canonical string built from the
+// template and writes it to the configured headers
+func signHMAC(req *http.Request, config *AuthConfig, now time.Time) (string, error) {
+	if config.SigningSecret == "" {
+		return "", fmt.Errorf("signing secret is required")
+	}
+
+	newHash, err := signingHash(valueOr(config.SigningAlgorithm, DefaultSigningAlgorithm))
+	if err != nil {
+		return "", err
+	}
+
+	body, err := readRequestBody(req)
+	if err != nil {
+		return "", err
+	}
+
+	nonceBytes := make([]byte, 16)
+	if _, err := rand.Read(nonceBytes); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	timestamp, err := formatSigningTimestamp(now, valueOr(config.SigningTimestamp, DefaultSigningTimestamp))
+	if err != nil {
+		return "", err
+	}
+
+	values := map[string]string{
+		"method":       req.Method,
+		"path":         req.URL.EscapedPath(),
+		"query":        req.URL.RawQuery,
+		"uri":          req.URL.RequestURI(),
+		"host":         req.URL.Host,
+		"content_type": req.Header.Get("Content-Type"),
+		"timestamp":    timestamp,
+		"nonce":        hex.EncodeToString(nonceBytes),
+		"body":         string(body),
+		"body_sha256":  sha256Hex(body),
+		"body_md5":     md5Hex(body),
+	}
+
+	canonical := expandSigningTemplate(unescapeTemplate(valueOr(config.SigningTemplate, DefaultSigningTemplate)), values, req)
+
+	mac := hmac.New(newHash, []byte(config.SigningSecret))
+	mac.Write([]byte(canonical))
+	signature, err := encodeSignature(mac.Sum(nil), valueOr(config.SigningEncoding, DefaultSigningEncoding))
+	if err != nil {
+		return "", err
+	}
+	values["signature"] = signature
+
+	headers := valueOr(config.SigningHeaders, DefaultSigningHeaders)
+	for _, entry := range strings.Split(headers, ";") {
+		name, template, ok := strings.Cut(entry, ":")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			continue
+		}
+		req.Header.Set(name, expandSigningTemplate(strings.TrimSpace(template), values, req))
+	}
+
+	return canonical, nil
+}
+
+// expandSigningTemplate replaces placeholders; {header:Name} reads a request header
+func expandSigningTemplate(template string, values map[string]string, req *http.Request) string {
+	return signingPlaceholder.ReplaceAllStringFunc(template, func(match string) string {
+		parts := signingPlaceholder.FindStringSubmatch(match)
+		if parts[1] == "header" && parts[2] != "" {
+			return req.Header.Get(parts[2])
+		}
+		if v, ok := values[parts[1]]; ok {
+			return v
+		}
+		return match
+	})
+}
+
+// unescapeTemplate turns \n and \t typed in a single line input into control characters
+func unescapeTemplate(s string) string {
+	return strings.NewReplacer(`\n`, "\n", `\t`, "\t", `\\`, `\`).Replace(s)
+}
+
+func signingHash(algorithm string) (func() hash.Hash, error) {
+	switch strings.ToLower(algorithm) {
+	case "sha256":
+		return sha256.New, nil
+	case "sha512":
+		return sha512.New, nil
+	case "sha1":
+		return sha1.New, nil
+	case "md5":
+		return md5.New, nil
+	}
+	return nil, fmt.Errorf("unsupported hash algorithm %q (sha256, sha512, sha1, md5)", algorithm)
+}
+
+func encodeSignature(sum []byte, encoding string) (string, error) {
+	switch strings.ToLower(encoding) {
+	case "hex":
+		return hex.EncodeToString(sum), nil
+	case "base64":
+		return base64.StdEncoding.EncodeToString(sum), nil
+	case "base64url":
+		return base64.RawURLEncoding.EncodeToString(sum), nil
+	}
+	return "", fmt.Errorf("unsupported signature encoding %q (hex, base64, base64url)", encoding)
+}
+
+func formatSigningTimestamp(now time.Time, format string) (string, error) {
+	switch strings.ToLower(format) {
+	case "unix":
+		return strconv.FormatInt(now.Unix(), 10), nil
+	case "unix_ms":
+		return strconv.FormatInt(now.UnixMilli(), 10), nil
+	case "rfc3339":
+		return now.UTC().Format(time.RFC3339), nil
+	case "rfc1123":
+		return now.UTC().Format(http.TimeFormat), nil
+	}
+	return "", fmt.Errorf("unsupported timestamp format %q (unix, unix_ms, rfc3339, rfc1123)", format)
+}
+
+func md5Hex(data []byte) string {
+	sum := md5.Sum(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func valueOr(value, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+	return value
+}
@@ -0,0 +1,106 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSignHMAC(t *testing.T) {
+	tests := []struct {
+		name      string
+		config    AuthConfig
+		canonical string
+		headers   map[string]string
+	}{
+		{
+			name:      "defaults",
+			config:    AuthConfig{SigningSecret: "s3cret"},
+			canonical: "POST\n/v1/orders\n1704164645\n015abd7f5cc57a2dd94b7590f04ad8084273905ee33ec5cebeae62276a97f862",
+			headers: map[string]string{
+				"X-Signature": "a1fbd863cdd117a1a5d9c9f4518a10133a07688b3e7e4f5a5cca98ec0f2f56f6",
+				"X-Timestamp": "1704164645",
+			},
+		},
+		{
+			name: "sha512 base64 with a header",
+			config: AuthConfig{
+				SigningSecret:    "s3cret",
+				SigningAlgorithm: "sha512",
+				SigningEncoding:  "base64",
+				SigningTimestamp: "rfc3339",
+				SigningTemplate:  `{method} {uri}\n{header:X-Request-Id}`,
+				SigningHeaders:   "Authorization: HMAC {signature}; Date: {timestamp}",
+			},
+			canonical: "POST /v1/orders?x=1\nreq-42",
+			headers: map[string]string{
+				"Authorization": "HMAC R2anePn/pZZqVWMWIxD/ZOSGfP7Md8g6tbDZtO9FFQM+lKknU1VtXUR/bYd5z7hZ1gKvbyOQnxo6is4ZqY4lNg==",
+				"Date":          "2024-01-02T03:04:05Z",
+			},
+		},
+		{
+			name: "sha1 base64url over the body",
+			config: AuthConfig{
+				SigningSecret:    "s3cret",
+				SigningAlgorithm: "SHA1",
+				SigningEncoding:  "base64url",
+				SigningTimestamp: "unix_ms",
+				SigningTemplate:  "{timestamp}.{body}",
+				SigningHeaders:   "X-Sig: t={timestamp},v1={signature}",
+			},
+			canonical: `1704164645000.{"a":1}`,
+			headers: map[string]string{
+				"X-Sig": "t=1704164645000,v1=VY8EYayKdQgayyb0RmonYHZTEtk",
+			},
+		},
+	}
+
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest("POST", "https://api.example.com/v1/orders?x=1", strings.NewReader(`{"a":1}`))
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Set("X-Request-Id", "req-42")
+
+			canonical, err := signHMAC(req, &tt.config, now)
+			if err != nil {
+				t.Fatalf("signHMAC() error = %v", err)
+			}
+			if canonical != tt.canonical {
+				t.Errorf("canonical = %q, want %q", canonical, tt.canonical)
+			}
+			for name, want := range tt.headers {
+				if got := req.Header.Get(name); got != want {
+					t.Errorf("%s = %q, want %q", name, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestSignHMACErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		config AuthConfig
+		err    string
+	}{
+		{"no secret", AuthConfig{}, "signing secret is required"},
+		{"algorithm", AuthConfig{SigningSecret: "s", SigningAlgorithm: "sha3"}, `unsupported hash algorithm "sha3"`},
+		{"encoding", AuthConfig{SigningSecret: "s", SigningEncoding: "base32"}, `unsupported signature encoding "base32"`},
+		{"timestamp", AuthConfig{SigningSecret: "s", SigningTimestamp: "iso"}, `unsupported timestamp format "iso"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest("GET", "https://api.example.com/", nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := signHMAC(req, &tt.config, time.Now()); err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("signHMAC() error = %v, want %q", err, tt.err)
+			}
+		})
+	}
+}
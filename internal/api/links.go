@@ -0,0 +1,216 @@
+package api
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// Link is an OpenAPI link from one of an operation's responses to another operation
+type Link struct {
+	Name         string
+	Status       string // Response the link belongs to: a code, a range such as 2XX, or default
+	OperationID  string
+	OperationRef string
+	Description  string
+	Parameters   map[string]any // Constants or runtime expressions, keyed by name or location.name
+	RequestBody  any
+}
+
+// embeddedExpression matches {$...} runtime expressions inside a string
+var embeddedExpression = regexp.MustCompile(`\{(\$[^}]+)\}`)
+
+// extractLinks returns the links of all responses of an operation
+func extractLinks(op *openapi3.Operation) []Link {
+	if op.Responses == nil {
+		return nil
+	}
+
+	var links []Link
+	for status, ref := range op.Responses.Map() {
+		if ref == nil || ref.Value == nil {
+			continue
+		}
+		for name, linkRef := range ref.Value.Links {
+			if linkRef == nil || linkRef.Value == nil {
+				continue
+			}
+			l := linkRef.Value
+			links = append(links, Link{
+				Name:         name,
+				Status:       status,
+				OperationID:  l.OperationID,
+				OperationRef: l.OperationRef,
+				Description:  l.Description,
+				Parameters:   l.Parameters,
+				RequestBody:  l.RequestBody,
+			})
+		}
+	}
+
+	sort.Slice(links, func(i, j int) bool {
+		if links[i].Status != links[j].Status {
+			return links[i].Status < links[j].Status
+		}
+		return links[i].Name < links[j].Name
+	})
+	return links
+}
+
+// LinksFor returns the links of the response documented for a status code,
+// preferring the exact code over its range and the range over default
+func (e *Endpoint) LinksFor(status int) []Link {
+	code := strconv.Itoa(status)
+	for _, key := range []string{code, code[:1] + "XX", "default"} {
+		var links []Link
+		for _, l := range e.Links {
+			if strings.EqualFold(l.Status, key) {
+				links = append(links, l)
+			}
+		}
+		if len(links) > 0 {
+			return links
+		}
+	}
+	return nil
+}
+
+// Target finds the linked operation, by operationId or by a local operationRef
+// such as #/paths/~1orders~1{id}/get
+func (l Link) Target(endpoints []Endpoint) (*Endpoint, error) {
+	if l.OperationID != "" {
+		for i := range endpoints {
+			if endpoints[i].OperationID == l.OperationID {
+				return &endpoints[i], nil
+			}
+		}
+		return nil, fmt.Errorf("link %s: operation %q not found", l.Name, l.OperationID)
+	}
+
+	_, fragment, _ := strings.Cut(l.OperationRef, "#")
+	parts := strings.Split(strings.TrimPrefix(fragment, "/"), "/")
+	if len(parts) != 3 || parts[0] != "paths" {
+		return nil, fmt.Errorf("link %s: unsupported operationRef %q", l.Name, l.OperationRef)
+	}
+	path := strings.ReplaceAll(strings.ReplaceAll(parts[1], "~1", "/"), "~0", "~")
+	for i := range endpoints {
+		if endpoints[i].Path == path && strings.EqualFold(endpoints[i].Method, parts[2]) {
+			return &endpoints[i], nil
+		}
+	}
+	return nil, fmt.Errorf("link %s: operation %q not found", l.Name, l.OperationRef)
+}
+
+// Resolve evaluates the link's parameters and request body against the request
+// that was sent and its response
+func (l Link) Resolve(req *Request, resp *Response) (params map[string]string, body string, err error) {
+	params = make(map[string]string, len(l.Parameters))
+	for key, expr := range l.Parameters {
+		name := key
+		if location, rest, ok := strings.Cut(key, "."); ok {
+			switch location {
+			case "path", "query", "header", "cookie":
+				name = rest
+			}
+		}
+		if params[name], err = evalLinkValue(expr, req, resp); err != nil {
+			return nil, "", fmt.Errorf("link %s: parameter %s: %w", l.Name, name, err)
+		}
+	}
+
+	if l.RequestBody != nil {
+		if body, err = evalLinkValue(l.RequestBody, req, resp); err != nil {
+			return nil, "", fmt.Errorf("link %s: request body: %w", l.Name, err)
+		}
+	}
+	return params, body, nil
+}
+
+// evalLinkValue evaluates a constant, a runtime expression, or a string with
+// embedded {$...} expressions
+func evalLinkValue(value any, req *Request, resp *Response) (string, error) {
+	s, ok := value.(string)
+	if !ok {
+		return jsonString(value), nil
+	}
+	if strings.HasPrefix(s, "$") {
+		return evalExpression(s, req, resp)
+	}
+
+	var evalErr error
+	result := embeddedExpression.ReplaceAllStringFunc(s, func(m string) string {
+		v, err := evalExpression(m[1:len(m)-1], req, resp)
+		if err != nil && evalErr == nil {
+			evalErr = err
+		}
+		return v
+	})
+	return result, evalErr
+}
+
+// evalExpression evaluates an OpenAPI runtime expression
+func evalExpression(expr string, req *Request, resp *Response) (string, error) {
+	switch expr {
+	case "$url":
+		return resp.URL, nil
+	case "$method":
+		return req.Method, nil
+	case "$statusCode":
+		return strconv.Itoa(resp.StatusCode), nil
+	}
+
+	source, rest, _ := strings.Cut(expr, ".")
+	switch source {
+	case "$request":
+		switch {
+		case strings.HasPrefix(rest, "path."):
+			return lookup(req.PathParams, strings.TrimPrefix(rest, "path."), false, expr)
+		case strings.HasPrefix(rest, "query."):
+			return lookup(req.QueryParams, strings.TrimPrefix(rest, "query."), false, expr)
+		case strings.HasPrefix(rest, "header."):
+			return lookup(req.Headers, strings.TrimPrefix(rest, "header."), true, expr)
+		case rest == "body" || strings.HasPrefix(rest, "body#"):
+			return bodyExpression([]byte(req.Body), strings.TrimPrefix(rest, "body"), expr)
+		}
+	case "$response":
+		switch {
+		case strings.HasPrefix(rest, "header."):
+			if v := resp.Headers.Get(strings.TrimPrefix(rest, "header.")); v != "" {
+				return v, nil
+			}
+			return "", fmt.Errorf("%s: header not present", expr)
+		case rest == "body" || strings.HasPrefix(rest, "body#"):
+			return bodyExpression(resp.BodyBytes, strings.TrimPrefix(rest, "body"), expr)
+		}
+	}
+	return "", fmt.Errorf("unsupported expression %q", expr)
+}
+
+func lookup(values map[string]string, name string, foldCase bool, expr string) (string, error) {
+	for k, v := range values {
+		if k == name || (foldCase && strings.EqualFold(k, name)) {
+			return v, nil
+		}
+	}
+	return "", fmt.Errorf("%s: no value", expr)
+}
+
+// bodyExpression resolves the #/json/pointer part of a body expression
+func bodyExpression(body []byte, fragment, expr string) (string, error) {
+	if fragment == "" {
+		return string(body), nil
+	}
+	data, err := DecodeJSON(body)
+	if err != nil {
+		return "", fmt.Errorf("%s: body is not JSON", expr)
+	}
+	v, err := jsonPointer(data, strings.TrimPrefix(fragment, "#"))
+	if err != nil {
+		return "", fmt.Errorf("%s: %w", expr, err)
+	}
+	return jsonString(v), nil
+}
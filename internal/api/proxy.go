@@ -0,0 +1,136 @@
+package api
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// ProxyConfig holds the proxy requests are sent through. Without a URL the
+// HTTP_PROXY, HTTPS_PROXY and NO_PROXY environment variables are honoured.
+type ProxyConfig struct {
+	URL      string `yaml:"url"` // http://, https:// or socks5://
+	Username string `yaml:"username"`
+	Password string `yaml:"password"` // May be a secret reference such as env:PROXY_PASSWORD
+	NoProxy  string `yaml:"no_proxy"` // Comma separated hosts, .domains, IPs or CIDRs; * disables the proxy
+}
+
+// Validate checks the proxy URL and resolves the password reference
+func (p ProxyConfig) Validate() error {
+	_, err := p.proxyFunc()
+	return err
+}
+
+// proxyFunc builds the transport's proxy selection from the config
+func (p ProxyConfig) proxyFunc() (func(*http.Request) (*url.URL, error), error) {
+	bypass := parseNoProxy(p.NoProxy)
+
+	if strings.TrimSpace(p.URL) == "" {
+		return func(req *http.Request) (*url.URL, error) {
+			if bypass.matches(req.URL) {
+				return nil, nil
+			}
+			return http.ProxyFromEnvironment(req)
+		}, nil
+	}
+
+	proxyURL, err := url.Parse(strings.TrimSpace(p.URL))
+	if err != nil {
+		return nil, fmt.Errorf("invalid proxy URL: %w", err)
+	}
+	switch proxyURL.Scheme {
+	case "http", "https", "socks5", "socks5h":
+	default:
+		return nil, fmt.Errorf("unsupported proxy scheme %q (http, https, socks5)", proxyURL.Scheme)
+	}
+	if proxyURL.Host == "" {
+		return nil, fmt.Errorf("proxy URL %q has no host", p.URL)
+	}
+
+	if p.Username != "" {
+		password, err := newSecretResolver().resolve(p.Password)
+		if err != nil {
+			return nil, fmt.Errorf("proxy password: %w", err)
+		}
+		proxyURL.User = url.UserPassword(p.Username, password)
+	}
+
+	return func(req *http.Request) (*url.URL, error) {
+		if bypass.matches(req.URL) {
+			return nil, nil
+		}
+		return proxyURL, nil
+	}, nil
+}
+
+// noProxy is a parsed NO_PROXY style list
+type noProxy struct {
+	all     bool
+	entries []noProxyEntry
+	nets    []*net.IPNet
+}
+
+type noProxyEntry struct {
+	host string // Domain or IP, a leading dot is dropped
+	port string // Empty matches any port
+}
+
+func parseNoProxy(list string) noProxy {
+	var np noProxy
+	for _, item := range strings.Split(list, ",") {
+		item = strings.ToLower(strings.TrimSpace(item))
+		switch {
+		case item == "":
+			continue
+		case item == "*":
+			np.all = true
+			continue
+		}
+
+		if _, ipNet, err := net.ParseCIDR(item); err == nil {
+			np.nets = append(np.nets, ipNet)
+			continue
+		}
+
+		entry := noProxyEntry{host: item}
+		if host, port, err := net.SplitHostPort(item); err == nil {
+			entry = noProxyEntry{host: host, port: port}
+		}
+		entry.host = strings.TrimPrefix(strings.TrimPrefix(entry.host, "*"), ".")
+		np.entries = append(np.entries, entry)
+	}
+	return np
+}
+
+// matches reports whether u must be reached directly
+func (np noProxy) matches(u *url.URL) bool {
+	if np.all {
+		return true
+	}
+
+	host := strings.ToLower(u.Hostname())
+	port := u.Port()
+	if port == "" {
+		port = map[string]string{"http": "80", "https": "443", "ws": "80", "wss": "443"}[u.Scheme]
+	}
+
+	if ip := net.ParseIP(host); ip != nil {
+		for _, ipNet := range np.nets {
+			if ipNet.Contains(ip) {
+				return true
+			}
+		}
+	}
+
+	for _, entry := range np.entries {
+		if entry.port != "" && entry.port != port {
+			continue
+		}
+		if host == entry.host || strings.HasSuffix(host, "."+entry.host) {
+			return true
+		}
+	}
+	return false
+}
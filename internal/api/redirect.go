@@ -0,0 +1,89 @@
+package api
+
+import (
+	"net/http"
+	"sync"
+)
+
+// DefaultMaxRedirects is the number of redirects followed when no limit is set
+const DefaultMaxRedirects = 10
+
+// RedirectPolicy controls whether redirects are followed
+type RedirectPolicy struct {
+	Follow  bool
+	MaxHops int // Zero uses DefaultMaxRedirects
+}
+
+// DefaultRedirectPolicy follows up to DefaultMaxRedirects redirects
+var DefaultRedirectPolicy = RedirectPolicy{Follow: true, MaxHops: DefaultMaxRedirects}
+
+func (p RedirectPolicy) maxHops() int {
+	if p.MaxHops <= 0 {
+		return DefaultMaxRedirects
+	}
+	return p.MaxHops
+}
+
+// RedirectHop is a redirect response that was followed
+type RedirectHop struct {
+	Method     string
+	URL        string
+	StatusCode int
+	Status     string
+	Location   string
+	Headers    http.Header
+}
+
+// redirectState tracks the redirects of a single request
+type redirectState struct {
+	mu        sync.Mutex
+	policy    RedirectPolicy
+	hops      []RedirectHop
+	truncated bool
+}
+
+// redirectStateKey carries the redirect state in the request context
+type redirectStateKey struct{}
+
+// checkRedirect records the redirect response that led to req and applies the
+// request's policy. A redirect that is not followed, also once the hop limit
+// is reached, becomes the response.
+func checkRedirect(req *http.Request, via []*http.Request) error {
+	state, ok := req.Context().Value(redirectStateKey{}).(*redirectState)
+	if !ok {
+		return nil
+	}
+
+	if !state.policy.Follow {
+		return http.ErrUseLastResponse
+	}
+
+	if len(via) > state.policy.maxHops() {
+		state.mu.Lock()
+		state.truncated = true
+		state.mu.Unlock()
+		return http.ErrUseLastResponse
+	}
+
+	if redirect := req.Response; redirect != nil {
+		previous := via[len(via)-1]
+		state.mu.Lock()
+		state.hops = append(state.hops, RedirectHop{
+			Method:     previous.Method,
+			URL:        previous.URL.String(),
+			StatusCode: redirect.StatusCode,
+			Status:     redirect.Status,
+			Location:   redirect.Header.Get("Location"),
+			Headers:    redirect.Header,
+		})
+		state.mu.Unlock()
+	}
+	return nil
+}
+
+// redirects returns the redirects followed and whether the hop limit stopped them
+func (s *redirectState) redirects() ([]RedirectHop, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]RedirectHop(nil), s.hops...), s.truncated
+}
@@ -0,0 +1,310 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// maxSampleDepth stops recursive schemas from generating endless values
+const maxSampleDepth = 8
+
+// Sample values for string formats
+var sampleFormats = map[string]string{
+	"date":      "2024-01-01",
+	"date-time": "2024-01-01T00:00:00Z",
+	"time":      "12:00:00",
+	"email":     "user@example.com",
+	"uuid":      "3fa85f64-5717-4562-b3fc-2c963f66afa6",
+	"uri":       "https://example.com",
+	"url":       "https://example.com",
+	"hostname":  "example.com",
+	"ipv4":      "192.0.2.1",
+	"ipv6":      "2001:db8::1",
+	"byte":      "YXBpbXVn",
+	"password":  "password",
+}
+
+// Sample returns a value valid against the schema, preferring its example,
+// default and first enum value over a generated one. Objects get their
+// required properties, or all of them when none are required. Read-only
+// properties are left out, as the value is meant for requests.
+func Sample(schema *openapi3.Schema) any {
+	return sample(schema, 0)
+}
+
+func sample(schema *openapi3.Schema, depth int) any {
+	if schema == nil || depth > maxSampleDepth {
+		return nil
+	}
+	switch {
+	case schema.Example != nil:
+		return schema.Example
+	case schema.Default != nil:
+		return schema.Default
+	case len(schema.Enum) > 0:
+		return schema.Enum[0]
+	}
+
+	if len(schema.AllOf) > 0 {
+		merged := make(map[string]any)
+		for _, ref := range schema.AllOf {
+			if ref == nil {
+				continue
+			}
+			part, ok := sample(ref.Value, depth+1).(map[string]any)
+			if !ok {
+				return sample(ref.Value, depth+1)
+			}
+			for k, v := range part {
+				merged[k] = v
+			}
+		}
+		if props := sampleProperties(schema, depth); props != nil {
+			for k, v := range props {
+				merged[k] = v
+			}
+		}
+		return merged
+	}
+	for _, alternatives := range []openapi3.SchemaRefs{schema.OneOf, schema.AnyOf} {
+		if len(alternatives) > 0 && alternatives[0] != nil {
+			return sample(alternatives[0].Value, depth+1)
+		}
+	}
+
+	switch schemaType(schema) {
+	case openapi3.TypeString:
+		return sampleString(schema)
+	case openapi3.TypeInteger:
+		return int64(sampleNumber(schema, true))
+	case openapi3.TypeNumber:
+		return sampleNumber(schema, false)
+	case openapi3.TypeBoolean:
+		return true
+	case openapi3.TypeArray:
+		n := int(schema.MinItems)
+		if n == 0 {
+			n = 1
+		}
+		items := make([]any, 0, n)
+		for i := 0; i < n; i++ {
+			if schema.Items == nil {
+				items = append(items, "string")
+				continue
+			}
+			items = append(items, sample(schema.Items.Value, depth+1))
+		}
+		return items
+	case openapi3.TypeObject:
+		return sampleProperties(schema, depth)
+	}
+	return nil
+}
+
+// schemaType returns the schema's first type, inferring object from properties
+func schemaType(schema *openapi3.Schema) string {
+	if types := schema.Type.Slice(); len(types) > 0 {
+		return types[0]
+	}
+	if len(schema.Properties) > 0 {
+		return openapi3.TypeObject
+	}
+	if schema.Items != nil {
+		return openapi3.TypeArray
+	}
+	return ""
+}
+
+func sampleProperties(schema *openapi3.Schema, depth int) map[string]any {
+	names := schema.Required
+	if len(names) == 0 {
+		for name := range schema.Properties {
+			names = append(names, name)
+		}
+	}
+
+	obj := make(map[string]any, len(names))
+	for _, name := range names {
+		prop := schema.Properties[name]
+		if prop == nil || prop.Value == nil {
+			obj[name] = "string"
+			continue
+		}
+		if prop.Value.ReadOnly {
+			continue
+		}
+		obj[name] = sample(prop.Value, depth+1)
+	}
+	return obj
+}
+
+func sampleString(schema *openapi3.Schema) string {
+	s, ok := sampleFormats[schema.Format]
+	if !ok {
+		s = "string"
+	}
+	if n := int(schema.MinLength); len(s) < n {
+		s += strings.Repeat("a", n-len(s))
+	}
+	if schema.MaxLength != nil && uint64(len(s)) > *schema.MaxLength {
+		s = s[:*schema.MaxLength]
+	}
+	return s
+}
+
+func sampleNumber(schema *openapi3.Schema, integer bool) float64 {
+	n := 1.0
+	switch {
+	case schema.Min != nil:
+		n = *schema.Min
+		if schema.ExclusiveMin {
+			n += 1
+		}
+	case schema.Max != nil && *schema.Max < n:
+		n = *schema.Max
+		if schema.ExclusiveMax {
+			n -= 1
+		}
+	}
+	if integer {
+		n = math.Ceil(n)
+	}
+	if m := schema.MultipleOf; m != nil && *m > 0 {
+		n = math.Ceil(n / *m) * *m
+	}
+	return n
+}
+
+// FormatParam renders a parameter value, joining arrays with commas
+func FormatParam(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case []any:
+		parts := make([]string, len(v))
+		for i, item := range v {
+			parts[i] = FormatParam(item)
+		}
+		return strings.Join(parts, ",")
+	case float64:
+		if v == math.Trunc(v) {
+			return fmt.Sprintf("%d", int64(v))
+		}
+	case map[string]any:
+		data, _ := json.Marshal(v)
+		return string(data)
+	}
+	return fmt.Sprint(v)
+}
+
+// SampleBody returns a request body for the endpoint, taken from the
+// documented example or generated from the schema. JSON media types are
+// preferred. ok is false when the endpoint documents no body.
+func (e *Endpoint) SampleBody() (body, contentType string, ok bool) {
+	if e.Body == nil || len(e.Body.Content) == 0 {
+		return "", "", false
+	}
+
+	mediaType, media := e.bodyMediaType()
+	var value any
+	switch {
+	case media.Example != nil:
+		value = media.Example
+	case len(media.Examples) > 0:
+		names := make([]string, 0, len(media.Examples))
+		for name := range media.Examples {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		if ex := media.Examples[names[0]]; ex != nil && ex.Value != nil {
+			value = ex.Value.Value
+		}
+	}
+	if value == nil && media.Schema != nil {
+		value = Sample(media.Schema.Value)
+	}
+
+	if !strings.Contains(mediaType, "json") {
+		switch v := value.(type) {
+		case string:
+			return v, mediaType, true
+		case map[string]any:
+			if mediaType == "application/x-www-form-urlencoded" {
+				form := url.Values{}
+				for name, field := range v {
+					form.Set(name, FormatParam(field))
+				}
+				return form.Encode(), mediaType, true
+			}
+		}
+		return "", mediaType, true
+	}
+	data, err := json.MarshalIndent(value, "", "  ")
+	if err != nil {
+		return "", "", false
+	}
+	return string(data), mediaType, true
+}
+
+// bodyMediaType picks the documented request media type, JSON first
+func (e *Endpoint) bodyMediaType() (string, *openapi3.MediaType) {
+	names := make([]string, 0, len(e.Body.Content))
+	for name := range e.Body.Content {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if strings.Contains(name, "json") {
+			return name, e.Body.Content[name]
+		}
+	}
+	return names[0], e.Body.Content[names[0]]
+}
+
+// SampleRequest builds a request from values, falling back to examples and
+// generated values for required parameters. Optional parameters without a
+// value are left out.
+func (e *Endpoint) SampleRequest(values map[string]string) *Request {
+	params := make(map[string]string, len(e.Parameters))
+	for _, p := range e.Parameters {
+		switch {
+		case values[p.Name] != "":
+			params[p.Name] = values[p.Name]
+		case !p.Required:
+		case p.Example != "":
+			params[p.Name] = p.Example
+		case p.SchemaRef != nil:
+			params[p.Name] = FormatParam(Sample(p.SchemaRef.Value))
+		default:
+			params[p.Name] = "1"
+		}
+	}
+
+	body, contentType, ok := e.SampleBody()
+	req := e.NewRequest(params, body)
+	if ok {
+		req.ContentType = contentType
+	}
+	return req
+}
+
+// BodySchema returns the preferred request media type and its schema, nil
+// when the endpoint documents no body schema
+func (e *Endpoint) BodySchema() (string, *openapi3.Schema) {
+	if e.Body == nil || len(e.Body.Content) == 0 {
+		return "", nil
+	}
+	mediaType, media := e.bodyMediaType()
+	if media == nil || media.Schema == nil {
+		return mediaType, nil
+	}
+	return mediaType, media.Schema.Value
+}
@@ -0,0 +1,123 @@
+package api
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+)
+
+// Secret values can reference their source instead of holding the secret:
+//
+//	env:API_TOKEN            read from an environment variable
+//	exec:pass show api/token read from the output of a command
+const (
+	secretEnvPrefix  = "env:"
+	secretExecPrefix = "exec:"
+)
+
+// IsSecretReference reports whether a value points to an external secret source
+func IsSecretReference(value string) bool {
+	return strings.HasPrefix(value, secretEnvPrefix) || strings.HasPrefix(value, secretExecPrefix)
+}
+
+// secretResolver resolves secret references, caching command output for the session
+// so that password managers are not prompted on every request
+type secretResolver struct {
+	mu    sync.Mutex
+	cache map[string]string
+}
+
+func newSecretResolver() *secretResolver {
+	return &secretResolver{cache: make(map[string]string)}
+}
+
+func (r *secretResolver) resolve(value string) (string, error) {
+	switch {
+	case strings.HasPrefix(value, secretEnvPrefix):
+		name := strings.TrimPrefix(value, secretEnvPrefix)
+		v, ok := os.LookupEnv(name)
+		if !ok {
+			return "", fmt.Errorf("environment variable %s is not set", name)
+		}
+		return v, nil
+
+	case strings.HasPrefix(value, secretExecPrefix):
+		command := strings.TrimSpace(strings.TrimPrefix(value, secretExecPrefix))
+
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		if v, ok := r.cache[command]; ok {
+			return v, nil
+		}
+
+		v, err := runSecretCommand(command)
+		if err != nil {
+			return "", err
+		}
+		r.cache[command] = v
+		return v, nil
+	}
+
+	return value, nil
+}
+
+func runSecretCommand(command string) (string, error) {
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.Command("cmd", "/C", command)
+	} else {
+		cmd = exec.Command("sh", "-c", command)
+	}
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if msg == "" {
+			msg = err.Error()
+		}
+		return "", fmt.Errorf("secret command %q failed: %s", command, msg)
+	}
+
+	// Tools like pass print the secret on the first line
+	return strings.TrimRight(strings.SplitN(string(out), "\n", 2)[0], "\r"), nil
+}
+
+// resolveSecrets returns a copy of config with secret references replaced by
+// their values. Configs not marked with SecretRefs are used as they are.
+func (r *secretResolver) resolveSecrets(config *AuthConfig) (*AuthConfig, error) {
+	resolved := *config
+	if !config.SecretRefs {
+		return &resolved, nil
+	}
+	fields := []*string{
+		&resolved.Token, &resolved.APIKey, &resolved.Username, &resolved.Password,
+		&resolved.AccessKey, &resolved.SecretKey, &resolved.SessionToken,
+		&resolved.SigningSecret,
+	}
+	for _, field := range fields {
+		v, err := r.resolve(*field)
+		if err != nil {
+			return nil, err
+		}
+		*field = v
+	}
+	return &resolved, nil
+}
+
+// SpecKey identifies a spec in the credential store by its absolute path or URL
+func SpecKey(spec *Spec) string {
+	if isAbsoluteURL(spec.Source) || spec.Source == "" {
+		return spec.Source
+	}
+	if abs, err := filepath.Abs(spec.Source); err == nil {
+		return abs
+	}
+	return spec.Source
+}
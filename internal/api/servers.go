@@ -0,0 +1,147 @@
+package api
+
+import (
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// Server represents a server declared in the spec
+type Server struct {
+	URL         string
+	Description string
+	Variables   []ServerVariable
+}
+
+// ServerVariable represents a substitutable part of a server URL
+type ServerVariable struct {
+	Name        string
+	Default     string
+	Enum        []string
+	Description string
+}
+
+// GetServers returns the document level servers with relative URLs resolved.
+// A spec without servers defaults to "/" as the OpenAPI specification requires.
+func (s *Spec) GetServers() []Server {
+	if s.Doc == nil {
+		return nil
+	}
+
+	servers := convertServers(s.Doc.Servers)
+	if len(servers) == 0 && s.referenceURL() != "" {
+		servers = []Server{{URL: "/"}}
+	}
+	return s.resolveServers(servers)
+}
+
+// resolveServers resolves relative server URLs against the spec's reference URL
+func (s *Spec) resolveServers(servers []Server) []Server {
+	for i := range servers {
+		servers[i].URL = s.ResolveServerURL(servers[i].URL)
+	}
+	return servers
+}
+
+// referenceURL returns the URL relative server URLs are resolved against:
+// the configured base URL, or the URL the spec was loaded from
+func (s *Spec) referenceURL() string {
+	if isAbsoluteURL(s.BaseURL) {
+		return s.BaseURL
+	}
+	if isAbsoluteURL(s.Source) {
+		return s.Source
+	}
+	return ""
+}
+
+// ResolveServerURL resolves a relative server URL such as "/api/v1". Absolute URLs
+// and relative URLs without a reference URL are returned unchanged.
+func (s *Spec) ResolveServerURL(serverURL string) string {
+	if serverURL == "" || strings.Contains(serverURL, "://") {
+		return serverURL
+	}
+
+	ref := s.referenceURL()
+	if ref == "" {
+		return serverURL
+	}
+	base, err := url.Parse(ref)
+	if err != nil {
+		return serverURL
+	}
+
+	// Resolve by hand rather than with url.ResolveReference so {variables} stay unescaped
+	origin := base.Scheme + "://" + base.Host
+	if strings.HasPrefix(serverURL, "//") {
+		return base.Scheme + ":" + serverURL
+	}
+	if strings.HasPrefix(serverURL, "/") {
+		return origin + serverURL
+	}
+
+	dir := path.Dir(base.Path)
+	if strings.HasSuffix(base.Path, "/") {
+		dir = strings.TrimSuffix(base.Path, "/")
+	}
+	if dir == "." || dir == "/" {
+		dir = ""
+	}
+	return origin + dir + "/" + strings.TrimPrefix(serverURL, "./")
+}
+
+func isAbsoluteURL(s string) bool {
+	return strings.HasPrefix(s, "http://") || strings.HasPrefix(s, "https://")
+}
+
+func convertServers(servers openapi3.Servers) []Server {
+	var result []Server
+	for _, srv := range servers {
+		if srv == nil {
+			continue
+		}
+
+		server := Server{
+			URL:         srv.URL,
+			Description: srv.Description,
+		}
+
+		names := make([]string, 0, len(srv.Variables))
+		for name := range srv.Variables {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		for _, name := range names {
+			v := srv.Variables[name]
+			if v == nil {
+				continue
+			}
+			server.Variables = append(server.Variables, ServerVariable{
+				Name:        name,
+				Default:     v.Default,
+				Enum:        v.Enum,
+				Description: v.Description,
+			})
+		}
+
+		result = append(result, server)
+	}
+	return result
+}
+
+// Resolve substitutes server variables into the URL, falling back to their defaults
+func (s Server) Resolve(values map[string]string) string {
+	url := s.URL
+	for _, v := range s.Variables {
+		value := values[v.Name]
+		if value == "" {
+			value = v.Default
+		}
+		url = strings.ReplaceAll(url, "{"+v.Name+"}", value)
+	}
+	return url
+}
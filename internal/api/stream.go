@@ -0,0 +1,288 @@
+package api
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultSSERetry is the reconnection delay until the server sends a retry field
+const defaultSSERetry = 3 * time.Second
+
+// Limits on what is kept of a stream, which may run for hours. The oldest
+// events and bytes are dropped first.
+const (
+	MaxStreamEvents = 10000
+	maxStreamBytes  = 16 << 20
+)
+
+// StreamEvent is a single Server-Sent Event or NDJSON line
+type StreamEvent struct {
+	Time      time.Time
+	Event     string // SSE event type, empty for the default "message"
+	ID        string
+	Data      string
+	Reconnect bool // The stream was reopened; Data holds the reason
+}
+
+// StreamHandler receives a streaming response as it arrives. Without a handler
+// streaming responses are read whole like any other response.
+type StreamHandler struct {
+	Started func(resp *Response) // Status and headers, called once the stream opens
+	Event   func(event StreamEvent)
+}
+
+type streamKind int
+
+const (
+	streamNone streamKind = iota
+	streamSSE
+	streamNDJSON
+)
+
+func streamKindOf(contentType string) streamKind {
+	mediaType, _, _ := strings.Cut(strings.ToLower(contentType), ";")
+	switch strings.TrimSpace(mediaType) {
+	case "text/event-stream":
+		return streamSSE
+	case "application/x-ndjson", "application/ndjson", "application/jsonl",
+		"application/x-jsonlines", "application/stream+json":
+		return streamNDJSON
+	}
+	return streamNone
+}
+
+// readStream delivers events of an open stream to the request's handler until
+// the server ends it or ctx is cancelled. Server-Sent Event streams are reopened
+// with Last-Event-ID as the protocol requires, until the server answers with
+// anything other than a new event stream.
+func (c *Client) readStream(ctx context.Context, req *Request, url string, httpResp *http.Response, resp *Response, kind streamKind) {
+	started := *resp
+	if req.Stream.Started != nil {
+		req.Stream.Started(&started)
+	}
+
+	emit := func(event StreamEvent) {
+		resp.Events = KeepLast(append(resp.Events, event), MaxStreamEvents)
+		if req.Stream.Event != nil {
+			req.Stream.Event(event)
+		}
+	}
+
+	raw := &tailBuffer{max: maxStreamBytes}
+	sse := &sseParser{retry: defaultSSERetry}
+
+loop:
+	for {
+		var body io.Reader = io.TeeReader(httpResp.Body, raw)
+		if req.Progress != nil {
+			body = &progressReader{r: body, progress: req.Progress}
+		}
+
+		var err error
+		if kind == streamSSE {
+			err = sse.read(body, emit)
+		} else {
+			err = readNDJSON(body, emit)
+		}
+		httpResp.Body.Close()
+
+		if ctx.Err() != nil {
+			// Stopped by the caller
+			break
+		}
+		if kind != streamSSE {
+			if err != nil {
+				resp.Error = fmt.Errorf("stream interrupted: %w", err)
+			}
+			break
+		}
+
+		reason := "stream closed by server"
+		if err != nil {
+			reason = err.Error()
+		}
+
+		select {
+		case <-time.After(sse.retry):
+		case <-ctx.Done():
+			break loop
+		}
+
+		httpResp, err = c.reconnectSSE(ctx, req, url, sse.lastID)
+		if err != nil {
+			if ctx.Err() == nil {
+				resp.Error = err
+			}
+			break
+		}
+		if httpResp == nil {
+			// The server asked not to reconnect
+			break
+		}
+		emit(StreamEvent{Time: time.Now(), ID: sse.lastID, Data: reason, Reconnect: true})
+	}
+
+	resp.Events = resp.Events[max(len(resp.Events)-MaxStreamEvents, 0):]
+	resp.BodyBytes = raw.Bytes()
+}
+
+// KeepLast trims s to its last n elements once it holds a quarter more, so that
+// appending one element at a time only copies it now and then. Trim it with
+// s[max(len(s)-n, 0):] for exactly n.
+func KeepLast[T any](s []T, n int) []T {
+	if len(s) <= n+n/4 {
+		return s
+	}
+	kept := copy(s, s[len(s)-n:])
+	clear(s[kept:])
+	return s[:kept]
+}
+
+// tailBuffer keeps the last max bytes written to it
+type tailBuffer struct {
+	max     int
+	buf     []byte
+	dropped bool
+}
+
+func (t *tailBuffer) Write(p []byte) (int, error) {
+	t.buf = append(t.buf, p...)
+	if len(t.buf) > t.max+t.max/4 {
+		t.buf = KeepLast(t.buf, t.max)
+		t.dropped = true
+	}
+	return len(p), nil
+}
+
+// Bytes returns the bytes kept, from the first line that was kept whole
+func (t *tailBuffer) Bytes() []byte {
+	if len(t.buf) > t.max {
+		t.buf, t.dropped = t.buf[len(t.buf)-t.max:], true
+	}
+	if !t.dropped {
+		return t.buf
+	}
+	if i := bytes.IndexByte(t.buf, '\n'); i >= 0 {
+		return t.buf[i+1:]
+	}
+	return t.buf
+}
+
+// reconnectSSE reopens an event stream. It returns a nil response when the
+// server answers 204 No Content, which tells clients to stop reconnecting.
+func (c *Client) reconnectSSE(ctx context.Context, req *Request, url, lastID string) (*http.Response, error) {
+	retry := *req
+	retry.Headers = make(map[string]string, len(req.Headers)+1)
+	for k, v := range req.Headers {
+		retry.Headers[k] = v
+	}
+	if lastID != "" {
+		retry.Headers["Last-Event-ID"] = lastID
+	}
+
+	httpReq, _, err := c.newHTTPRequest(ctx, &retry, url)
+	if err != nil {
+		return nil, err
+	}
+	httpResp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("reconnect failed: %w", err)
+	}
+
+	if httpResp.StatusCode == http.StatusNoContent {
+		httpResp.Body.Close()
+		return nil, nil
+	}
+	if httpResp.StatusCode != http.StatusOK || streamKindOf(httpResp.Header.Get("Content-Type")) != streamSSE {
+		httpResp.Body.Close()
+		return nil, fmt.Errorf("reconnect failed: %s", httpResp.Status)
+	}
+	return httpResp, nil
+}
+
+// sseParser implements the event stream interpretation of the HTML standard.
+// The last event ID and retry delay outlive a connection.
+type sseParser struct {
+	lastID string
+	retry  time.Duration
+}
+
+func (p *sseParser) read(r io.Reader, emit func(StreamEvent)) error {
+	reader := bufio.NewReader(r)
+
+	var event string
+	var data strings.Builder
+	hasData := false
+
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			// An incomplete event at the end of the stream is discarded
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+		line = strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r")
+
+		if line == "" {
+			if hasData {
+				emit(StreamEvent{
+					Time:  time.Now(),
+					Event: event,
+					ID:    p.lastID,
+					Data:  strings.TrimSuffix(data.String(), "\n"),
+				})
+			}
+			event = ""
+			data.Reset()
+			hasData = false
+			continue
+		}
+		if strings.HasPrefix(line, ":") {
+			continue // Comment, often used as keep-alive
+		}
+
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+
+		switch field {
+		case "event":
+			event = value
+		case "data":
+			data.WriteString(value)
+			data.WriteString("\n")
+			hasData = true
+		case "id":
+			if !strings.ContainsRune(value, 0) {
+				p.lastID = value
+			}
+		case "retry":
+			if ms, err := strconv.Atoi(value); err == nil && ms >= 0 {
+				p.retry = time.Duration(ms) * time.Millisecond
+			}
+		}
+	}
+}
+
+// readNDJSON emits every non-empty line as an event
+func readNDJSON(r io.Reader, emit func(StreamEvent)) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		emit(StreamEvent{Time: time.Now(), Data: line})
+	}
+	return scanner.Err()
+}
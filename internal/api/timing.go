@@ -0,0 +1,154 @@
+package api
+
+import (
+	"crypto/tls"
+	"net/http/httptrace"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// Timing holds a breakdown of where time was spent during a request
+type Timing struct {
+	DNS        time.Duration
+	Connect    time.Duration
+	TLS        time.Duration
+	Wait       time.Duration // Request written until first response byte
+	TTFB       time.Duration // Request start until first response byte
+	Transfer   time.Duration // First response byte until body fully read
+	Total      time.Duration
+	ConnReused bool
+	RemoteAddr string
+	Proxy      string // Proxy the request went through, credentials redacted
+	Phases     []TimingPhase
+}
+
+// TimingPhase is a single bar of the request waterfall, relative to request start
+type TimingPhase struct {
+	Name     string
+	Offset   time.Duration
+	Duration time.Duration
+}
+
+// timingRecorder collects httptrace events for a single request
+type timingRecorder struct {
+	mu           sync.Mutex
+	start        time.Time
+	dnsStart     time.Time
+	dnsDone      time.Time
+	connectStart time.Time
+	connectDone  time.Time
+	tlsStart     time.Time
+	tlsDone      time.Time
+	wroteRequest time.Time
+	firstByte    time.Time
+	reused       bool
+	remoteAddr   string
+	proxy        string
+}
+
+// timingRecorderKey carries the recorder in the request context so the
+// transport's proxy selection can report to it
+type timingRecorderKey struct{}
+
+func newTimingRecorder(start time.Time) *timingRecorder {
+	return &timingRecorder{start: start}
+}
+
+// trace returns the httptrace hooks feeding this recorder
+func (r *timingRecorder) trace() *httptrace.ClientTrace {
+	return &httptrace.ClientTrace{
+		DNSStart: func(httptrace.DNSStartInfo) {
+			r.mu.Lock()
+			defer r.mu.Unlock()
+			r.dnsStart = time.Now()
+		},
+		DNSDone: func(httptrace.DNSDoneInfo) {
+			r.mu.Lock()
+			defer r.mu.Unlock()
+			r.dnsDone = time.Now()
+		},
+		ConnectStart: func(network, addr string) {
+			r.mu.Lock()
+			defer r.mu.Unlock()
+			// Dialers may race several addresses; keep the earliest attempt
+			if r.connectStart.IsZero() {
+				r.connectStart = time.Now()
+			}
+		},
+		ConnectDone: func(network, addr string, err error) {
+			r.mu.Lock()
+			defer r.mu.Unlock()
+			if err == nil {
+				r.connectDone = time.Now()
+			}
+		},
+		TLSHandshakeStart: func() {
+			r.mu.Lock()
+			defer r.mu.Unlock()
+			r.tlsStart = time.Now()
+		},
+		TLSHandshakeDone: func(tls.ConnectionState, error) {
+			r.mu.Lock()
+			defer r.mu.Unlock()
+			r.tlsDone = time.Now()
+		},
+		GotConn: func(info httptrace.GotConnInfo) {
+			r.mu.Lock()
+			defer r.mu.Unlock()
+			r.reused = info.Reused
+			if info.Conn != nil {
+				r.remoteAddr = info.Conn.RemoteAddr().String()
+			}
+		},
+		WroteRequest: func(httptrace.WroteRequestInfo) {
+			r.mu.Lock()
+			defer r.mu.Unlock()
+			r.wroteRequest = time.Now()
+		},
+		GotFirstResponseByte: func() {
+			r.mu.Lock()
+			defer r.mu.Unlock()
+			r.firstByte = time.Now()
+		},
+	}
+}
+
+func (r *timingRecorder) setProxy(u *url.URL) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.proxy = u.Redacted()
+}
+
+// finish builds the timing breakdown once the body has been read
+func (r *timingRecorder) finish(end time.Time) *Timing {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	t := &Timing{
+		Total:      end.Sub(r.start),
+		ConnReused: r.reused,
+		RemoteAddr: r.remoteAddr,
+		Proxy:      r.proxy,
+	}
+
+	addPhase := func(name string, from, to time.Time) time.Duration {
+		if from.IsZero() || to.IsZero() {
+			return 0
+		}
+		d := to.Sub(from)
+		t.Phases = append(t.Phases, TimingPhase{Name: name, Offset: from.Sub(r.start), Duration: d})
+		return d
+	}
+
+	t.DNS = addPhase("DNS lookup", r.dnsStart, r.dnsDone)
+	t.Connect = addPhase("TCP connect", r.connectStart, r.connectDone)
+	t.TLS = addPhase("TLS handshake", r.tlsStart, r.tlsDone)
+	t.Wait = addPhase("Server wait", r.wroteRequest, r.firstByte)
+	t.Transfer = addPhase("Content transfer", r.firstByte, end)
+	if !r.firstByte.IsZero() {
+		t.TTFB = r.firstByte.Sub(r.start)
+	}
+
+	return t
+}
@@ -0,0 +1,82 @@
+package api
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// TLSConfig holds TLS options for connections to the API
+type TLSConfig struct {
+	CertFile           string `yaml:"cert"`        // Client certificate (PEM)
+	KeyFile            string `yaml:"key"`         // Client private key (PEM)
+	CAFile             string `yaml:"ca"`          // CA bundle trusted in addition to the system roots
+	ServerName         string `yaml:"server_name"` // Overrides the name verified against the certificate
+	InsecureSkipVerify bool   `yaml:"insecure"`
+	MinVersion         string `yaml:"min_version"` // 1.0, 1.1, 1.2 or 1.3
+}
+
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// ClientConfig builds a crypto/tls configuration from the options
+func (t TLSConfig) ClientConfig() (*tls.Config, error) {
+	config := &tls.Config{
+		ServerName:         t.ServerName,
+		InsecureSkipVerify: t.InsecureSkipVerify,
+	}
+
+	if t.MinVersion != "" {
+		version, ok := tlsVersions[strings.TrimPrefix(strings.TrimSpace(t.MinVersion), "TLS")]
+		if !ok {
+			return nil, fmt.Errorf("unsupported TLS version %q (1.0, 1.1, 1.2, 1.3)", t.MinVersion)
+		}
+		config.MinVersion = version
+	}
+
+	if t.CAFile != "" {
+		pem, err := os.ReadFile(t.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA bundle: %w", err)
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in CA bundle %s", t.CAFile)
+		}
+		config.RootCAs = pool
+	}
+
+	if t.CertFile != "" || t.KeyFile != "" {
+		cert, err := loadClientCertificate(t.CertFile, t.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+		config.Certificates = []tls.Certificate{*cert}
+	}
+
+	return config, nil
+}
+
+// loadClientCertificate loads a certificate and key; the key may be bundled in the certificate file
+func loadClientCertificate(certFile, keyFile string) (*tls.Certificate, error) {
+	if certFile == "" {
+		return nil, fmt.Errorf("a client certificate is required with a client key")
+	}
+	if keyFile == "" {
+		keyFile = certFile
+	}
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load client certificate: %w", err)
+	}
+	return &cert, nil
+}
@@ -2,7 +2,9 @@ package api
 
 import (
 	"fmt"
+	"strings"
 
+	"github.com/doganarif/ApiMug/pkg/spec"
 	"github.com/getkin/kin-openapi/openapi3"
 )
 
@@ -13,6 +15,7 @@ type Spec struct {
 	Title    string
 	Version  string
 	BaseURL  string
+	Warnings []spec.Diagnostic // Validation problems of a spec loaded leniently
 }
 
 // Parameter represents a request parameter
@@ -23,18 +26,26 @@ type Parameter struct {
 	Description string
 	Schema      string
 	Example     string
+	SchemaRef   *openapi3.SchemaRef
 }
 
 // Endpoint represents an API endpoint
 type Endpoint struct {
 	Path         string
 	Method       string
+	OperationID  string
 	Summary      string
 	Description  string
 	Tags         []string
 	Parameters   []Parameter
 	RequestBody  string
 	HasBody      bool
+	Servers      []Server // Path or operation level overrides
+	Security     *openapi3.SecurityRequirements // Operation level, nil inherits the document's
+	WebSocket    bool     // Upgrades to a WebSocket, see isWebSocket
+	Links        []Link   // Links of the operation's responses
+	Responses    *openapi3.Responses
+	Body         *openapi3.RequestBody // Documented request body, nil when there is none
 }
 
 // GetEndpoints extracts all endpoints from the spec
@@ -50,14 +61,26 @@ func (s *Spec) GetEndpoints() []Endpoint {
 			endpoint := Endpoint{
 				Path:        path,
 				Method:      method,
+				OperationID: operation.OperationID,
 				Summary:     operation.Summary,
 				Description: operation.Description,
 				Tags:        operation.Tags,
-				Parameters:  extractParameters(operation),
+				Parameters:  extractParameters(pathItem, operation),
 				HasBody:     operation.RequestBody != nil,
+				Security:    operation.Security,
+				WebSocket:   isWebSocket(pathItem, operation),
+				Links:       extractLinks(operation),
+				Responses:   operation.Responses,
+			}
+
+			if operation.Servers != nil && len(*operation.Servers) > 0 {
+				endpoint.Servers = s.resolveServers(convertServers(*operation.Servers))
+			} else if len(pathItem.Servers) > 0 {
+				endpoint.Servers = s.resolveServers(convertServers(pathItem.Servers))
 			}
 
 			if operation.RequestBody != nil && operation.RequestBody.Value != nil {
+				endpoint.Body = operation.RequestBody.Value
 				content := operation.RequestBody.Value.Content
 				if jsonContent := content.Get("application/json"); jsonContent != nil && jsonContent.Example != nil {
 					endpoint.RequestBody = formatExample(jsonContent.Example)
@@ -71,10 +94,84 @@ func (s *Spec) GetEndpoints() []Endpoint {
 	return endpoints
 }
 
-func extractParameters(op *openapi3.Operation) []Parameter {
+// Ref identifies the endpoint by its operationId, or by method and path when it has none
+func (e *Endpoint) Ref() string {
+	if e.OperationID != "" {
+		return e.OperationID
+	}
+	return strings.ToUpper(e.Method) + " " + e.Path
+}
+
+// Matches reports whether ref, as returned by Ref, names the endpoint
+func (e *Endpoint) Matches(ref string) bool {
+	if e.OperationID != "" && ref == e.OperationID {
+		return true
+	}
+	method, path, ok := strings.Cut(strings.TrimSpace(ref), " ")
+	return ok && strings.EqualFold(method, e.Method) && strings.TrimSpace(path) == e.Path
+}
+
+// NewRequest builds a request for the endpoint, placing each parameter value
+// where the spec declares it. Empty values are left out.
+func (e *Endpoint) NewRequest(params map[string]string, body string) *Request {
+	req := &Request{
+		Method:      strings.ToUpper(e.Method),
+		Path:        e.Path,
+		Template:    e.Path,
+		PathParams:  make(map[string]string),
+		QueryParams: make(map[string]string),
+		Headers:     make(map[string]string),
+		Servers:     e.Servers,
+		Security:    e.Security,
+	}
+
+	for _, p := range e.Parameters {
+		val := params[p.Name]
+		if val == "" {
+			continue
+		}
+		switch p.In {
+		case "query":
+			req.QueryParams[p.Name] = val
+		case "header":
+			req.Headers[p.Name] = val
+		case "path":
+			req.PathParams[p.Name] = val
+		}
+	}
+	req.fillPath()
+
+	if e.HasBody {
+		req.Body = body
+		req.ContentType = "application/json"
+	}
+	return req
+}
+
+// isWebSocket reports whether an operation upgrades to a WebSocket, either
+// marked with the x-websocket extension or documenting a 101 response
+func isWebSocket(pathItem *openapi3.PathItem, op *openapi3.Operation) bool {
+	for _, extensions := range []map[string]any{op.Extensions, pathItem.Extensions} {
+		if v, ok := extensions[WebSocketExtension]; ok && v != false {
+			return true
+		}
+	}
+	return op.Responses != nil && op.Responses.Value("101") != nil
+}
+
+// extractParameters returns the operation's parameters followed by those of the
+// path item that the operation does not override
+func extractParameters(pathItem *openapi3.PathItem, op *openapi3.Operation) []Parameter {
 	var params []Parameter
 
-	for _, paramRef := range op.Parameters {
+	refs := append(openapi3.Parameters{}, op.Parameters...)
+	for _, paramRef := range pathItem.Parameters {
+		if paramRef.Value != nil && op.Parameters.GetByInAndName(paramRef.Value.In, paramRef.Value.Name) == nil {
+			refs = append(refs, paramRef)
+		}
+	}
+
+	for _, paramRef := range refs {
 		if paramRef.Value == nil {
 			continue
 		}
@@ -88,11 +185,17 @@ func extractParameters(op *openapi3.Operation) []Parameter {
 		}
 
 		if p.Schema != nil && p.Schema.Value != nil {
-			param.Schema = p.Schema.Value.Type.Slice()[0]
+			param.SchemaRef = p.Schema
+			if types := p.Schema.Value.Type.Slice(); len(types) > 0 {
+				param.Schema = types[0]
+			}
 			if p.Schema.Value.Example != nil {
 				param.Example = formatExample(p.Schema.Value.Example)
 			}
 		}
+		if p.Example != nil {
+			param.Example = formatExample(p.Example)
+		}
 
 		params = append(params, param)
 	}
@@ -0,0 +1,87 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// maxSchemaErrors bounds the number of violations reported for one response
+const maxSchemaErrors = 5
+
+// DocumentedResponse returns the response the spec documents for a status code,
+// preferring the exact code over its range and the range over default. The
+// matching key is returned alongside.
+func (e *Endpoint) DocumentedResponse(status int) (*openapi3.Response, string) {
+	if e.Responses == nil {
+		return nil, ""
+	}
+	code := strconv.Itoa(status)
+	for _, key := range []string{code, code[:1] + "XX", "default"} {
+		if ref := e.Responses.Value(key); ref != nil && ref.Value != nil {
+			return ref.Value, key
+		}
+	}
+	return nil, ""
+}
+
+// ValidateResponse checks that the status code is documented and that a JSON
+// body matches the schema documented for its media type
+func (e *Endpoint) ValidateResponse(resp *Response) error {
+	documented, _ := e.DocumentedResponse(resp.StatusCode)
+	if documented == nil {
+		return fmt.Errorf("status %d is not documented", resp.StatusCode)
+	}
+	if len(documented.Content) == 0 || len(resp.BodyBytes) == 0 {
+		return nil
+	}
+
+	mediaType := resp.MediaType()
+	content := documented.Content.Get(mediaType)
+	if content == nil {
+		return fmt.Errorf("content type %q is not documented for status %d", mediaType, resp.StatusCode)
+	}
+	if content.Schema == nil || content.Schema.Value == nil || !strings.Contains(mediaType, "json") {
+		return nil
+	}
+
+	var body any
+	if err := json.Unmarshal(resp.BodyBytes, &body); err != nil {
+		return fmt.Errorf("body is not valid JSON: %w", err)
+	}
+	if err := content.Schema.Value.VisitJSON(body, openapi3.VisitAsResponse(), openapi3.MultiErrors()); err != nil {
+		return schemaViolations(err)
+	}
+	return nil
+}
+
+// schemaViolations condenses schema errors to one line per violation
+func schemaViolations(err error) error {
+	var lines []string
+	var collect func(error)
+	collect = func(err error) {
+		var multi openapi3.MultiError
+		var schemaErr *openapi3.SchemaError
+		switch {
+		case errors.As(err, &multi):
+			for _, e := range multi {
+				collect(e)
+			}
+		case errors.As(err, &schemaErr):
+			pointer := "/" + strings.Join(schemaErr.JSONPointer(), "/")
+			lines = append(lines, fmt.Sprintf("%s: %s", pointer, schemaErr.Reason))
+		default:
+			lines = append(lines, err.Error())
+		}
+	}
+	collect(err)
+
+	if len(lines) > maxSchemaErrors {
+		lines = append(lines[:maxSchemaErrors], fmt.Sprintf("and %d more", len(lines)-maxSchemaErrors))
+	}
+	return fmt.Errorf("body does not match the schema: %s", strings.Join(lines, "; "))
+}
@@ -0,0 +1,56 @@
+package api
+
+import (
+	"regexp"
+	"strings"
+)
+
+// variablePattern matches {{name}} references
+var variablePattern = regexp.MustCompile(`\{\{\s*([A-Za-z0-9_.-]+)\s*\}\}`)
+
+// Variables are values referenced as {{name}} in requests. They start out as
+// the environment's variables and are updated by extraction rules.
+type Variables map[string]string
+
+// Expand replaces {{name}} references, leaving unknown ones untouched
+func (v Variables) Expand(s string) string {
+	if !strings.Contains(s, "{{") {
+		return s
+	}
+	return variablePattern.ReplaceAllStringFunc(s, func(ref string) string {
+		name := variablePattern.FindStringSubmatch(ref)[1]
+		if value, ok := v[name]; ok {
+			return value
+		}
+		return ref
+	})
+}
+
+// ExpandRequest expands references in the path, query, headers and body of
+// req. Path parameters are escaped once expanded.
+func (v Variables) ExpandRequest(req *Request) {
+	for name, value := range req.QueryParams {
+		req.QueryParams[name] = v.Expand(value)
+	}
+	for name, value := range req.Headers {
+		req.Headers[name] = v.Expand(value)
+	}
+	for name, value := range req.PathParams {
+		req.PathParams[name] = v.Expand(value)
+	}
+	if req.Template != "" {
+		req.fillPath()
+	} else {
+		req.Path = v.Expand(req.Path)
+	}
+	req.Body = v.Expand(req.Body)
+}
+
+// Clone returns a copy that can be changed independently
+func (v Variables) Clone() Variables {
+	clone := make(Variables, len(v))
+	for name, value := range v {
+		clone[name] = value
+	}
+	return clone
+}
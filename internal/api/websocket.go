@@ -0,0 +1,355 @@
+package api
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// webSocketGUID is appended to the handshake key as RFC 6455 specifies
+const webSocketGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// maxWebSocketMessage bounds the size of a reassembled message
+const maxWebSocketMessage = 16 << 20
+
+// WebSocketExtension marks operations that upgrade to a WebSocket
+const WebSocketExtension = "x-websocket"
+
+// Opcodes of WebSocket frames
+const (
+	OpContinuation = 0x0
+	OpText         = 0x1
+	OpBinary       = 0x2
+	OpClose        = 0x8
+	OpPing         = 0x9
+	OpPong         = 0xA
+)
+
+// WebSocketMessage is a message sent or received during a session
+type WebSocketMessage struct {
+	Time      time.Time
+	Outgoing  bool
+	Opcode    int
+	Data      []byte
+	CloseCode int // Status code of close frames
+}
+
+// WebSocket is an open WebSocket session
+type WebSocket struct {
+	Response *Response // Handshake response
+
+	conn   io.ReadWriteCloser
+	reader *bufio.Reader
+
+	// A fragmented message being read, kept across the control frames
+	// that may arrive between its fragments
+	opcode   int
+	fragment []byte
+
+	mu      sync.Mutex // Serializes writes
+	closing bool       // A close frame was sent
+}
+
+// ErrWebSocketClosed is returned once the closing handshake completed
+var ErrWebSocketClosed = errors.New("websocket closed")
+
+// DialWebSocket opens a WebSocket session to the request's URL, applying
+// authentication, TLS and proxy settings like any other request
+func (c *Client) DialWebSocket(ctx context.Context, req *Request) (*WebSocket, error) {
+	timeout := c.timeout
+	if req.Timeout > 0 {
+		timeout = req.Timeout
+	}
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	keyBytes := make([]byte, 16)
+	if _, err := rand.Read(keyBytes); err != nil {
+		return nil, fmt.Errorf("failed to generate handshake key: %w", err)
+	}
+	key := base64.StdEncoding.EncodeToString(keyBytes)
+
+	upgrade := *req
+	upgrade.Method = http.MethodGet
+	upgrade.Body = ""
+	upgrade.Headers = make(map[string]string, len(req.Headers)+4)
+	for k, v := range req.Headers {
+		upgrade.Headers[k] = v
+	}
+	upgrade.Headers["Connection"] = "Upgrade"
+	upgrade.Headers["Upgrade"] = "websocket"
+	upgrade.Headers["Sec-WebSocket-Version"] = "13"
+	upgrade.Headers["Sec-WebSocket-Key"] = key
+
+	start := time.Now()
+	// Servers may be declared with ws:// and wss:// URLs
+	url := c.URL(req)
+	if rest, ok := strings.CutPrefix(url, "ws://"); ok {
+		url = "http://" + rest
+	} else if rest, ok := strings.CutPrefix(url, "wss://"); ok {
+		url = "https://" + rest
+	}
+
+	httpReq, signatures, err := c.newHTTPRequest(ctx, &upgrade, url)
+	if err != nil {
+		return nil, err
+	}
+
+	// The upgrade needs HTTP/1.1, so HTTP/2 must not be negotiated
+	transport := c.transport.Clone()
+	transport.ForceAttemptHTTP2 = false
+	transport.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+	if transport.TLSClientConfig != nil {
+		transport.TLSClientConfig.NextProtos = nil
+	}
+	httpClient := &http.Client{
+		Transport: transport,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	httpResp, err := httpClient.Do(httpReq)
+	if err != nil {
+		return nil, requestError(ctx, err, timeout)
+	}
+
+	resp := &Response{
+		StatusCode: httpResp.StatusCode,
+		Status:     httpResp.Status,
+		Headers:    httpResp.Header,
+		URL:        httpResp.Request.URL.String(),
+		Duration:   time.Since(start),
+		Signatures: signatures,
+	}
+
+	if httpResp.StatusCode != http.StatusSwitchingProtocols {
+		body, _ := io.ReadAll(io.LimitReader(httpResp.Body, 64*1024))
+		httpResp.Body.Close()
+		return nil, fmt.Errorf("server did not upgrade the connection: %s %s", httpResp.Status, strings.TrimSpace(string(body)))
+	}
+
+	conn, ok := httpResp.Body.(io.ReadWriteCloser)
+	if !ok {
+		httpResp.Body.Close()
+		return nil, fmt.Errorf("upgraded connection is not writable")
+	}
+
+	sum := sha1.Sum([]byte(key + webSocketGUID))
+	if httpResp.Header.Get("Sec-WebSocket-Accept") != base64.StdEncoding.EncodeToString(sum[:]) {
+		conn.Close()
+		return nil, fmt.Errorf("invalid Sec-WebSocket-Accept in handshake response")
+	}
+
+	return &WebSocket{Response: resp, conn: conn, reader: bufio.NewReader(conn)}, nil
+}
+
+// SendText sends a text message
+func (ws *WebSocket) SendText(text string) (WebSocketMessage, error) {
+	msg := WebSocketMessage{Time: time.Now(), Outgoing: true, Opcode: OpText, Data: []byte(text)}
+	if ws.isClosing() {
+		return msg, ErrWebSocketClosed
+	}
+	return msg, ws.writeFrame(OpText, msg.Data)
+}
+
+// Ping sends a ping, the pong is reported by ReadMessage
+func (ws *WebSocket) Ping() (WebSocketMessage, error) {
+	msg := WebSocketMessage{Time: time.Now(), Outgoing: true, Opcode: OpPing}
+	if ws.isClosing() {
+		return msg, ErrWebSocketClosed
+	}
+	return msg, ws.writeFrame(OpPing, nil)
+}
+
+func (ws *WebSocket) isClosing() bool {
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	return ws.closing
+}
+
+// Close starts the closing handshake. ReadMessage returns ErrWebSocketClosed
+// once the server confirms; the connection is dropped if it does not in time.
+func (ws *WebSocket) Close() (WebSocketMessage, error) {
+	msg := WebSocketMessage{Time: time.Now(), Outgoing: true, Opcode: OpClose, CloseCode: 1000}
+
+	ws.mu.Lock()
+	already := ws.closing
+	ws.closing = true
+	ws.mu.Unlock()
+	if already {
+		return msg, nil
+	}
+
+	payload := make([]byte, 2)
+	binary.BigEndian.PutUint16(payload, 1000)
+	err := ws.writeFrame(OpClose, payload)
+
+	time.AfterFunc(3*time.Second, func() { ws.conn.Close() })
+	return msg, err
+}
+
+// ReadMessage returns the next message from the server. Fragmented messages
+// are reassembled and pings are answered automatically but still reported.
+func (ws *WebSocket) ReadMessage() (WebSocketMessage, error) {
+	for {
+		fin, op, payload, err := ws.readFrame()
+		if err != nil {
+			ws.conn.Close()
+			if ws.isClosing() {
+				return WebSocketMessage{}, ErrWebSocketClosed
+			}
+			return WebSocketMessage{}, fmt.Errorf("connection lost: %w", err)
+		}
+
+		switch op {
+		case OpPing:
+			ws.writeFrame(OpPong, payload)
+			return WebSocketMessage{Time: time.Now(), Opcode: OpPing, Data: payload}, nil
+
+		case OpPong:
+			return WebSocketMessage{Time: time.Now(), Opcode: OpPong, Data: payload}, nil
+
+		case OpClose:
+			msg := WebSocketMessage{Time: time.Now(), Opcode: OpClose}
+			if len(payload) >= 2 {
+				msg.CloseCode = int(binary.BigEndian.Uint16(payload))
+				msg.Data = payload[2:]
+			}
+
+			ws.mu.Lock()
+			initiated := ws.closing
+			ws.closing = true
+			ws.mu.Unlock()
+			if !initiated {
+				// Echo the status code to complete the server initiated handshake
+				echo := payload
+				if len(echo) > 2 {
+					echo = echo[:2]
+				}
+				ws.writeFrame(OpClose, echo)
+			}
+			ws.conn.Close()
+			return msg, nil
+
+		case OpContinuation:
+			if ws.opcode == 0 {
+				return WebSocketMessage{}, fmt.Errorf("unexpected continuation frame")
+			}
+			ws.fragment = append(ws.fragment, payload...)
+
+		default:
+			if ws.opcode != 0 {
+				ws.conn.Close()
+				return WebSocketMessage{}, fmt.Errorf("new message before the end of a fragmented one")
+			}
+			ws.opcode = op
+			ws.fragment = payload
+		}
+
+		if len(ws.fragment) > maxWebSocketMessage {
+			ws.conn.Close()
+			return WebSocketMessage{}, fmt.Errorf("message exceeds %d bytes", maxWebSocketMessage)
+		}
+		if fin {
+			msg := WebSocketMessage{Time: time.Now(), Opcode: ws.opcode, Data: ws.fragment}
+			ws.opcode, ws.fragment = 0, nil
+			return msg, nil
+		}
+	}
+}
+
+func (ws *WebSocket) readFrame() (fin bool, opcode int, payload []byte, err error) {
+	var header [2]byte
+	if _, err = io.ReadFull(ws.reader, header[:]); err != nil {
+		return
+	}
+
+	fin = header[0]&0x80 != 0
+	opcode = int(header[0] & 0x0F)
+	masked := header[1]&0x80 != 0
+	length := uint64(header[1] & 0x7F)
+
+	switch length {
+	case 126:
+		var ext [2]byte
+		if _, err = io.ReadFull(ws.reader, ext[:]); err != nil {
+			return
+		}
+		length = uint64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err = io.ReadFull(ws.reader, ext[:]); err != nil {
+			return
+		}
+		length = binary.BigEndian.Uint64(ext[:])
+	}
+	if length > maxWebSocketMessage {
+		err = fmt.Errorf("frame exceeds %d bytes", maxWebSocketMessage)
+		return
+	}
+
+	var mask [4]byte
+	if masked {
+		if _, err = io.ReadFull(ws.reader, mask[:]); err != nil {
+			return
+		}
+	}
+
+	payload = make([]byte, length)
+	if _, err = io.ReadFull(ws.reader, payload); err != nil {
+		return
+	}
+	if masked {
+		for i := range payload {
+			payload[i] ^= mask[i%4]
+		}
+	}
+	return
+}
+
+// writeFrame writes a single masked frame, as required for clients
+func (ws *WebSocket) writeFrame(opcode int, payload []byte) error {
+	frame := []byte{0x80 | byte(opcode)}
+
+	switch n := len(payload); {
+	case n < 126:
+		frame = append(frame, 0x80|byte(n))
+	case n <= 0xFFFF:
+		frame = append(frame, 0x80|126, 0, 0)
+		binary.BigEndian.PutUint16(frame[2:], uint16(n))
+	default:
+		frame = append(frame, 0x80|127, 0, 0, 0, 0, 0, 0, 0, 0)
+		binary.BigEndian.PutUint64(frame[2:], uint64(n))
+	}
+
+	var mask [4]byte
+	if _, err := rand.Read(mask[:]); err != nil {
+		return fmt.Errorf("failed to generate mask: %w", err)
+	}
+	frame = append(frame, mask[:]...)
+	for i, b := range payload {
+		frame = append(frame, b^mask[i%4])
+	}
+
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	if _, err := ws.conn.Write(frame); err != nil {
+		return fmt.Errorf("failed to send frame: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,262 @@
+// Package bench replays a request under load and summarises the latencies and
+// statuses it got.
+package bench
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/doganarif/ApiMug/internal/api"
+)
+
+// histogramBuckets is the number of latency buckets in a result
+const histogramBuckets = 10
+
+// MaxRate is the highest rate a run can pace, one request per nanosecond
+const MaxRate = 1e9
+
+// Options controls a benchmark run. It stops at the first of its duration,
+// its request count and cancellation.
+type Options struct {
+	Concurrency int           // Requests in flight at once, at least 1
+	Rate        float64       // Requests per second across all workers, zero for no limit
+	Duration    time.Duration // Zero runs until the request count is reached
+	Requests    int           // Zero runs until the duration is over
+}
+
+// Result summarises the responses recorded so far
+type Result struct {
+	Elapsed    time.Duration  `json:"elapsed_ns"`
+	Requests   int            `json:"requests"`
+	Errors     int            `json:"errors"`
+	Throughput float64        `json:"throughput"` // Requests per second
+	Min        time.Duration  `json:"min_ns"`
+	Mean       time.Duration  `json:"mean_ns"`
+	P50        time.Duration  `json:"p50_ns"`
+	P90        time.Duration  `json:"p90_ns"`
+	P99        time.Duration  `json:"p99_ns"`
+	Max        time.Duration  `json:"max_ns"`
+	Statuses   map[int]int    `json:"statuses"`
+	ErrorKinds map[string]int `json:"error_kinds,omitempty"` // Errors by message
+	Histogram  []Bucket       `json:"histogram"`
+	Done       bool           `json:"-"`
+}
+
+// Bucket counts the responses with a latency in [From, To)
+type Bucket struct {
+	From  time.Duration `json:"from_ns"`
+	To    time.Duration `json:"to_ns"`
+	Count int           `json:"count"`
+}
+
+// Recorder collects responses while a benchmark runs; it is safe to read
+// results from another goroutine
+type Recorder struct {
+	mu        sync.Mutex
+	started   time.Time
+	finished  time.Time
+	latencies []time.Duration
+	statuses  map[int]int
+	errors    map[string]int
+	errCount  int
+}
+
+// NewRecorder creates an empty recorder
+func NewRecorder() *Recorder {
+	return &Recorder{statuses: make(map[int]int), errors: make(map[string]int)}
+}
+
+func (r *Recorder) start() {
+	r.mu.Lock()
+	r.started = time.Now()
+	r.mu.Unlock()
+}
+
+func (r *Recorder) finish() {
+	r.mu.Lock()
+	r.finished = time.Now()
+	r.mu.Unlock()
+}
+
+func (r *Recorder) record(resp *api.Response) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if resp.Error != nil {
+		r.errCount++
+		r.errors[resp.Error.Error()]++
+		return
+	}
+	r.latencies = append(r.latencies, resp.Duration)
+	r.statuses[resp.StatusCode]++
+}
+
+// Result summarises what has been recorded so far
+func (r *Recorder) Result() *Result {
+	r.mu.Lock()
+	latencies := append([]time.Duration(nil), r.latencies...)
+	res := &Result{
+		Errors:     r.errCount,
+		Statuses:   make(map[int]int, len(r.statuses)),
+		ErrorKinds: make(map[string]int, len(r.errors)),
+		Done:       !r.finished.IsZero(),
+	}
+	for status, n := range r.statuses {
+		res.Statuses[status] = n
+	}
+	for msg, n := range r.errors {
+		res.ErrorKinds[msg] = n
+	}
+	switch {
+	case r.started.IsZero():
+	case res.Done:
+		res.Elapsed = r.finished.Sub(r.started)
+	default:
+		res.Elapsed = time.Since(r.started)
+	}
+	r.mu.Unlock()
+
+	res.Requests = len(latencies) + res.Errors
+	if res.Elapsed > 0 {
+		res.Throughput = float64(res.Requests) / res.Elapsed.Seconds()
+	}
+	if len(latencies) == 0 {
+		return res
+	}
+
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	var total time.Duration
+	for _, d := range latencies {
+		total += d
+	}
+	res.Min = latencies[0]
+	res.Max = latencies[len(latencies)-1]
+	res.Mean = total / time.Duration(len(latencies))
+	res.P50 = percentile(latencies, 50)
+	res.P90 = percentile(latencies, 90)
+	res.P99 = percentile(latencies, 99)
+	res.Histogram = histogram(latencies, histogramBuckets)
+	return res
+}
+
+// percentile returns the nearest-rank percentile of sorted latencies
+func percentile(sorted []time.Duration, p int) time.Duration {
+	rank := (p*len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+// histogram splits the latencies between the fastest and the 99th percentile
+// into equal buckets, so that a few outliers do not squeeze the rest into
+// one; the last bucket also holds the slower tail
+func histogram(sorted []time.Duration, n int) []Bucket {
+	lo, hi := sorted[0], percentile(sorted, 99)
+	width := (hi - lo) / time.Duration(n)
+	if width <= 0 {
+		return []Bucket{{From: lo, To: sorted[len(sorted)-1] + 1, Count: len(sorted)}}
+	}
+
+	buckets := make([]Bucket, n)
+	for i := range buckets {
+		buckets[i].From = lo + time.Duration(i)*width
+		buckets[i].To = buckets[i].From + width
+	}
+	buckets[n-1].To = sorted[len(sorted)-1] + 1
+	for _, d := range sorted {
+		i := int((d - lo) / width)
+		if i >= n {
+			i = n - 1
+		}
+		buckets[i].Count++
+	}
+	return buckets
+}
+
+// CheckRate returns an error unless rate is a rate Run can pace
+func CheckRate(rate float64) error {
+	if math.IsNaN(rate) || rate < 0 || rate > MaxRate {
+		return fmt.Errorf("invalid rate %g: expected requests per second up to %g", rate, MaxRate)
+	}
+	return nil
+}
+
+// NewClient returns a copy of client for a run with the options' concurrency,
+// so that the workers keep their connections without changing the client
+func NewClient(client *api.Client, opts Options) *api.Client {
+	return client.Clone(max(opts.Concurrency, 1))
+}
+
+// Run sends req repeatedly with the options' concurrency and rate, recording
+// the responses, and returns once the run is over. The client should be one of
+// its own, see NewClient.
+func Run(ctx context.Context, client *api.Client, req *api.Request, opts Options, rec *Recorder) {
+	if opts.Duration > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, opts.Duration)
+		defer cancel()
+	}
+
+	var tick <-chan time.Time
+	if opts.Rate > 0 {
+		interval := max(time.Duration(float64(time.Second)/opts.Rate), time.Nanosecond)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	workers := opts.Concurrency
+	if workers < 1 {
+		workers = 1
+	}
+
+	var issued atomic.Int64
+	var wg sync.WaitGroup
+	rec.start()
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				if tick != nil {
+					select {
+					case <-tick:
+					case <-ctx.Done():
+						return
+					}
+				}
+				if ctx.Err() != nil {
+					return
+				}
+				if opts.Requests > 0 && issued.Add(1) > int64(opts.Requests) {
+					return
+				}
+
+				resp := client.Send(ctx, req)
+				if resp.Error != nil && ctx.Err() != nil {
+					return // Cut short by the end of the run, not a failure
+				}
+				rec.record(resp)
+			}
+		}()
+	}
+	wg.Wait()
+	rec.finish()
+}
+
+// WriteJSON writes the result as indented JSON
+func WriteJSON(w io.Writer, result *Result) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(result); err != nil {
+		return fmt.Errorf("failed to encode JSON report: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,227 @@
+// Package collection stores named requests against a spec's operations in a
+// YAML file kept next to the spec, so that they can be versioned with it.
+package collection
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/doganarif/ApiMug/internal/api"
+	"github.com/getkin/kin-openapi/openapi3"
+	"gopkg.in/yaml.v3"
+)
+
+// AuthNone sends a saved request without credentials
+const AuthNone = "none"
+
+// File is a collections file
+type File struct {
+	Collections []*Collection `yaml:"collections"`
+
+	path string
+}
+
+// Collection groups saved requests
+type Collection struct {
+	Name     string     `yaml:"name"`
+	Requests []*Request `yaml:"requests"`
+}
+
+// Request is a saved request. Operation is the operationId, or "METHOD /path"
+// for operations without one.
+type Request struct {
+	Name      string            `yaml:"name"`
+	Operation string            `yaml:"operation"`
+	Params    map[string]string `yaml:"params,omitempty"`
+	Headers   map[string]string `yaml:"headers,omitempty"`
+	Body      string            `yaml:"body,omitempty"`
+	Auth      string            `yaml:"auth,omitempty"` // Security scheme to use instead of the operation's, or "none"
+	Extract   []api.Extraction  `yaml:"extract,omitempty"`
+}
+
+// PathFor returns the collections file of a spec: name.collections.yaml next to
+// a spec file, or apimug.collections.yaml in the working directory for a URL
+func PathFor(source string) string {
+	if strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://") {
+		return "apimug.collections.yaml"
+	}
+	base := strings.TrimSuffix(source, filepath.Ext(source))
+	return base + ".collections.yaml"
+}
+
+// Load reads a collections file, a missing file is an empty one
+func Load(path string) (*File, error) {
+	f := &File{path: path}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return f, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read collections: %w", err)
+	}
+	if err := yaml.Unmarshal(data, f); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	for _, c := range f.Collections {
+		if c.Name == "" {
+			return nil, fmt.Errorf("%s: collection without a name", path)
+		}
+		for _, r := range c.Requests {
+			if r.Name == "" || r.Operation == "" {
+				return nil, fmt.Errorf("%s: request in %q needs a name and an operation", path, c.Name)
+			}
+			for _, e := range r.Extract {
+				if e.Var == "" {
+					return nil, fmt.Errorf("%s: extraction in %q without a var", path, r.Name)
+				}
+			}
+		}
+	}
+	return f, nil
+}
+
+// Path returns the location of the file
+func (f *File) Path() string {
+	return f.path
+}
+
+// Save writes the file back to disk
+func (f *File) Save() error {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(f); err != nil {
+		return fmt.Errorf("failed to encode collections: %w", err)
+	}
+	// Write to a temporary file first so a crash cannot truncate saved requests
+	tmp := f.path + ".tmp"
+	if err := os.WriteFile(tmp, buf.Bytes(), 0o644); err != nil {
+		return fmt.Errorf("failed to write collections: %w", err)
+	}
+	if err := os.Rename(tmp, f.path); err != nil {
+		return fmt.Errorf("failed to write collections: %w", err)
+	}
+	return nil
+}
+
+// Collection returns the named collection, or nil
+func (f *File) Collection(name string) *Collection {
+	for _, c := range f.Collections {
+		if c.Name == name {
+			return c
+		}
+	}
+	return nil
+}
+
+// Find resolves "collection/request", or a request name that is unique across collections
+func (f *File) Find(ref string) (*Collection, *Request, error) {
+	if name, reqName, ok := strings.Cut(ref, "/"); ok {
+		if c := f.Collection(name); c != nil {
+			if r := c.Request(reqName); r != nil {
+				return c, r, nil
+			}
+		}
+		return nil, nil, fmt.Errorf("saved request %q not found", ref)
+	}
+
+	var foundIn *Collection
+	var found *Request
+	for _, c := range f.Collections {
+		if r := c.Request(ref); r != nil {
+			if found != nil {
+				return nil, nil, fmt.Errorf("saved request %q is ambiguous: it exists in %q and %q", ref, foundIn.Name, c.Name)
+			}
+			foundIn, found = c, r
+		}
+	}
+	if found == nil {
+		return nil, nil, fmt.Errorf("saved request %q not found", ref)
+	}
+	return foundIn, found, nil
+}
+
+// Select resolves references to saved requests in order. A collection name
+// selects all of its requests, otherwise references are resolved by Find.
+func (f *File) Select(refs []string) ([]*Request, error) {
+	var requests []*Request
+	for _, ref := range refs {
+		if c := f.Collection(ref); c != nil {
+			requests = append(requests, c.Requests...)
+			continue
+		}
+		_, r, err := f.Find(ref)
+		if err != nil {
+			return nil, err
+		}
+		requests = append(requests, r)
+	}
+	return requests, nil
+}
+
+// Put adds req to the named collection, replacing a request of the same name.
+// The collection is created when missing.
+func (f *File) Put(collection string, req *Request) {
+	c := f.Collection(collection)
+	if c == nil {
+		c = &Collection{Name: collection}
+		f.Collections = append(f.Collections, c)
+	}
+	for i, r := range c.Requests {
+		if r.Name == req.Name {
+			c.Requests[i] = req
+			return
+		}
+	}
+	c.Requests = append(c.Requests, req)
+}
+
+// Request returns the named request, or nil
+func (c *Collection) Request(name string) *Request {
+	for _, r := range c.Requests {
+		if r.Name == name {
+			return r
+		}
+	}
+	return nil
+}
+
+// Endpoint finds the operation the request refers to
+func (r *Request) Endpoint(endpoints []api.Endpoint) (*api.Endpoint, error) {
+	for i := range endpoints {
+		if endpoints[i].Matches(r.Operation) {
+			return &endpoints[i], nil
+		}
+	}
+	return nil, fmt.Errorf("%s: operation %q not found in the spec", r.Name, r.Operation)
+}
+
+// Build creates the request to send for the saved request, expanding {{name}}
+// references to vars
+func (r *Request) Build(endpoint *api.Endpoint, vars api.Variables) *api.Request {
+	req := endpoint.NewRequest(r.Params, r.Body)
+	r.Apply(req)
+	vars.ExpandRequest(req)
+	return req
+}
+
+// Apply adds the saved headers and auth override to a request of the same operation
+func (r *Request) Apply(req *api.Request) {
+	for name, value := range r.Headers {
+		req.Headers[name] = value
+	}
+
+	switch r.Auth {
+	case "":
+	case AuthNone:
+		req.Security = &openapi3.SecurityRequirements{}
+	default:
+		req.Security = &openapi3.SecurityRequirements{{r.Auth: []string{}}}
+	}
+}
@@ -0,0 +1,241 @@
+// Package contract sends a generated request to every operation of a spec and
+// checks the responses against what the spec documents.
+package contract
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/doganarif/ApiMug/internal/api"
+	"gopkg.in/yaml.v3"
+)
+
+// Config supplies the values generated requests cannot guess, such as the IDs
+// of existing resources
+type Config struct {
+	Params     map[string]string     `yaml:"params"`  // Parameter values for every operation, by name
+	Headers    map[string]string     `yaml:"headers"` // Headers for every operation
+	Operations map[string]*Operation `yaml:"operations"`
+}
+
+// Operation overrides the generated request of one operation, keyed by
+// operationId or "METHOD /path"
+type Operation struct {
+	Params  map[string]string `yaml:"params"`
+	Headers map[string]string `yaml:"headers"`
+	Body    string            `yaml:"body"`
+	Skip    bool              `yaml:"skip"`
+}
+
+// LoadConfig reads a config file; an empty path gives an empty config
+func LoadConfig(path string) (*Config, error) {
+	cfg := &Config{}
+	if path == "" {
+		return cfg, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("config %s not found", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config: %w", err)
+	}
+	if err := yaml.Unmarshal(data, cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	return cfg, nil
+}
+
+// operation returns the overrides for an endpoint, or nil
+func (c *Config) operation(e *api.Endpoint) *Operation {
+	for ref, op := range c.Operations {
+		if e.Matches(ref) {
+			return op
+		}
+	}
+	return nil
+}
+
+// Request builds the request sent to an endpoint, with parameter values and
+// headers from the config and variables expanded
+func (c *Config) Request(e *api.Endpoint, vars api.Variables) *api.Request {
+	values := make(map[string]string, len(c.Params))
+	for name, value := range c.Params {
+		values[name] = value
+	}
+	op := c.operation(e)
+	if op != nil {
+		for name, value := range op.Params {
+			values[name] = value
+		}
+	}
+
+	req := e.SampleRequest(values)
+	for name, value := range c.Headers {
+		req.Headers[name] = value
+	}
+	if op != nil {
+		for name, value := range op.Headers {
+			req.Headers[name] = value
+		}
+		if op.Body != "" {
+			req.Body = op.Body
+		}
+	}
+
+	vars.ExpandRequest(req)
+	return req
+}
+
+// Result is the outcome of testing one operation
+type Result struct {
+	Operation  string        `json:"operation"`
+	Method     string        `json:"method"`
+	Path       string        `json:"path"` // Path template
+	Status     int           `json:"status,omitempty"`
+	Response   string        `json:"response,omitempty"` // Documented response the status matched
+	Documented []string      `json:"documented"`         // Documented response keys
+	Duration   time.Duration `json:"duration_ns"`
+	Error      string        `json:"error,omitempty"`
+	Skipped    string        `json:"skipped,omitempty"` // Reason the operation was not tested
+}
+
+// Passed reports whether the operation was tested and conformed
+func (r *Result) Passed() bool {
+	return r.Skipped == "" && r.Error == ""
+}
+
+// Report is the outcome of a contract test run
+type Report struct {
+	Spec     string        `json:"spec"`
+	Started  time.Time     `json:"started"`
+	Duration time.Duration `json:"duration_ns"`
+	Results  []*Result     `json:"results"`
+}
+
+// Counts returns the number of passed, failed and skipped operations
+func (r *Report) Counts() (passed, failed, skipped int) {
+	for _, result := range r.Results {
+		switch {
+		case result.Skipped != "":
+			skipped++
+		case result.Passed():
+			passed++
+		default:
+			failed++
+		}
+	}
+	return
+}
+
+// Run tests the endpoints in path and method order. include, when set,
+// selects the endpoints to test; the others are left out of the report.
+// WebSocket endpoints and those the config skips are reported as skipped.
+func Run(ctx context.Context, client *api.Client, endpoints []api.Endpoint, cfg *Config, vars api.Variables, include func(*api.Endpoint) bool, progress func(*Result)) *Report {
+	report := &Report{Started: time.Now()}
+
+	sorted := append([]api.Endpoint(nil), endpoints...)
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Path != sorted[j].Path {
+			return sorted[i].Path < sorted[j].Path
+		}
+		return methodOrder(sorted[i].Method) < methodOrder(sorted[j].Method)
+	})
+
+	for i := range sorted {
+		e := &sorted[i]
+		if include != nil && !include(e) {
+			continue
+		}
+
+		result := &Result{
+			Operation:  e.Ref(),
+			Method:     strings.ToUpper(e.Method),
+			Path:       e.Path,
+			Documented: documented(e),
+		}
+		switch op := cfg.operation(e); {
+		case ctx.Err() != nil:
+			result.Skipped = "cancelled"
+		case op != nil && op.Skip:
+			result.Skipped = "skipped by config"
+		case e.WebSocket:
+			result.Skipped = "WebSocket endpoint"
+		default:
+			check(ctx, client, e, cfg.Request(e, vars), result)
+		}
+
+		report.Results = append(report.Results, result)
+		if progress != nil {
+			progress(result)
+		}
+	}
+
+	report.Duration = time.Since(report.Started)
+	return report
+}
+
+// check sends the request and validates the response against the endpoint
+func check(ctx context.Context, client *api.Client, e *api.Endpoint, req *api.Request, result *Result) {
+	resp := client.Send(ctx, req)
+	result.Duration = resp.Duration
+	if resp.Error != nil {
+		result.Error = resp.Error.Error()
+		return
+	}
+
+	result.Status = resp.StatusCode
+	_, result.Response = e.DocumentedResponse(resp.StatusCode)
+	if err := e.ValidateResponse(resp); err != nil {
+		result.Error = err.Error()
+		return
+	}
+	if resp.StatusCode >= 500 {
+		result.Error = fmt.Sprintf("server error %s", resp.Status)
+	}
+}
+
+// documented returns the endpoint's documented response keys in matrix order
+func documented(e *api.Endpoint) []string {
+	if e.Responses == nil {
+		return nil
+	}
+	var keys []string
+	for key := range e.Responses.Map() {
+		keys = append(keys, key)
+	}
+	sortResponseKeys(keys)
+	return keys
+}
+
+// sortResponseKeys orders status codes numerically, then ranges, then default
+func sortResponseKeys(keys []string) {
+	rank := func(key string) string {
+		if key == "default" {
+			return "9999"
+		}
+		if _, err := strconv.Atoi(key); err != nil {
+			return key[:1] + "99x" // A range sorts after its codes
+		}
+		return key
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return rank(keys[i]) < rank(keys[j])
+	})
+}
+
+func methodOrder(method string) int {
+	for i, m := range []string{"get", "head", "post", "put", "patch", "delete", "options", "trace"} {
+		if strings.EqualFold(method, m) {
+			return i
+		}
+	}
+	return 99
+}
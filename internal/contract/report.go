@@ -0,0 +1,119 @@
+package contract
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+// Matrix cells
+const (
+	cellPassed     = "✓"
+	cellFailed     = "✗"
+	cellDocumented = "·" // Documented but not returned
+)
+
+// WriteMatrix writes a table with a row per operation and a column per
+// documented or returned status, followed by the failures
+func WriteMatrix(w io.Writer, report *Report) error {
+	var columns []string
+	seen := make(map[string]bool)
+	addColumn := func(key string) {
+		if key != "" && !seen[key] {
+			seen[key] = true
+			columns = append(columns, key)
+		}
+	}
+	for _, result := range report.Results {
+		for _, key := range result.Documented {
+			addColumn(key)
+		}
+		addColumn(resultColumn(result))
+	}
+	sortResponseKeys(columns)
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "OPERATION\t%s\tRESULT\n", strings.Join(columns, "\t"))
+	for _, result := range report.Results {
+		cells := make([]string, len(columns))
+		for i, key := range columns {
+			cells[i] = cell(result, key)
+		}
+
+		outcome := "pass"
+		switch {
+		case result.Skipped != "":
+			outcome = "skip"
+		case !result.Passed():
+			outcome = "FAIL"
+		}
+		fmt.Fprintf(tw, "%s %s\t%s\t%s\n", result.Method, result.Path, strings.Join(cells, "\t"), outcome)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	var failures []string
+	for _, result := range report.Results {
+		switch {
+		case result.Skipped != "":
+			failures = append(failures, fmt.Sprintf("- %s %s: %s", result.Method, result.Path, result.Skipped))
+		case !result.Passed():
+			failures = append(failures, fmt.Sprintf("%s %s %s: %s", cellFailed, result.Method, result.Path, result.Error))
+		}
+	}
+	if len(failures) > 0 {
+		fmt.Fprintf(w, "\n%s\n", strings.Join(failures, "\n"))
+	}
+
+	passed, failed, skipped := report.Counts()
+	_, err := fmt.Fprintf(w, "\n%d passed, %d failed, %d skipped\n", passed, failed, skipped)
+	return err
+}
+
+// resultColumn returns the column of the status a result returned: the
+// documented response it matched, or the code itself when undocumented
+func resultColumn(result *Result) string {
+	if result.Response != "" {
+		return result.Response
+	}
+	if result.Status != 0 {
+		return strconv.Itoa(result.Status)
+	}
+	return ""
+}
+
+func cell(result *Result, key string) string {
+	if result.Status != 0 && key == resultColumn(result) {
+		if result.Passed() {
+			return cellPassed
+		}
+		return cellFailed
+	}
+	for _, documented := range result.Documented {
+		if documented == key {
+			return cellDocumented
+		}
+	}
+	return ""
+}
+
+// WriteJSON writes the report as indented JSON
+func WriteJSON(w io.Writer, report *Report) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	passed, failed, skipped := report.Counts()
+	summary := struct {
+		*Report
+		Passed  int `json:"passed"`
+		Failed  int `json:"failed"`
+		Skipped int `json:"skipped"`
+	}{report, passed, failed, skipped}
+	if err := enc.Encode(summary); err != nil {
+		return fmt.Errorf("failed to encode JSON report: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,710 @@
+// Package diff compares two versions of a spec and classifies each change as
+// breaking for existing clients or not.
+package diff
+
+import (
+	"fmt"
+	"path"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// maxDepth stops recursive schemas from being compared forever
+const maxDepth = 8
+
+// Kind is what happened to the thing that changed
+type Kind string
+
+const (
+	Added   Kind = "added"
+	Removed Kind = "removed"
+	Changed Kind = "changed"
+)
+
+// Change is a difference between the old and the new spec
+type Change struct {
+	Operation string `json:"operation"` // "METHOD /path", as in the new spec unless removed
+	Kind      Kind   `json:"kind"`
+	Message   string `json:"message"`
+	Breaking  bool   `json:"breaking"` // Existing clients may fail against the new spec
+}
+
+// Report lists the changes from one spec to another, by operation
+type Report struct {
+	Old     string   `json:"old"`
+	New     string   `json:"new"`
+	Changes []Change `json:"changes"`
+}
+
+// Breaking returns the number of breaking changes
+func (r *Report) Breaking() int {
+	n := 0
+	for _, c := range r.Changes {
+		if c.Breaking {
+			n++
+		}
+	}
+	return n
+}
+
+// methodOrder is the order operations of a path are compared in
+var methodOrder = []string{"GET", "PUT", "POST", "DELETE", "OPTIONS", "HEAD", "PATCH", "TRACE"}
+
+var templateParam = regexp.MustCompile(`\{([^{}]+)\}`)
+
+type operation struct {
+	path string
+	op   *openapi3.Operation
+	item *openapi3.PathItem
+	doc  *openapi3.T
+}
+
+// operations returns the operations of doc by method and path, with path
+// parameter names left out so that renaming one does not remove the operation
+func operations(doc *openapi3.T) map[string]operation {
+	ops := make(map[string]operation)
+	if doc == nil || doc.Paths == nil {
+		return ops
+	}
+	for path, item := range doc.Paths.Map() {
+		if item == nil {
+			continue
+		}
+		for _, method := range methodOrder {
+			if op := item.GetOperation(method); op != nil {
+				key := method + " " + templateParam.ReplaceAllString(path, "{}")
+				ops[key] = operation{path: path, op: op, item: item, doc: doc}
+			}
+		}
+	}
+	return ops
+}
+
+// sortedKeys orders operation keys by path, then method
+func sortedKeys(ops ...map[string]operation) []string {
+	seen := make(map[string]bool)
+	var keys []string
+	for _, m := range ops {
+		for key := range m {
+			if !seen[key] {
+				seen[key] = true
+				keys = append(keys, key)
+			}
+		}
+	}
+	methodIndex := func(key string) int {
+		method, _, _ := strings.Cut(key, " ")
+		for i, m := range methodOrder {
+			if m == method {
+				return i
+			}
+		}
+		return len(methodOrder)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		_, pi, _ := strings.Cut(keys[i], " ")
+		_, pj, _ := strings.Cut(keys[j], " ")
+		if pi != pj {
+			return pi < pj
+		}
+		return methodIndex(keys[i]) < methodIndex(keys[j])
+	})
+	return keys
+}
+
+// Compare lists the changes from one spec to another
+func Compare(from, to *openapi3.T) *Report {
+	report := &Report{Changes: []Change{}}
+	oldOps, newOps := operations(from), operations(to)
+
+	for _, key := range sortedKeys(oldOps, newOps) {
+		o, inOld := oldOps[key]
+		n, inNew := newOps[key]
+		method, _, _ := strings.Cut(key, " ")
+
+		c := &comparer{report: report}
+		switch {
+		case !inNew:
+			c.operation = method + " " + o.path
+			c.add(Removed, true, "operation removed")
+		case !inOld:
+			c.operation = method + " " + n.path
+			c.add(Added, false, "operation added")
+		default:
+			c.operation = method + " " + n.path
+			c.compareOperation(o, n)
+		}
+	}
+	return report
+}
+
+// direction is which way a schema is sent, which decides whether narrowing or
+// widening it breaks clients
+type direction int
+
+const (
+	request direction = iota
+	response
+)
+
+type comparer struct {
+	report    *Report
+	operation string
+	seen      map[[2]*openapi3.Schema]bool
+}
+
+func (c *comparer) add(kind Kind, breaking bool, format string, args ...any) {
+	c.report.Changes = append(c.report.Changes, Change{
+		Operation: c.operation,
+		Kind:      kind,
+		Message:   fmt.Sprintf(format, args...),
+		Breaking:  breaking,
+	})
+}
+
+func (c *comparer) compareOperation(o, n operation) {
+	if !o.op.Deprecated && n.op.Deprecated {
+		c.add(Changed, false, "operation deprecated")
+	}
+	c.compareSecurity(o, n)
+	c.compareParameters(o, n)
+	c.compareRequestBody(o.op.RequestBody, n.op.RequestBody)
+	c.compareResponses(o.op.Responses, n.op.Responses)
+}
+
+// security returns the names of the schemes an operation accepts, one entry
+// per alternative; nil when it can be called without credentials
+func security(o operation) []string {
+	reqs := o.doc.Security
+	if o.op.Security != nil {
+		reqs = *o.op.Security
+	}
+	var alternatives []string
+	for _, req := range reqs {
+		if len(req) == 0 {
+			return nil // Anonymous access is one of the alternatives
+		}
+		names := make([]string, 0, len(req))
+		for name := range req {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		alternatives = append(alternatives, strings.Join(names, "+"))
+	}
+	sort.Strings(alternatives)
+	return alternatives
+}
+
+func (c *comparer) compareSecurity(o, n operation) {
+	before, after := security(o), security(n)
+	switch {
+	case len(before) == 0 && len(after) > 0:
+		c.add(Changed, true, "now requires authentication (%s)", strings.Join(after, " or "))
+	case len(before) > 0 && len(after) == 0:
+		c.add(Changed, false, "no longer requires authentication")
+	case !reflect.DeepEqual(before, after):
+		c.add(Changed, true, "authentication changed from %s to %s", strings.Join(before, " or "), strings.Join(after, " or "))
+	}
+}
+
+// parameters returns the parameters of an operation, including the ones of
+// its path it does not override. Path parameters are keyed by their position
+// in the template, as that is what clients fill in.
+func parameters(o operation) map[string]*openapi3.Parameter {
+	params := make(map[string]*openapi3.Parameter)
+	position := make(map[string]int)
+	for i, m := range templateParam.FindAllStringSubmatch(o.path, -1) {
+		position[m[1]] = i
+	}
+	key := func(p *openapi3.Parameter) string {
+		if i, ok := position[p.Name]; ok && p.In == openapi3.ParameterInPath {
+			return fmt.Sprintf("path #%d", i)
+		}
+		return p.In + " " + p.Name
+	}
+
+	for _, refs := range []openapi3.Parameters{o.item.Parameters, o.op.Parameters} {
+		for _, ref := range refs {
+			if ref != nil && ref.Value != nil {
+				params[key(ref.Value)] = ref.Value // Operation parameters override the path's
+			}
+		}
+	}
+	return params
+}
+
+func paramLabel(p *openapi3.Parameter) string {
+	return fmt.Sprintf("%s parameter %q", p.In, p.Name)
+}
+
+func (c *comparer) compareParameters(o, n operation) {
+	before, after := parameters(o), parameters(n)
+	keys := make([]string, 0, len(before)+len(after))
+	for key := range before {
+		keys = append(keys, key)
+	}
+	for key := range after {
+		if _, ok := before[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		p, inOld := before[key]
+		q, inNew := after[key]
+		switch {
+		case !inNew:
+			c.add(Removed, false, "%s removed", paramLabel(p))
+		case !inOld:
+			if q.Required {
+				c.add(Added, true, "required %s added", paramLabel(q))
+			} else {
+				c.add(Added, false, "optional %s added", paramLabel(q))
+			}
+		default:
+			if p.Name != q.Name {
+				c.add(Changed, false, "%s renamed to %q", paramLabel(p), q.Name)
+			}
+			switch {
+			case !p.Required && q.Required:
+				c.add(Changed, true, "%s became required", paramLabel(q))
+			case p.Required && !q.Required:
+				c.add(Changed, false, "%s became optional", paramLabel(q))
+			}
+			c.compareSchema(paramLabel(q), "", p.Schema, q.Schema, request, 0)
+		}
+	}
+}
+
+func (c *comparer) compareRequestBody(o, n *openapi3.RequestBodyRef) {
+	var before, after *openapi3.RequestBody
+	if o != nil {
+		before = o.Value
+	}
+	if n != nil {
+		after = n.Value
+	}
+
+	switch {
+	case before == nil && after == nil:
+		return
+	case before == nil:
+		if after.Required {
+			c.add(Added, true, "required request body added")
+		} else {
+			c.add(Added, false, "optional request body added")
+		}
+		return
+	case after == nil:
+		c.add(Removed, false, "request body removed")
+		return
+	}
+
+	switch {
+	case !before.Required && after.Required:
+		c.add(Changed, true, "request body became required")
+	case before.Required && !after.Required:
+		c.add(Changed, false, "request body became optional")
+	}
+	c.compareContent("request body", before.Content, after.Content, request)
+}
+
+func (c *comparer) compareResponses(o, n *openapi3.Responses) {
+	before, after := map[string]*openapi3.ResponseRef{}, map[string]*openapi3.ResponseRef{}
+	if o != nil {
+		before = o.Map()
+	}
+	if n != nil {
+		after = n.Map()
+	}
+	codes := make([]string, 0, len(before)+len(after))
+	for code := range before {
+		codes = append(codes, code)
+	}
+	for code := range after {
+		if _, ok := before[code]; !ok {
+			codes = append(codes, code)
+		}
+	}
+	sort.Strings(codes)
+
+	for _, code := range codes {
+		p, inOld := before[code]
+		q, inNew := after[code]
+		switch {
+		case !inNew:
+			// Clients rely on success responses, error ones they handle generically
+			c.add(Removed, strings.HasPrefix(code, "2"), "response %s removed", code)
+		case !inOld:
+			c.add(Added, false, "response %s added", code)
+		case p != nil && q != nil && p.Value != nil && q.Value != nil:
+			c.compareContent("response "+code, p.Value.Content, q.Value.Content, response)
+		}
+	}
+}
+
+func (c *comparer) compareContent(label string, before, after openapi3.Content, dir direction) {
+	types := make([]string, 0, len(before)+len(after))
+	for mt := range before {
+		types = append(types, mt)
+	}
+	for mt := range after {
+		if _, ok := before[mt]; !ok {
+			types = append(types, mt)
+		}
+	}
+	sort.Strings(types)
+
+	for _, mt := range types {
+		p, inOld := before[mt]
+		q, inNew := after[mt]
+		switch {
+		case !inNew:
+			if dir == request {
+				c.add(Removed, true, "%s no longer accepts %s", label, mt)
+			} else {
+				c.add(Removed, true, "%s no longer returns %s", label, mt)
+			}
+		case !inOld:
+			if dir == request {
+				c.add(Added, false, "%s now also accepts %s", label, mt)
+			} else {
+				c.add(Added, false, "%s can now also be %s", label, mt)
+			}
+		case p != nil && q != nil:
+			where := label
+			if len(after) > 1 {
+				where = fmt.Sprintf("%s (%s)", label, mt)
+			}
+			c.compareSchema(where, "", p.Schema, q.Schema, dir, 0)
+		}
+	}
+}
+
+// shape is a schema with allOf merged into it
+type shape struct {
+	*openapi3.Schema
+	properties map[string]*openapi3.SchemaRef
+	required   map[string]bool
+}
+
+func flatten(s *openapi3.Schema, depth int) shape {
+	sh := shape{Schema: s, properties: make(map[string]*openapi3.SchemaRef), required: make(map[string]bool)}
+	if depth > maxDepth {
+		return sh
+	}
+	for _, ref := range s.AllOf {
+		if ref == nil || ref.Value == nil {
+			continue
+		}
+		part := flatten(ref.Value, depth+1)
+		for name, prop := range part.properties {
+			sh.properties[name] = prop
+		}
+		for name := range part.required {
+			sh.required[name] = true
+		}
+	}
+	for name, prop := range s.Properties {
+		sh.properties[name] = prop
+	}
+	for _, name := range s.Required {
+		sh.required[name] = true
+	}
+	return sh
+}
+
+// typeName returns the schema's type, including the types of its allOf parts
+func (sh shape) typeName() string {
+	if types := sh.Type.Slice(); len(types) > 0 {
+		sorted := append([]string(nil), types...)
+		sort.Strings(sorted)
+		return strings.Join(sorted, "|")
+	}
+	for _, ref := range sh.AllOf {
+		if ref != nil && ref.Value != nil {
+			if name := (shape{Schema: ref.Value}).typeName(); name != "" {
+				return name
+			}
+		}
+	}
+	if len(sh.properties) > 0 {
+		return openapi3.TypeObject
+	}
+	return ""
+}
+
+func fieldLabel(label, field string) string {
+	if field == "" {
+		return label
+	}
+	return fmt.Sprintf("%s property %q", label, field)
+}
+
+func joinField(field, name string) string {
+	if field == "" {
+		return name
+	}
+	return field + "." + name
+}
+
+// compareSchema reports the changes between two versions of a schema sent in
+// the given direction
+func (c *comparer) compareSchema(label, field string, o, n *openapi3.SchemaRef, dir direction, depth int) {
+	if o == nil || n == nil || o.Value == nil || n.Value == nil || depth > maxDepth {
+		return
+	}
+	pair := [2]*openapi3.Schema{o.Value, n.Value}
+	if c.seen == nil {
+		c.seen = make(map[[2]*openapi3.Schema]bool)
+	}
+	if c.seen[pair] {
+		return
+	}
+	c.seen[pair] = true
+	defer delete(c.seen, pair)
+
+	before, after := flatten(o.Value, 0), flatten(n.Value, 0)
+	where := fieldLabel(label, field)
+
+	bt, at := before.typeName(), after.typeName()
+	switch {
+	case bt == "" || at == "" || bt == at:
+	case widened(bt, at, dir):
+		// Integers are numbers, so the values that used to be valid still are
+		c.add(Changed, false, "type of %s changed from %s to %s", where, bt, at)
+	default:
+		c.add(Changed, true, "type of %s changed from %s to %s", where, bt, at)
+		return
+	}
+	if bt == at && before.Format != "" && after.Format != "" && before.Format != after.Format {
+		c.add(Changed, true, "format of %s changed from %s to %s", where, before.Format, after.Format)
+	}
+
+	switch {
+	case before.Nullable && !after.Nullable && dir == request:
+		c.add(Changed, true, "%s no longer accepts null", where)
+	case !before.Nullable && after.Nullable && dir == response:
+		c.add(Changed, true, "%s may now be null", where)
+	}
+
+	c.compareEnum(where, before.Enum, after.Enum, dir)
+	if dir == request {
+		c.compareLimits(where, before.Schema, after.Schema)
+	}
+
+	names := make([]string, 0, len(before.properties)+len(after.properties))
+	for name := range before.properties {
+		names = append(names, name)
+	}
+	for name := range after.properties {
+		if _, ok := before.properties[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		p, inOld := before.properties[name]
+		q, inNew := after.properties[name]
+		prop := fieldLabel(label, joinField(field, name))
+		switch {
+		case !inNew:
+			// Servers ignore what they no longer read, clients miss what they used to get
+			c.add(Removed, dir == response, "%s removed", prop)
+		case !inOld:
+			if dir == request && after.required[name] {
+				c.add(Added, true, "required %s added", prop)
+			} else {
+				c.add(Added, false, "%s added", prop)
+			}
+		default:
+			wasRequired, isRequired := before.required[name], after.required[name]
+			switch {
+			case !wasRequired && isRequired:
+				c.add(Changed, dir == request, "%s became required", prop)
+			case wasRequired && !isRequired:
+				if dir == response {
+					c.add(Changed, true, "%s may now be missing", prop)
+				} else {
+					c.add(Changed, false, "%s became optional", prop)
+				}
+			}
+			c.compareSchema(label, joinField(field, name), p, q, dir, depth+1)
+		}
+	}
+
+	if before.Items != nil && after.Items != nil {
+		c.compareSchema(label, field+"[]", before.Items, after.Items, dir, depth+1)
+	}
+
+	c.compareBranches(where, "oneOf", before.OneOf, after.OneOf, dir, depth)
+	c.compareBranches(where, "anyOf", before.AnyOf, after.AnyOf, dir, depth)
+}
+
+// widened reports whether a type change only lets through more values where
+// that is safe: requests may accept numbers for integers, responses may
+// return only integers for numbers
+func widened(from, to string, dir direction) bool {
+	if dir == request {
+		return from == openapi3.TypeInteger && to == openapi3.TypeNumber
+	}
+	return from == openapi3.TypeNumber && to == openapi3.TypeInteger
+}
+
+// compareBranches compares the oneOf or anyOf branches of a schema, matching
+// them by the name of the schema they refer to, inline ones by type, or else
+// by position. Fewer
+// branches break requests, more branches break clients reading responses.
+func (c *comparer) compareBranches(where, keyword string, before, after openapi3.SchemaRefs, dir direction, depth int) {
+	matched := make([]bool, len(after))
+	match := func(ref *openapi3.SchemaRef, i int) int {
+		for j, other := range after {
+			if matched[j] || other == nil {
+				continue
+			}
+			if ref.Ref != "" && other.Ref == ref.Ref {
+				return j
+			}
+			if ref.Ref == "" && other.Ref == "" && ref.Value != nil && other.Value != nil {
+				if name := (shape{Schema: ref.Value}).typeName(); name != "" && name == (shape{Schema: other.Value}).typeName() {
+					return j
+				}
+			}
+		}
+		// Branches that refer to different schemas are different branches
+		if i < len(after) && !matched[i] && after[i] != nil && (ref.Ref == "" || after[i].Ref == "") {
+			return i
+		}
+		return -1
+	}
+
+	for i, ref := range before {
+		if ref == nil {
+			continue
+		}
+		branch := fmt.Sprintf("%s %s %s", where, keyword, branchName(ref, i))
+		j := match(ref, i)
+		if j < 0 {
+			c.add(Removed, dir == request, "%s removed", branch)
+			continue
+		}
+		matched[j] = true
+		c.compareSchema(branch, "", ref, after[j], dir, depth+1)
+	}
+	for j, ref := range after {
+		if ref != nil && !matched[j] {
+			c.add(Added, dir == response, "%s %s %s added", where, keyword, branchName(ref, j))
+		}
+	}
+}
+
+// branchName names a oneOf or anyOf branch after the schema it refers to, or
+// its position
+func branchName(ref *openapi3.SchemaRef, i int) string {
+	if ref.Ref != "" {
+		return path.Base(ref.Ref)
+	}
+	return fmt.Sprintf("#%d", i+1)
+}
+
+// compareEnum reports allowed values that were added or removed. Fewer values
+// break requests, more values break clients reading responses.
+func (c *comparer) compareEnum(where string, before, after []any, dir direction) {
+	switch {
+	case len(before) == 0 && len(after) == 0:
+		return
+	case len(before) == 0:
+		c.add(Changed, dir == request, "%s is now limited to %s", where, formatValues(after))
+		return
+	case len(after) == 0:
+		c.add(Changed, dir == response, "%s is no longer limited to a set of values", where)
+		return
+	}
+
+	if removed := missingValues(before, after); len(removed) > 0 {
+		c.add(Changed, dir == request, "%s no longer allows %s", where, formatValues(removed))
+	}
+	if added := missingValues(after, before); len(added) > 0 {
+		c.add(Changed, dir == response, "%s now also allows %s", where, formatValues(added))
+	}
+}
+
+// missingValues returns the values of a that are not in b
+func missingValues(a, b []any) []any {
+	var missing []any
+	for _, v := range a {
+		found := false
+		for _, w := range b {
+			if reflect.DeepEqual(v, w) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			missing = append(missing, v)
+		}
+	}
+	return missing
+}
+
+func formatValues(values []any) string {
+	parts := make([]string, len(values))
+	for i, v := range values {
+		parts[i] = fmt.Sprintf("%v", v)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// compareLimits reports length and range limits of a request value that were
+// tightened, which rejects values that used to be accepted, or loosened
+func (c *comparer) compareLimits(where string, before, after *openapi3.Schema) {
+	tightened := func(what string, from, to string) {
+		c.add(Changed, true, "%s of %s tightened from %s to %s", what, where, from, to)
+	}
+	loosened := func(what string, from, to string) {
+		c.add(Changed, false, "%s of %s loosened from %s to %s", what, where, from, to)
+	}
+	limit := func(v *float64) string {
+		if v == nil {
+			return "none"
+		}
+		return fmt.Sprintf("%g", *v)
+	}
+	length := func(v *uint64) string {
+		if v == nil {
+			return "none"
+		}
+		return fmt.Sprint(*v)
+	}
+
+	// A nil upper bound is unlimited
+	switch a, b := before.Max, after.Max; {
+	case a == nil && b != nil, a != nil && b != nil && *b < *a:
+		tightened("maximum", limit(a), limit(b))
+	case a != nil && b == nil, a != nil && b != nil && *b > *a:
+		loosened("maximum", limit(a), limit(b))
+	}
+	switch a, b := before.Min, after.Min; {
+	case a == nil && b != nil, a != nil && b != nil && *b > *a:
+		tightened("minimum", limit(a), limit(b))
+	case a != nil && b == nil, a != nil && b != nil && *b < *a:
+		loosened("minimum", limit(a), limit(b))
+	}
+	switch a, b := before.MaxLength, after.MaxLength; {
+	case a == nil && b != nil, a != nil && b != nil && *b < *a:
+		tightened("maximum length", length(a), length(b))
+	case a != nil && b == nil, a != nil && b != nil && *b > *a:
+		loosened("maximum length", length(a), length(b))
+	}
+	if a, b := before.MinLength, after.MinLength; b > a {
+		tightened("minimum length", fmt.Sprint(a), fmt.Sprint(b))
+	} else if b < a {
+		loosened("minimum length", fmt.Sprint(a), fmt.Sprint(b))
+	}
+}
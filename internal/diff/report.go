@@ -0,0 +1,79 @@
+package diff
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+)
+
+// WriteText writes a line per change followed by the totals
+func WriteText(w io.Writer, report *Report) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, c := range report.Changes {
+		marker := ""
+		if c.Breaking {
+			marker = "BREAKING"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", marker, c.Operation, c.Message)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	if len(report.Changes) > 0 {
+		fmt.Fprintln(w)
+	}
+	_, err := fmt.Fprintf(w, "%d change(s), %d breaking\n", len(report.Changes), report.Breaking())
+	return err
+}
+
+// WriteJSON writes the report as indented JSON
+func WriteJSON(w io.Writer, report *Report) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	summary := struct {
+		*Report
+		Breaking int `json:"breaking"`
+	}{report, report.Breaking()}
+	if err := enc.Encode(summary); err != nil {
+		return fmt.Errorf("failed to encode JSON report: %w", err)
+	}
+	return nil
+}
+
+// WriteMarkdown writes the changes as release notes, breaking ones first,
+// grouped by operation
+func WriteMarkdown(w io.Writer, report *Report) error {
+	var b strings.Builder
+	b.WriteString("## API changes\n\n")
+	fmt.Fprintf(&b, "From `%s` to `%s`: %d change(s), %d breaking.\n", report.Old, report.New, len(report.Changes), report.Breaking())
+
+	section := func(title string, breaking bool) {
+		var changes []Change
+		for _, c := range report.Changes {
+			if c.Breaking == breaking {
+				changes = append(changes, c)
+			}
+		}
+		if len(changes) == 0 {
+			return
+		}
+
+		fmt.Fprintf(&b, "\n### %s\n\n", title)
+		operation := ""
+		for _, c := range changes {
+			if c.Operation != operation {
+				operation = c.Operation
+				fmt.Fprintf(&b, "- `%s`\n", operation)
+			}
+			fmt.Fprintf(&b, "  - %s\n", c.Message)
+		}
+	}
+	section("Breaking changes", true)
+	section("Other changes", false)
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
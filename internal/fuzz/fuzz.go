@@ -0,0 +1,319 @@
+// Package fuzz sends invalid and edge-case variations of generated requests
+// and reports the responses that point to bugs: server errors, timeouts and
+// responses that contradict the spec.
+package fuzz
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/doganarif/ApiMug/internal/api"
+)
+
+// Kind classifies a finding
+type Kind string
+
+const (
+	KindServerError     Kind = "server error"
+	KindTimeout         Kind = "timeout"
+	KindSchemaViolation Kind = "schema violation"
+)
+
+// Options controls a fuzz run
+type Options struct {
+	Concurrency int           // Requests in flight at once, at least 1
+	Timeout     time.Duration // Per request, zero uses the client's
+	MaxCases    int           // Per operation, zero sends all
+	Minimize    bool          // Drop optional parts of failing requests that do not matter
+}
+
+// Finding is a response pointing to a bug, with a request reproducing it
+type Finding struct {
+	Kind      Kind     `json:"kind"`
+	Operation string   `json:"operation"`
+	Target    string   `json:"target"`
+	Mutation  string   `json:"mutation"`
+	Others    []string `json:"other_mutations,omitempty"` // Mutations of the same target with the same outcome
+	Status    int      `json:"status,omitempty"`
+	Message   string   `json:"message"`
+	Request   Repro    `json:"request"`
+}
+
+// Repro is a request as sent, without authentication
+type Repro struct {
+	Method  string            `json:"method"`
+	URL     string            `json:"url"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Body    string            `json:"body,omitempty"`
+}
+
+// Report is the outcome of a fuzz run
+type Report struct {
+	Spec       string        `json:"spec"`
+	Operations []string      `json:"operations"`
+	Started    time.Time     `json:"started"`
+	Duration   time.Duration `json:"duration_ns"`
+	Sent       int           `json:"sent"`
+	Errors     int           `json:"errors"` // Requests that failed without a response, other than timeouts
+	LastError  string        `json:"last_error,omitempty"`
+	Findings   []*Finding    `json:"findings"`
+}
+
+// job is a case of an operation to send
+type job struct {
+	index    int
+	endpoint *api.Endpoint
+	c        *Case
+}
+
+// Run fuzzes the endpoints. base builds the valid request each endpoint's
+// cases are mutated from; it is sent too, as the baseline. progress, when
+// set, is called after each request with the number sent so far and the total.
+func Run(ctx context.Context, client *api.Client, endpoints []*api.Endpoint, base func(*api.Endpoint) *api.Request, opts Options, progress func(done, total int)) *Report {
+	report := &Report{Started: time.Now()}
+
+	var jobs []job
+	for _, e := range endpoints {
+		report.Operations = append(report.Operations, e.Ref())
+		req := base(e)
+		jobs = append(jobs, job{len(jobs), e, &Case{Target: "request", Mutation: "none (generated request)", Request: req}})
+
+		cs := cases(e, req)
+		if opts.MaxCases > 0 && len(cs) > opts.MaxCases {
+			cs = cs[:opts.MaxCases]
+		}
+		for _, c := range cs {
+			jobs = append(jobs, job{len(jobs), e, c})
+		}
+	}
+
+	workers := opts.Concurrency
+	if workers < 1 {
+		workers = 1
+	}
+	queue := make(chan job)
+	var (
+		mu       sync.Mutex
+		wg       sync.WaitGroup
+		findings = make(map[string]*Finding)
+		order    []string
+		pending  = make(map[string]job)
+	)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range queue {
+				resp := send(ctx, client, j.c.Request, opts.Timeout)
+				kind, message := classify(j.endpoint, resp)
+
+				mu.Lock()
+				report.Sent++
+				if resp.Error != nil && kind == "" && ctx.Err() == nil {
+					report.Errors++
+					report.LastError = resp.Error.Error()
+				}
+				if kind != "" {
+					// Mutations of the same target failing the same way are one finding
+					key := fmt.Sprintf("%s|%s|%s|%d", j.endpoint.Ref(), j.c.Target, kind, resp.StatusCode)
+					if f, ok := findings[key]; ok {
+						f.Others = append(f.Others, j.c.Mutation)
+					} else {
+						findings[key] = &Finding{
+							Kind:      kind,
+							Operation: j.endpoint.Ref(),
+							Target:    j.c.Target,
+							Mutation:  j.c.Mutation,
+							Status:    resp.StatusCode,
+							Message:   message,
+							Request:   repro(client, j.c.Request),
+						}
+						order = append(order, key)
+						pending[key] = j
+					}
+				}
+				done := report.Sent
+				mu.Unlock()
+
+				if progress != nil {
+					progress(done, len(jobs))
+				}
+			}
+		}()
+	}
+
+feed:
+	for _, j := range jobs {
+		select {
+		case queue <- j:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(queue)
+	wg.Wait()
+
+	// Report in the order the cases were generated, not as they completed
+	sort.Slice(order, func(i, j int) bool { return pending[order[i]].index < pending[order[j]].index })
+	for _, key := range order {
+		f := findings[key]
+		sort.Strings(f.Others)
+		if opts.Minimize && ctx.Err() == nil {
+			j := pending[key]
+			f.Request = repro(client, minimize(ctx, client, j.endpoint, j.c, opts.Timeout))
+		}
+		report.Findings = append(report.Findings, f)
+	}
+
+	report.Duration = time.Since(report.Started)
+	return report
+}
+
+func send(ctx context.Context, client *api.Client, req *api.Request, timeout time.Duration) *api.Response {
+	if timeout > 0 {
+		req = cloneRequest(req)
+		req.Timeout = timeout
+	}
+	return client.Send(ctx, req)
+}
+
+// classify returns the kind of finding a response is, empty when there is none
+func classify(e *api.Endpoint, resp *api.Response) (Kind, string) {
+	if resp.Error != nil {
+		if errors.Is(resp.Error, api.ErrTimeout) {
+			return KindTimeout, resp.Error.Error()
+		}
+		return "", ""
+	}
+	if resp.StatusCode >= 500 {
+		return KindServerError, resp.Status
+	}
+	// Undocumented statuses are expected for invalid input, only a
+	// documented response can contradict the spec
+	if documented, _ := e.DocumentedResponse(resp.StatusCode); documented != nil {
+		if err := e.ValidateResponse(resp); err != nil {
+			return KindSchemaViolation, err.Error()
+		}
+	}
+	return "", ""
+}
+
+// minimize drops optional query parameters, headers and top-level body
+// properties that are not needed to reproduce the finding. A reduced request
+// must fail the same way, with the same status and schema violations, so that
+// it fails for the same reason rather than for the part that was removed.
+// Bodies are not compared, they may hold request ids or timestamps.
+func minimize(ctx context.Context, client *api.Client, e *api.Endpoint, c *Case, timeout time.Duration) *api.Request {
+	req := c.Request
+	want := outcome(e, send(ctx, client, req, timeout))
+	reproduces := func(candidate *api.Request) bool {
+		return outcome(e, send(ctx, client, candidate, timeout)) == want
+	}
+
+	for _, p := range e.Parameters {
+		if p.Required || p.Name == c.param {
+			continue
+		}
+		candidate := cloneRequest(req)
+		switch p.In {
+		case "query":
+			delete(candidate.QueryParams, p.Name)
+		case "header":
+			delete(candidate.Headers, p.Name)
+		default:
+			continue
+		}
+		if len(candidate.QueryParams) != len(req.QueryParams) || len(candidate.Headers) != len(req.Headers) {
+			if reproduces(candidate) {
+				req = candidate
+			}
+		}
+	}
+
+	_, schema := e.BodySchema()
+	var body map[string]any
+	dec := json.NewDecoder(strings.NewReader(req.Body))
+	dec.UseNumber() // Keeps numbers such as overflowing ones as they were sent
+	if schema == nil || dec.Decode(&body) != nil {
+		return req
+	}
+	required := make(map[string]bool)
+	for _, name := range schema.Required {
+		required[name] = true
+	}
+	names := make([]string, 0, len(body))
+	for name := range body {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if required[name] || (len(c.path) > 0 && c.path[0] == name) {
+			continue
+		}
+		reduced := make(map[string]any, len(body))
+		for k, v := range body {
+			if k != name {
+				reduced[k] = v
+			}
+		}
+		data, _ := json.Marshal(reduced)
+		candidate := cloneRequest(req)
+		candidate.Body = string(data)
+		if reproduces(candidate) {
+			req, body = candidate, reduced
+		}
+	}
+	return req
+}
+
+// outcome summarises a response for comparing failures: its kind, status and,
+// for schema violations, the violations
+func outcome(e *api.Endpoint, resp *api.Response) string {
+	kind, message := classify(e, resp)
+	switch kind {
+	case KindTimeout:
+		return string(kind)
+	case KindSchemaViolation:
+		return fmt.Sprintf("%s|%d|%s", kind, resp.StatusCode, message)
+	}
+	return fmt.Sprintf("%s|%d", kind, resp.StatusCode)
+}
+
+func repro(client *api.Client, req *api.Request) Repro {
+	r := Repro{Method: req.Method, URL: client.URL(req), Body: req.Body}
+	if len(req.Headers) > 0 || req.ContentType != "" && req.Body != "" {
+		r.Headers = cloneMap(req.Headers)
+		if req.ContentType != "" && req.Body != "" {
+			r.Headers["Content-Type"] = req.ContentType
+		}
+	}
+	return r
+}
+
+// String renders the request for reports, with long bodies cut short
+func (r Repro) String() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s %s\n", r.Method, r.URL)
+	names := make([]string, 0, len(r.Headers))
+	for name := range r.Headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(&b, "%s: %s\n", name, r.Headers[name])
+	}
+	if r.Body != "" {
+		body := r.Body
+		if len(body) > 200 {
+			body = fmt.Sprintf("%s... (%d bytes)", body[:200], len(body))
+		}
+		fmt.Fprintf(&b, "\n%s\n", body)
+	}
+	return b.String()
+}
@@ -0,0 +1,378 @@
+package fuzz
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/doganarif/ApiMug/internal/api"
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// maxBodyDepth bounds how deep into a body properties are mutated
+const maxBodyDepth = 3
+
+// Strings aimed at parsers, templates and queries built from input
+var injections = []string{
+	"' OR '1'='1",
+	"\"; DROP TABLE users; --",
+	"<script>alert(1)</script>",
+	"../../../../etc/passwd",
+	"${jndi:ldap://127.0.0.1/a}",
+	"{{7*7}}",
+	"$(id)",
+	"%00",
+}
+
+// Strings that trip up encoding and normalisation
+var unicodeStrings = []string{
+	"Ωμέγα 漢字 🚀",
+	"\u0000",
+	"\u202eevil", // Right-to-left override
+	"e\u0301",    // Combining accent
+	"\ufeff",     // Byte order mark
+}
+
+// mutation replaces a valid value, or removes it when missing is set
+type mutation struct {
+	name    string
+	value   any
+	missing bool
+}
+
+// mutations returns invalid and edge-case values for a schema
+func mutations(schema *openapi3.Schema) []mutation {
+	if schema == nil {
+		return []mutation{{name: "null", value: nil}, {name: "empty string", value: ""}}
+	}
+
+	var ms []mutation
+	add := func(name string, value any) {
+		ms = append(ms, mutation{name: name, value: value})
+	}
+
+	switch schemaTypeOf(schema) {
+	case openapi3.TypeInteger, openapi3.TypeNumber:
+		add("zero", 0)
+		add("negative", -1)
+		if schema.Min != nil {
+			add("below minimum", *schema.Min-1)
+		}
+		if schema.Max != nil {
+			add("above maximum", *schema.Max+1)
+		}
+		add("int64 overflow", json.Number("9223372036854775808"))
+		add("float overflow", json.Number("1e309"))
+		if schemaTypeOf(schema) == openapi3.TypeInteger {
+			add("fraction", 1.5)
+		}
+		add("wrong type string", "abc")
+		add("wrong type boolean", true)
+
+	case openapi3.TypeString:
+		add("empty string", "")
+		if schema.MinLength > 0 {
+			add("shorter than minLength", strings.Repeat("a", int(schema.MinLength)-1))
+		}
+		if schema.MaxLength != nil {
+			add("longer than maxLength", strings.Repeat("a", int(*schema.MaxLength)+1))
+		}
+		add("overlong string", strings.Repeat("A", 10000))
+		if schema.Format != "" {
+			add("invalid "+schema.Format, "not-a-"+schema.Format)
+		}
+		if len(schema.Enum) > 0 {
+			add("not in enum", "not-in-enum")
+		}
+		for _, s := range unicodeStrings {
+			add("unicode "+strconv.QuoteToASCII(s), s)
+		}
+		for _, s := range injections {
+			add("injection "+s, s)
+		}
+		add("wrong type number", 12345)
+		add("wrong type boolean", true)
+
+	case openapi3.TypeBoolean:
+		add("wrong type string", "yes")
+		add("wrong type number", 2)
+
+	case openapi3.TypeArray:
+		add("wrong type string", "not-an-array")
+		if schema.MinItems > 0 {
+			add("fewer than minItems", []any{})
+		}
+		if schema.MaxItems != nil && schema.Items != nil {
+			items := make([]any, int(*schema.MaxItems)+1)
+			for i := range items {
+				items[i] = api.Sample(schema.Items.Value)
+			}
+			add("more than maxItems", items)
+		}
+
+	case openapi3.TypeObject:
+		add("wrong type string", "not-an-object")
+		add("wrong type array", []any{})
+	}
+
+	add("null", nil)
+	return ms
+}
+
+func schemaTypeOf(schema *openapi3.Schema) string {
+	if types := schema.Type.Slice(); len(types) > 0 {
+		return types[0]
+	}
+	if len(schema.Properties) > 0 {
+		return openapi3.TypeObject
+	}
+	return ""
+}
+
+// Case is a request with one part mutated
+type Case struct {
+	Target   string // The mutated part, e.g. "query limit" or "body /items/0"
+	Mutation string
+	Request  *api.Request
+
+	param string   // Mutated parameter
+	path  []string // Mutated body location
+}
+
+// cases returns a case per mutation of each parameter and body property of
+// the base request
+func cases(e *api.Endpoint, base *api.Request) []*Case {
+	var out []*Case
+
+	for _, p := range e.Parameters {
+		if p.In != "query" && p.In != "header" && p.In != "path" {
+			continue
+		}
+		var schema *openapi3.Schema
+		if p.SchemaRef != nil {
+			schema = p.SchemaRef.Value
+		}
+		target := p.In + " " + p.Name
+
+		if p.Required && p.In != "path" {
+			req := cloneRequest(base)
+			delete(req.QueryParams, p.Name)
+			delete(req.Headers, p.Name)
+			out = append(out, &Case{Target: target, Mutation: "missing required parameter", Request: req, param: p.Name})
+		}
+
+		seen := make(map[string]bool)
+		for _, m := range mutations(schema) {
+			value := api.FormatParam(m.value)
+			if m.value == nil || seen[value] {
+				continue
+			}
+			if p.In == "header" && !validHeaderValue(value) {
+				continue
+			}
+			seen[value] = true
+
+			req := cloneRequest(base)
+			setParam(req, p, value)
+			out = append(out, &Case{Target: target, Mutation: describe(m), Request: req, param: p.Name})
+		}
+	}
+
+	mediaType, schema := e.BodySchema()
+	if !strings.Contains(mediaType, "json") || schema == nil {
+		return out
+	}
+
+	var body any
+	if err := json.Unmarshal([]byte(base.Body), &body); err != nil {
+		return out
+	}
+	for _, raw := range []struct{ name, body string }{
+		{"empty body", ""},
+		{"malformed JSON", `{"`},
+	} {
+		req := cloneRequest(base)
+		req.Body = raw.body
+		out = append(out, &Case{Target: "body", Mutation: raw.name, Request: req})
+	}
+
+	var walk func(schema *openapi3.Schema, path []string, depth int)
+	walk = func(schema *openapi3.Schema, path []string, depth int) {
+		target := "body"
+		if len(path) > 0 {
+			target = "body /" + strings.Join(path, "/")
+		}
+		for _, m := range mutations(schema) {
+			if mutated, ok := setAt(cloneJSON(body), path, m); ok {
+				out = append(out, bodyCase(base, target, describe(m), mutated, path))
+			}
+		}
+		if schema == nil || depth >= maxBodyDepth {
+			return
+		}
+
+		names := make([]string, 0, len(schema.Properties))
+		for name := range schema.Properties {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			prop := schema.Properties[name]
+			sub := append(append([]string(nil), path...), name)
+			if _, ok := lookup(body, sub); !ok || prop == nil || prop.Value == nil || prop.Value.ReadOnly {
+				continue
+			}
+			walk(prop.Value, sub, depth+1)
+		}
+		for _, name := range schema.Required {
+			sub := append(append([]string(nil), path...), name)
+			if mutated, ok := setAt(cloneJSON(body), sub, mutation{missing: true}); ok {
+				out = append(out, bodyCase(base, "body /"+strings.Join(sub, "/"), "missing required property", mutated, sub))
+			}
+		}
+		if schema.Items != nil {
+			sub := append(append([]string(nil), path...), "0")
+			if _, ok := lookup(body, sub); ok {
+				walk(schema.Items.Value, sub, depth+1)
+			}
+		}
+	}
+	walk(schema, nil, 0)
+
+	return out
+}
+
+func bodyCase(base *api.Request, target, name string, body any, path []string) *Case {
+	req := cloneRequest(base)
+	data, _ := json.Marshal(body)
+	req.Body = string(data)
+	return &Case{Target: target, Mutation: name, Request: req, path: path}
+}
+
+// describe names a mutation with a short form of its value
+func describe(m mutation) string {
+	if m.missing {
+		return "missing"
+	}
+	switch v := m.value.(type) {
+	case string:
+		if v == "" {
+			return m.name
+		}
+		if utf8.RuneCountInString(v) > 20 {
+			return fmt.Sprintf("%s (%d chars)", m.name, utf8.RuneCountInString(v))
+		}
+	case nil, []any:
+		return m.name
+	}
+	if strings.HasPrefix(m.name, "unicode ") || strings.HasPrefix(m.name, "injection ") {
+		return m.name
+	}
+	return fmt.Sprintf("%s (%s)", m.name, api.FormatParam(m.value))
+}
+
+// setParam replaces a parameter value
+func setParam(req *api.Request, p api.Parameter, value string) {
+	switch p.In {
+	case "query":
+		req.QueryParams[p.Name] = value
+	case "header":
+		req.Headers[p.Name] = value
+	case "path":
+		req.SetPathParam(p.Name, value)
+	}
+}
+
+// validHeaderValue reports whether a value can be sent in a header at all
+func validHeaderValue(v string) bool {
+	for _, r := range v {
+		if r < 0x20 && r != '\t' || r == 0x7f {
+			return false
+		}
+	}
+	return true
+}
+
+func cloneRequest(req *api.Request) *api.Request {
+	c := *req
+	c.PathParams = cloneMap(req.PathParams)
+	c.QueryParams = cloneMap(req.QueryParams)
+	c.Headers = cloneMap(req.Headers)
+	return &c
+}
+
+func cloneMap(m map[string]string) map[string]string {
+	c := make(map[string]string, len(m))
+	for k, v := range m {
+		c[k] = v
+	}
+	return c
+}
+
+func cloneJSON(v any) any {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return v
+	}
+	var c any
+	json.Unmarshal(data, &c)
+	return c
+}
+
+// lookup returns the value at a path of object keys and array indexes
+func lookup(v any, path []string) (any, bool) {
+	for _, key := range path {
+		switch node := v.(type) {
+		case map[string]any:
+			var ok bool
+			if v, ok = node[key]; !ok {
+				return nil, false
+			}
+		case []any:
+			i, err := strconv.Atoi(key)
+			if err != nil || i >= len(node) {
+				return nil, false
+			}
+			v = node[i]
+		default:
+			return nil, false
+		}
+	}
+	return v, true
+}
+
+// setAt applies a mutation at a path, returning the new document. The root is
+// replaced when the path is empty; removing needs an object property.
+func setAt(doc any, path []string, m mutation) (any, bool) {
+	if len(path) == 0 {
+		return m.value, !m.missing
+	}
+	parent, ok := lookup(doc, path[:len(path)-1])
+	if !ok {
+		return nil, false
+	}
+	key := path[len(path)-1]
+	switch node := parent.(type) {
+	case map[string]any:
+		if m.missing {
+			if _, ok := node[key]; !ok {
+				return nil, false
+			}
+			delete(node, key)
+		} else {
+			node[key] = m.value
+		}
+	case []any:
+		i, err := strconv.Atoi(key)
+		if err != nil || i >= len(node) || m.missing {
+			return nil, false
+		}
+		node[i] = m.value
+	default:
+		return nil, false
+	}
+	return doc, true
+}
@@ -0,0 +1,102 @@
+package importer
+
+import (
+	"encoding/json"
+	"net/url"
+	"strings"
+)
+
+type harFile struct {
+	Log struct {
+		Entries []struct {
+			Request harRequest `json:"request"`
+		} `json:"entries"`
+	} `json:"log"`
+}
+
+type harRequest struct {
+	Method   string    `json:"method"`
+	URL      string    `json:"url"`
+	Headers  []harPair `json:"headers"`
+	PostData *struct {
+		MimeType string `json:"mimeType"`
+		Text     string `json:"text"`
+		Params   []struct {
+			Name     string `json:"name"`
+			Value    string `json:"value"`
+			FileName string `json:"fileName"`
+		} `json:"params"`
+	} `json:"postData"`
+}
+
+type harPair struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// har imports the requests of a HAR capture that match an operation and were
+// sent to the API's hosts. Repeated identical requests are imported once.
+func (im *importer) har(data []byte, name string) error {
+	var f harFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return err
+	}
+
+	hosts := im.hosts
+	if u, err := url.Parse(im.opts.BaseURL); err == nil && u.Host != "" {
+		hosts = map[string]bool{hostKey(u): true}
+		for host := range im.hosts {
+			hosts[host] = true
+		}
+	}
+	if len(hosts) == 0 {
+		im.warn("the spec names no server host, requests to every host are imported; set a base URL to import only those to the API")
+	}
+
+	for _, entry := range f.Log.Entries {
+		req := entry.Request
+		u, err := url.Parse(req.URL)
+		if err != nil || u.Host == "" {
+			im.result.Skipped = append(im.result.Skipped, req.Method+" "+req.URL)
+			continue
+		}
+		if len(hosts) > 0 && !hosts[hostKey(u)] {
+			im.result.OtherHosts = append(im.result.OtherHosts, req.Method+" "+req.URL)
+			continue
+		}
+
+		r := request{
+			collection: collectionName(name),
+			label:      req.Method + " " + req.URL,
+			method:     strings.ToUpper(req.Method),
+			origin:     u.Scheme + "://" + u.Host,
+			path:       u.EscapedPath(),
+			query:      parseQuery(u.RawQuery),
+		}
+		for _, h := range req.Headers {
+			r.headers = append(r.headers, pair{h.Name, h.Value})
+		}
+
+		if post := req.PostData; post != nil {
+			r.body = post.Text
+			if r.body == "" && len(post.Params) > 0 {
+				var fields []pair
+				for _, p := range post.Params {
+					if p.FileName != "" {
+						im.warn("%s: file uploads are not imported", r.label)
+						fields = nil
+						break
+					}
+					fields = append(fields, pair{p.Name, p.Value})
+				}
+				r.body = formEncode(fields)
+			}
+			if post.MimeType != "" && !hasHeader(r.headers, "Content-Type") {
+				r.headers = append(r.headers, pair{"Content-Type", post.MimeType})
+			}
+		}
+
+		im.add(r)
+	}
+	return nil
+}
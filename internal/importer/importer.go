@@ -0,0 +1,655 @@
+// Package importer turns Postman collections and HAR captures into saved
+// requests against a spec's operations, and their variables and credentials
+// into environment variables.
+package importer
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/doganarif/ApiMug/internal/api"
+	"github.com/doganarif/ApiMug/internal/collection"
+	"gopkg.in/yaml.v3"
+)
+
+// Result is what an import produced
+type Result struct {
+	Format      string // Postman, Postman environment or HAR
+	Requests    []Imported
+	Variables   api.Variables              // Including credentials taken out of the requests
+	Credentials map[string]*api.AuthConfig // By security scheme, when taken with Options.Credentials
+	Secrets     []string                   // Variables holding credentials
+	BaseURL     string                     // Where the imported requests were sent, when known
+	Skipped     []string                   // Requests that match no operation
+	OtherHosts  []string                   // HAR requests to hosts other than the API's
+	Warnings    []string
+}
+
+// Imported is a saved request and the collection it goes in
+type Imported struct {
+	Collection string
+	Request    *collection.Request
+}
+
+// Options adjust an import
+type Options struct {
+	Collection string        // Collection to import into, by default named after the Postman collection or the HAR file
+	Variables  api.Variables // Already set, e.g. in the environment, credentials get names of their own
+	BaseURL    string        // Chosen for the API, its host is imported from HAR files besides the servers'
+
+	// Credentials taken by earlier imports, by security scheme. When set,
+	// credentials of the operations' security schemes go to Result.Credentials
+	// rather than variables, and are left out of the requests.
+	Credentials map[string]*api.AuthConfig
+
+	// Requests saved already. Their names are not reused, and requests saved
+	// unchanged are not imported again.
+	Saved *collection.File
+}
+
+// Import reads a Postman v2.1 collection or environment, or a HAR file.
+// Requests are saved to the collection of the options. Folders of a Postman
+// collection get collections of their own.
+func Import(spec *api.Spec, source string, data []byte, opts Options) (*Result, error) {
+	var probe struct {
+		Info *struct {
+			Schema string `json:"schema"`
+		} `json:"info"`
+		Log    json.RawMessage `json:"log"`
+		Values json.RawMessage `json:"values"`
+	}
+	if err := json.Unmarshal(data, &probe); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", source, err)
+	}
+
+	im := newImporter(spec)
+	im.opts = opts
+	name := opts.Collection
+	var err error
+	switch {
+	case probe.Log != nil:
+		if name == "" {
+			name = strings.TrimSuffix(filepath.Base(source), filepath.Ext(source))
+		}
+		im.result.Format = "HAR"
+		err = im.har(data, name)
+	case probe.Info != nil:
+		if !strings.Contains(probe.Info.Schema, "/v2.") {
+			return nil, fmt.Errorf("%s: unsupported Postman collection format %q, export it as v2.1", source, probe.Info.Schema)
+		}
+		im.result.Format = "Postman"
+		err = im.postman(data, name)
+	case probe.Values != nil:
+		im.result.Format = "Postman environment"
+		err = im.postmanEnvironment(data)
+	default:
+		return nil, fmt.Errorf("%s is not a Postman collection, Postman environment or HAR file", source)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", source, err)
+	}
+	return im.result, nil
+}
+
+// pair is a header, query parameter or form field
+type pair struct {
+	name, value string
+}
+
+// request is a request to import, in a form common to both formats
+type request struct {
+	collection string
+	name       string // Empty derives a name from the operation
+	label      string // Method and URL, for messages
+	method     string
+	origin     string // scheme://host, empty when unknown
+	path       string // Escaped, without the query
+	query      []pair
+	headers    []pair
+	body       string
+	auth       string
+}
+
+type importer struct {
+	endpoints []api.Endpoint
+	prefixes  []string        // Path prefixes of the spec's servers, tried in order
+	hosts     map[string]bool // Hosts of the spec's servers, see hostKey
+	patterns  map[string]*regexp.Regexp
+	names     map[string]map[string]bool // Request names taken, per collection
+	seen      map[string]bool            // Derived requests already imported
+	auth      *api.AuthManager           // Security requirements and schemes of the spec
+	opts      Options
+	result    *Result
+}
+
+func newImporter(spec *api.Spec) *importer {
+	endpoints := spec.GetEndpoints()
+	sort.Slice(endpoints, func(i, j int) bool {
+		if endpoints[i].Path != endpoints[j].Path {
+			return endpoints[i].Path < endpoints[j].Path
+		}
+		return endpoints[i].Method < endpoints[j].Method
+	})
+
+	// Requests may be sent to a server with a base path that the spec's
+	// paths leave out, try without one first
+	servers := spec.GetServers()
+	for _, e := range endpoints {
+		servers = append(servers, e.Servers...)
+	}
+	prefixes := []string{""}
+	seen := map[string]bool{"": true}
+	hosts := make(map[string]bool)
+	for _, s := range servers {
+		u, err := url.Parse(s.Resolve(nil))
+		if err != nil {
+			continue
+		}
+		if u.Host != "" {
+			hosts[hostKey(u)] = true
+		}
+		prefix := strings.TrimSuffix(u.EscapedPath(), "/")
+		if !seen[prefix] {
+			seen[prefix] = true
+			prefixes = append(prefixes, prefix)
+		}
+	}
+	sort.SliceStable(prefixes[1:], func(i, j int) bool { return len(prefixes[1+i]) > len(prefixes[1+j]) })
+
+	return &importer{
+		endpoints: endpoints,
+		prefixes:  prefixes,
+		hosts:     hosts,
+		patterns:  make(map[string]*regexp.Regexp),
+		names:     make(map[string]map[string]bool),
+		seen:      make(map[string]bool),
+		auth:      api.NewAuthManager(spec),
+		result:    &Result{Variables: make(api.Variables), Credentials: make(map[string]*api.AuthConfig)},
+	}
+}
+
+func (im *importer) warn(format string, args ...any) {
+	im.result.Warnings = append(im.result.Warnings, fmt.Sprintf(format, args...))
+}
+
+// add saves r when it matches an operation, placing its values where the
+// operation declares them
+func (im *importer) add(r request) {
+	endpoint, params, prefix := im.match(r.method, r.path)
+	if endpoint == nil {
+		im.result.Skipped = append(im.result.Skipped, r.label)
+		return
+	}
+
+	saved := &collection.Request{
+		Operation: endpoint.Ref(),
+		Params:    params,
+		Auth:      r.auth,
+	}
+	var warnings []string
+	for _, q := range r.query {
+		if im.credential(endpoint, "query", q.name, q.value) {
+			continue
+		}
+		if p := declared(endpoint, "query", q.name); p != nil {
+			saved.Params[p.Name] = im.secret(q.name, q.value)
+		} else {
+			warnings = append(warnings, fmt.Sprintf("%s: query parameter %q is not in the spec, left out", r.label, q.name))
+		}
+	}
+	for _, h := range r.headers {
+		im.header(saved, endpoint, h)
+	}
+
+	if r.body != "" {
+		if endpoint.HasBody {
+			saved.Body = r.body
+		} else {
+			warnings = append(warnings, fmt.Sprintf("%s: the operation takes no body, left out", r.label))
+		}
+	}
+
+	key, _ := json.Marshal(struct {
+		Collection string
+		Request    *collection.Request
+	}{r.collection, saved})
+	if im.saved(r.collection, r.name, saved) {
+		return
+	}
+	if r.name == "" {
+		if im.seen[string(key)] {
+			return
+		}
+		im.seen[string(key)] = true
+		r.name = derivedName(endpoint)
+	}
+	if strings.Contains(string(key), "{{$") {
+		warnings = append(warnings, fmt.Sprintf("%s: dynamic variables such as {{$guid}} are not supported, replace them with values", r.label))
+	}
+	im.result.Warnings = append(im.result.Warnings, warnings...)
+	saved.Name = im.unique(r.collection, r.name)
+
+	im.result.Requests = append(im.result.Requests, Imported{Collection: r.collection, Request: saved})
+	if im.result.BaseURL == "" && r.origin != "" {
+		im.result.BaseURL = r.origin + prefix
+	}
+}
+
+// ignoredHeaders are set by browsers and HTTP clients on their own
+var ignoredHeaders = map[string]bool{
+	"host":                      true,
+	"connection":                true,
+	"content-length":            true,
+	"accept-encoding":           true,
+	"accept-language":           true,
+	"user-agent":                true,
+	"referer":                   true,
+	"origin":                    true,
+	"cache-control":             true,
+	"pragma":                    true,
+	"dnt":                       true,
+	"te":                        true,
+	"priority":                  true,
+	"upgrade-insecure-requests": true,
+	"postman-token":             true,
+}
+
+// header adds a header to the saved request, as a parameter when the
+// operation declares it
+func (im *importer) header(saved *collection.Request, endpoint *api.Endpoint, h pair) {
+	lower := strings.ToLower(h.name)
+	switch {
+	case ignoredHeaders[lower], strings.HasPrefix(lower, "sec-"), strings.HasPrefix(lower, ":"):
+		return
+	case lower == "content-type" && isJSON(h.value):
+		// Saved bodies are sent as JSON already
+		return
+	}
+	if im.credential(endpoint, "header", h.name, h.value) {
+		return
+	}
+
+	if p := declared(endpoint, "header", h.name); p != nil {
+		saved.Params[p.Name] = im.secret(h.name, h.value)
+		return
+	}
+	if saved.Headers == nil {
+		saved.Headers = make(map[string]string)
+	}
+	saved.Headers[h.name] = im.secret(h.name, h.value)
+}
+
+// secretNames are headers and parameters that carry credentials
+var secretNames = map[string]bool{
+	"authorization":       true,
+	"proxy-authorization": true,
+	"cookie":              true,
+	"apikey":              true,
+	"api-key":             true,
+	"api_key":             true,
+	"x-api-key":           true,
+}
+
+// secret moves a credential into an environment variable, so that it is not
+// saved with the requests, and returns the reference to it
+func (im *importer) secret(name, value string) string {
+	lower := strings.ToLower(name)
+	if value == "" || strings.Contains(value, "{{") {
+		return value
+	}
+	if !secretNames[lower] && !strings.Contains(lower, "token") && !strings.Contains(lower, "secret") && !strings.Contains(lower, "password") {
+		return value
+	}
+
+	base := strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '-' || r == '_' || r == '.' {
+			return r
+		}
+		return '_'
+	}, lower)
+
+	// Credentials that differ, e.g. tokens of two users, are numbered rather
+	// than overwrite each other
+	variable := base
+	for n := 2; ; n++ {
+		current, ok := im.result.Variables[variable]
+		if !ok {
+			current, ok = im.opts.Variables[variable]
+		}
+		if !ok {
+			break
+		}
+		if current == value {
+			return "{{" + variable + "}}"
+		}
+		variable = fmt.Sprintf("%s_%d", base, n)
+	}
+	if variable != base {
+		im.warn("another %s value is saved as {{%s}}", name, variable)
+	}
+	im.result.Variables[variable] = value
+	im.result.Secrets = append(im.result.Secrets, variable)
+	return "{{" + variable + "}}"
+}
+
+// credential takes a header or query parameter that carries the credentials
+// of one of the operation's security schemes, reporting whether it did
+func (im *importer) credential(endpoint *api.Endpoint, in, name, value string) bool {
+	if im.opts.Credentials == nil || value == "" || strings.Contains(value, "{{") {
+		return false
+	}
+	// A stored env: or exec: value would be read from the environment or run
+	// as a command, which an imported file must not be able to do
+	if api.IsSecretReference(value) {
+		im.warn("%s looks like an env: or exec: reference, kept in a variable rather than stored", name)
+		return false
+	}
+
+	alternatives, _ := im.auth.Requirements(endpoint.Security)
+	for _, schemes := range alternatives {
+		for _, scheme := range schemes {
+			config, err := im.auth.ParseAuthScheme(scheme)
+			if err != nil || !setCredential(config, in, name, value) {
+				continue
+			}
+			for _, field := range []string{config.Token, config.APIKey, config.Username, config.Password} {
+				if api.IsSecretReference(field) {
+					im.warn("%s looks like an env: or exec: reference, kept in a variable rather than stored", name)
+					return false
+				}
+			}
+			current := im.result.Credentials[scheme]
+			if current == nil {
+				current = im.opts.Credentials[scheme]
+			}
+			if current != nil && *current != *config {
+				im.warn("another %s credential is kept in a variable", scheme)
+				return false
+			}
+			im.result.Credentials[scheme] = config
+			return true
+		}
+	}
+	return false
+}
+
+// setCredential fills in a scheme's config from a header or query parameter
+// when it is the one the scheme is sent in
+func setCredential(config *api.AuthConfig, in, name, value string) bool {
+	scheme, credentials, _ := strings.Cut(value, " ")
+	authorization := in == "header" && strings.EqualFold(name, "Authorization")
+
+	switch config.Type {
+	case api.AuthTypeBearer:
+		if !authorization || !strings.EqualFold(scheme, "Bearer") {
+			return false
+		}
+		config.Token = credentials
+	case api.AuthTypeBasic:
+		if !authorization || !strings.EqualFold(scheme, "Basic") {
+			return false
+		}
+		decoded, err := base64.StdEncoding.DecodeString(credentials)
+		if err != nil {
+			return false
+		}
+		username, password, ok := strings.Cut(string(decoded), ":")
+		if !ok {
+			return false
+		}
+		config.Username, config.Password = username, password
+	case api.AuthTypeAPIKey:
+		if config.APIKeyIn != in || !strings.EqualFold(config.KeyName, name) {
+			return false
+		}
+		config.APIKey = value
+	default:
+		return false
+	}
+	return true
+}
+
+// match finds the operation a request was sent to, with the values of its
+// path parameters and the server path prefix it was sent under. Of the
+// operations matching, the one with the most literal segments wins.
+func (im *importer) match(method, path string) (*api.Endpoint, map[string]string, string) {
+	segments := split(path)
+	for _, prefix := range im.prefixes {
+		rest, ok := trimSegments(segments, split(prefix))
+		if !ok {
+			continue
+		}
+
+		var best *api.Endpoint
+		var bestParams map[string]string
+		bestScore := -1
+		for i := range im.endpoints {
+			e := &im.endpoints[i]
+			if !strings.EqualFold(e.Method, method) {
+				continue
+			}
+			if params, score, ok := im.matchPath(e.Path, rest); ok && score > bestScore {
+				best, bestParams, bestScore = e, params, score
+			}
+		}
+		if best != nil {
+			return best, bestParams, prefix
+		}
+	}
+	return nil, nil, ""
+}
+
+// matchPath matches path segments against a path template, returning the
+// parameter values and the number of literal segments
+func (im *importer) matchPath(template string, segments []string) (map[string]string, int, bool) {
+	parts := split(template)
+	if len(parts) != len(segments) {
+		return nil, 0, false
+	}
+
+	params := make(map[string]string)
+	score := 0
+	for i, part := range parts {
+		segment := unescape(segments[i])
+		if !strings.Contains(part, "{") {
+			if part != segment {
+				return nil, 0, false
+			}
+			score++
+			continue
+		}
+
+		names := templateParam.FindAllStringSubmatch(part, -1)
+		values := im.pattern(part).FindStringSubmatch(segment)
+		if values == nil {
+			return nil, 0, false
+		}
+		for j, name := range names {
+			params[name[1]] = values[j+1]
+		}
+	}
+	return params, score, true
+}
+
+// templateParam matches {name} in a path template
+var templateParam = regexp.MustCompile(`\{([^{}]+)\}`)
+
+// pattern compiles a templated path segment such as {name}.{ext}
+func (im *importer) pattern(part string) *regexp.Regexp {
+	if re, ok := im.patterns[part]; ok {
+		return re
+	}
+	var b strings.Builder
+	b.WriteString("^")
+	last := 0
+	for _, loc := range templateParam.FindAllStringIndex(part, -1) {
+		b.WriteString(regexp.QuoteMeta(part[last:loc[0]]))
+		b.WriteString("(.+?)")
+		last = loc[1]
+	}
+	b.WriteString(regexp.QuoteMeta(part[last:]))
+	b.WriteString("$")
+	re := regexp.MustCompile(b.String())
+	im.patterns[part] = re
+	return re
+}
+
+// saved reports whether the collection already has the request, under the
+// given name unless it is derived
+func (im *importer) saved(collectionName, name string, req *collection.Request) bool {
+	c := im.savedCollection(collectionName)
+	if c == nil {
+		return false
+	}
+	// Compared as saved, where empty fields are left out
+	key, _ := yaml.Marshal(req)
+	for _, existing := range c.Requests {
+		if name != "" && existing.Name != name {
+			continue
+		}
+		unnamed := *existing
+		unnamed.Name = ""
+		if existingKey, _ := yaml.Marshal(&unnamed); string(existingKey) == string(key) {
+			return true
+		}
+	}
+	return false
+}
+
+// savedCollection returns the named collection saved before the import, or nil
+func (im *importer) savedCollection(name string) *collection.Collection {
+	if im.opts.Saved == nil {
+		return nil
+	}
+	return im.opts.Saved.Collection(name)
+}
+
+// unique returns name, numbered when the collection already has a request of
+// that name, saved before or from this import
+func (im *importer) unique(collection, name string) string {
+	taken := im.names[collection]
+	if taken == nil {
+		taken = make(map[string]bool)
+		im.names[collection] = taken
+	}
+	existing := im.savedCollection(collection)
+	unique := name
+	for n := 2; taken[unique] || existing != nil && existing.Request(unique) != nil; n++ {
+		unique = fmt.Sprintf("%s %d", name, n)
+	}
+	taken[unique] = true
+	return unique
+}
+
+// declared returns the operation's parameter of the given name and location
+func declared(endpoint *api.Endpoint, in, name string) *api.Parameter {
+	for i, p := range endpoint.Parameters {
+		if p.In != in {
+			continue
+		}
+		if p.Name == name || in == "header" && strings.EqualFold(p.Name, name) {
+			return &endpoint.Parameters[i]
+		}
+	}
+	return nil
+}
+
+// derivedName names a request after its operation
+func derivedName(endpoint *api.Endpoint) string {
+	if endpoint.OperationID != "" {
+		return endpoint.OperationID
+	}
+	name := strings.ToLower(endpoint.Method)
+	for _, part := range split(endpoint.Path) {
+		name += "-" + strings.Trim(part, "{}")
+	}
+	return name
+}
+
+// collectionName makes a name usable as a collection, which can't contain a slash
+func collectionName(parts ...string) string {
+	return strings.ReplaceAll(strings.Join(parts, " - "), "/", "-")
+}
+
+// requestName makes a name usable as a saved request
+func requestName(name string) string {
+	return strings.ReplaceAll(strings.TrimSpace(name), "/", "-")
+}
+
+// parseQuery splits a query string, keeping the order of the parameters
+func parseQuery(query string) []pair {
+	var pairs []pair
+	for _, part := range strings.Split(query, "&") {
+		if part == "" {
+			continue
+		}
+		name, value, _ := strings.Cut(part, "=")
+		pairs = append(pairs, pair{queryUnescape(name), queryUnescape(value)})
+	}
+	return pairs
+}
+
+// formEncode encodes form fields, keeping {{name}} references readable
+func formEncode(fields []pair) string {
+	keep := strings.NewReplacer("%7B%7B", "{{", "%7D%7D", "}}")
+	parts := make([]string, len(fields))
+	for i, f := range fields {
+		parts[i] = keep.Replace(url.QueryEscape(f.name)) + "=" + keep.Replace(url.QueryEscape(f.value))
+	}
+	return strings.Join(parts, "&")
+}
+
+// hostKey identifies the host of a URL, without the default port
+func hostKey(u *url.URL) string {
+	host := strings.ToLower(u.Hostname())
+	if port := u.Port(); port != "" && port != "80" && port != "443" {
+		host += ":" + port
+	}
+	return host
+}
+
+func split(path string) []string {
+	path = strings.Trim(path, "/")
+	if path == "" {
+		return nil
+	}
+	return strings.Split(path, "/")
+}
+
+// trimSegments removes prefix from the start of segments
+func trimSegments(segments, prefix []string) ([]string, bool) {
+	if len(prefix) > len(segments) {
+		return nil, false
+	}
+	for i, p := range prefix {
+		if unescape(segments[i]) != unescape(p) {
+			return nil, false
+		}
+	}
+	return segments[len(prefix):], true
+}
+
+func unescape(s string) string {
+	if u, err := url.PathUnescape(s); err == nil {
+		return u
+	}
+	return s
+}
+
+func queryUnescape(s string) string {
+	if u, err := url.QueryUnescape(s); err == nil {
+		return u
+	}
+	return s
+}
+
+func isJSON(contentType string) bool {
+	mediaType := strings.ToLower(strings.TrimSpace(strings.Split(contentType, ";")[0]))
+	return mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")
+}
@@ -0,0 +1,358 @@
+package importer
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/doganarif/ApiMug/internal/api"
+	"github.com/doganarif/ApiMug/internal/collection"
+)
+
+type postmanCollection struct {
+	Info struct {
+		Name string `json:"name"`
+	} `json:"info"`
+	Item     []postmanItem     `json:"item"`
+	Variable []postmanVariable `json:"variable"`
+	Auth     *postmanAuth      `json:"auth"`
+}
+
+// postmanItem is a request, or a folder of items when it has no request
+type postmanItem struct {
+	Name    string          `json:"name"`
+	Item    []postmanItem   `json:"item"`
+	Request json.RawMessage `json:"request"`
+	Auth    *postmanAuth    `json:"auth"`
+	Event   []any           `json:"event"`
+}
+
+type postmanRequest struct {
+	Method string            `json:"method"`
+	URL    postmanURL        `json:"url"`
+	Header []postmanVariable `json:"header"`
+	Body   *postmanBody      `json:"body"`
+	Auth   *postmanAuth      `json:"auth"`
+}
+
+type postmanURL struct {
+	Raw      string            `json:"raw"`
+	Query    []postmanVariable `json:"query"`
+	Variable []postmanVariable `json:"variable"`
+}
+
+// UnmarshalJSON accepts a URL given as a plain string
+func (u *postmanURL) UnmarshalJSON(data []byte) error {
+	if len(data) > 0 && data[0] == '"' {
+		return json.Unmarshal(data, &u.Raw)
+	}
+	type plain postmanURL
+	return json.Unmarshal(data, (*plain)(u))
+}
+
+// postmanVariable is a key and value, used for variables, headers, query
+// parameters, form fields and auth settings alike
+type postmanVariable struct {
+	Key      string `json:"key"`
+	Value    any    `json:"value"`
+	Type     string `json:"type"`
+	Disabled bool   `json:"disabled"`
+	Enabled  *bool  `json:"enabled"` // Used by environments instead of disabled
+}
+
+func (v postmanVariable) text() string {
+	switch value := v.Value.(type) {
+	case nil:
+		return ""
+	case string:
+		return value
+	default:
+		data, _ := json.Marshal(value)
+		return string(data)
+	}
+}
+
+func (v postmanVariable) active() bool {
+	return !v.Disabled && (v.Enabled == nil || *v.Enabled)
+}
+
+type postmanBody struct {
+	Mode       string            `json:"mode"`
+	Raw        string            `json:"raw"`
+	URLEncoded []postmanVariable `json:"urlencoded"`
+	FormData   []postmanVariable `json:"formdata"`
+	GraphQL    *struct {
+		Query     string `json:"query"`
+		Variables string `json:"variables"`
+	} `json:"graphql"`
+	Options struct {
+		Raw struct {
+			Language string `json:"language"`
+		} `json:"raw"`
+	} `json:"options"`
+}
+
+type postmanAuth struct {
+	Type   string            `json:"type"`
+	Bearer []postmanVariable `json:"bearer"`
+	Basic  []postmanVariable `json:"basic"`
+	APIKey []postmanVariable `json:"apikey"`
+}
+
+// setting returns an auth setting by key
+func setting(settings []postmanVariable, key string) string {
+	for _, s := range settings {
+		if s.Key == key {
+			return s.text()
+		}
+	}
+	return ""
+}
+
+// postman imports the requests of a collection, and its variables
+func (im *importer) postman(data []byte, name string) error {
+	var c postmanCollection
+	if err := json.Unmarshal(data, &c); err != nil {
+		return err
+	}
+	if name == "" {
+		name = c.Info.Name
+	}
+	if name == "" {
+		name = "postman"
+	}
+
+	vars := make(api.Variables)
+	for _, v := range c.Variable {
+		if v.Key != "" && v.active() {
+			vars[v.Key] = v.text()
+			im.result.Variables[v.Key] = v.text()
+		}
+	}
+
+	scripts := false
+	var walk func(items []postmanItem, folders []string, auth *postmanAuth) error
+	walk = func(items []postmanItem, folders []string, auth *postmanAuth) error {
+		for _, item := range items {
+			scripts = scripts || len(item.Event) > 0
+			if item.Request == nil {
+				inherited := auth
+				if item.Auth != nil {
+					inherited = item.Auth
+				}
+				if err := walk(item.Item, append(folders[:len(folders):len(folders)], item.Name), inherited); err != nil {
+					return err
+				}
+				continue
+			}
+
+			var req postmanRequest
+			if item.Request[0] == '"' {
+				req.Method = "GET"
+				if err := json.Unmarshal(item.Request, &req.URL.Raw); err != nil {
+					return err
+				}
+			} else if err := json.Unmarshal(item.Request, &req); err != nil {
+				return fmt.Errorf("request %q: %w", item.Name, err)
+			}
+			if req.Auth == nil {
+				req.Auth = auth
+			}
+			im.add(im.postmanRequest(req, vars, collectionName(append([]string{name}, folders...)...), requestName(item.Name)))
+		}
+		return nil
+	}
+	if err := walk(c.Item, nil, c.Auth); err != nil {
+		return err
+	}
+
+	if scripts {
+		im.warn("pre-request and test scripts are not imported")
+	}
+	return nil
+}
+
+// postmanRequest converts a Postman request, using the collection variables
+// to find where it was sent
+func (im *importer) postmanRequest(req postmanRequest, vars api.Variables, collection, name string) request {
+	method := strings.ToUpper(req.Method)
+	if method == "" {
+		method = "GET"
+	}
+	r := request{
+		collection: collection,
+		name:       name,
+		method:     method,
+		label:      method + " " + req.URL.Raw,
+	}
+
+	host, path, query := splitURL(req.URL.Raw)
+
+	// The part before the path is usually {{baseUrl}}, which may itself
+	// include the server's base path
+	expanded := vars.Expand(host)
+	if !strings.Contains(expanded, "{{") && expanded != "" {
+		if !strings.Contains(expanded, "://") {
+			expanded = "http://" + expanded
+		}
+		if u, err := url.Parse(expanded); err == nil && u.Host != "" {
+			r.origin = u.Scheme + "://" + u.Host
+			path = strings.TrimSuffix(u.EscapedPath(), "/") + path
+		}
+	}
+
+	// :name path segments take their values from the URL's variables
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if !strings.HasPrefix(segment, ":") {
+			continue
+		}
+		key := segment[1:]
+		segments[i] = "{{" + key + "}}"
+		for _, v := range req.URL.Variable {
+			if v.Key == key && v.text() != "" {
+				segments[i] = url.PathEscape(v.text())
+			}
+		}
+	}
+	r.path = strings.Join(segments, "/")
+
+	if req.URL.Query != nil {
+		for _, q := range req.URL.Query {
+			if q.active() {
+				r.query = append(r.query, pair{q.Key, q.text()})
+			}
+		}
+	} else {
+		r.query = parseQuery(query)
+	}
+
+	for _, h := range req.Header {
+		if h.active() {
+			r.headers = append(r.headers, pair{h.Key, h.text()})
+		}
+	}
+	im.postmanAuth(&r, req.Auth)
+	im.postmanBody(&r, req.Body)
+	return r
+}
+
+// postmanAuth turns bearer, basic and API key auth into headers and parameters
+func (im *importer) postmanAuth(r *request, auth *postmanAuth) {
+	if auth == nil {
+		return
+	}
+	switch auth.Type {
+	case "noauth":
+		r.auth = collection.AuthNone
+	case "bearer":
+		r.headers = append(r.headers, pair{"Authorization", "Bearer " + setting(auth.Bearer, "token")})
+	case "basic":
+		username, password := setting(auth.Basic, "username"), setting(auth.Basic, "password")
+		if strings.Contains(username+password, "{{") {
+			im.warn("%s: basic auth built from variables is not imported, set it up with the auth settings", r.label)
+			return
+		}
+		credentials := base64.StdEncoding.EncodeToString([]byte(username + ":" + password))
+		r.headers = append(r.headers, pair{"Authorization", "Basic " + credentials})
+	case "apikey":
+		key := pair{setting(auth.APIKey, "key"), setting(auth.APIKey, "value")}
+		if setting(auth.APIKey, "in") == "query" {
+			r.query = append(r.query, key)
+		} else {
+			r.headers = append(r.headers, key)
+		}
+	default:
+		im.warn("%s: %s auth is not imported, set it up with the auth settings", r.label, auth.Type)
+	}
+}
+
+// postmanBody converts raw, URL encoded and GraphQL bodies
+func (im *importer) postmanBody(r *request, body *postmanBody) {
+	if body == nil {
+		return
+	}
+	switch body.Mode {
+	case "raw":
+		r.body = body.Raw
+		if language := body.Options.Raw.Language; language != "" && language != "json" && !hasHeader(r.headers, "Content-Type") {
+			contentType := map[string]string{"xml": "application/xml", "html": "text/html", "javascript": "application/javascript"}[language]
+			if contentType == "" {
+				contentType = "text/plain"
+			}
+			r.headers = append(r.headers, pair{"Content-Type", contentType})
+		}
+	case "urlencoded":
+		var fields []pair
+		for _, f := range body.URLEncoded {
+			if f.active() {
+				fields = append(fields, pair{f.Key, f.text()})
+			}
+		}
+		r.body = formEncode(fields)
+		if !hasHeader(r.headers, "Content-Type") {
+			r.headers = append(r.headers, pair{"Content-Type", "application/x-www-form-urlencoded"})
+		}
+	case "graphql":
+		if body.GraphQL == nil {
+			return
+		}
+		payload := map[string]any{"query": body.GraphQL.Query}
+		var variables any
+		if json.Unmarshal([]byte(body.GraphQL.Variables), &variables) == nil && variables != nil {
+			payload["variables"] = variables
+		}
+		data, _ := json.Marshal(payload)
+		r.body = string(data)
+	case "":
+	default:
+		im.warn("%s: %s bodies are not imported", r.label, body.Mode)
+	}
+}
+
+// postmanEnvironment imports the variables of an exported environment
+func (im *importer) postmanEnvironment(data []byte) error {
+	var env struct {
+		Values []postmanVariable `json:"values"`
+	}
+	if err := json.Unmarshal(data, &env); err != nil {
+		return err
+	}
+	for _, v := range env.Values {
+		if v.Key != "" && v.active() {
+			im.result.Variables[v.Key] = v.text()
+		}
+	}
+	return nil
+}
+
+// splitURL separates a URL, which may start with a {{variable}} in place of
+// the scheme and host, into the part before the path, the path and the query
+func splitURL(raw string) (host, path, query string) {
+	raw, _, _ = strings.Cut(strings.TrimSpace(raw), "#")
+	raw, query, _ = strings.Cut(raw, "?")
+
+	start := 0
+	if i := strings.Index(raw, "://"); i >= 0 {
+		start = i + 3
+	} else if strings.HasPrefix(raw, "{{") {
+		if i := strings.Index(raw, "}}"); i >= 0 {
+			start = i + 2
+		}
+	}
+	if i := strings.Index(raw[start:], "/"); i >= 0 {
+		return raw[:start+i], raw[start+i:], query
+	}
+	return raw, "", query
+}
+
+func hasHeader(headers []pair, name string) bool {
+	for _, h := range headers {
+		if strings.EqualFold(h.name, name) {
+			return true
+		}
+	}
+	return false
+}
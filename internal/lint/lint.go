@@ -0,0 +1,194 @@
+// Package lint checks an OpenAPI document against style and correctness rules.
+package lint
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/doganarif/ApiMug/pkg/spec"
+	"github.com/getkin/kin-openapi/openapi3"
+	"gopkg.in/yaml.v3"
+)
+
+// Severity is how serious a finding is
+type Severity string
+
+const (
+	Error   Severity = "error"
+	Warning Severity = "warning"
+	Info    Severity = "info"
+	Off     Severity = "off" // Disables a rule in a config
+)
+
+// rank orders severities, most serious first
+func (s Severity) rank() int {
+	switch s {
+	case Error:
+		return 0
+	case Warning:
+		return 1
+	}
+	return 2
+}
+
+// ParseSeverity parses a severity name as used in configs and flags
+func ParseSeverity(s string) (Severity, error) {
+	switch sev := Severity(strings.ToLower(strings.TrimSpace(s))); sev {
+	case Error, Warning, Info, Off:
+		return sev, nil
+	}
+	return "", fmt.Errorf("unknown severity %q: expected error, warning, info or off", s)
+}
+
+// AtLeast reports whether s is as serious as other or more
+func (s Severity) AtLeast(other Severity) bool {
+	return s.rank() <= other.rank()
+}
+
+// Finding is a rule violation at a location in the document
+type Finding struct {
+	Rule      string   `json:"rule"`
+	Severity  Severity `json:"severity"`
+	Message   string   `json:"message"`
+	Location  string   `json:"location"`            // JSON pointer, e.g. #/paths/~1orders/get
+	Operation string   `json:"operation,omitempty"` // "METHOD /path" of the operation it is in
+	Line      int      `json:"line,omitempty"`      // Line in the spec file, when known
+}
+
+// Report is the result of linting a document
+type Report struct {
+	Spec     string    `json:"spec"`
+	Findings []Finding `json:"findings"`
+}
+
+// Counts returns the number of findings of each severity
+func (r *Report) Counts() (errors, warnings, infos int) {
+	for _, f := range r.Findings {
+		switch f.Severity {
+		case Error:
+			errors++
+		case Warning:
+			warnings++
+		default:
+			infos++
+		}
+	}
+	return errors, warnings, infos
+}
+
+// Has reports whether there is a finding at least as serious as sev
+func (r *Report) Has(sev Severity) bool {
+	for _, f := range r.Findings {
+		if f.Severity.AtLeast(sev) {
+			return true
+		}
+	}
+	return false
+}
+
+// Config overrides the severity of rules, "off" disables one
+type Config struct {
+	Rules map[string]Severity `yaml:"rules"`
+}
+
+// PathFor returns the lint config file next to a spec file
+func PathFor(source string) string {
+	if strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://") {
+		return "apimug.lint.yaml"
+	}
+	base := strings.TrimSuffix(source, filepath.Ext(source))
+	return base + ".lint.yaml"
+}
+
+// LoadConfig reads a config file. An empty path gives the default config, as
+// does a missing file unless required is set.
+func LoadConfig(path string, required bool) (*Config, error) {
+	cfg := &Config{Rules: make(map[string]Severity)}
+	if path == "" {
+		return cfg, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		if required {
+			return nil, fmt.Errorf("lint config %s not found", path)
+		}
+		return cfg, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read lint config: %w", err)
+	}
+	if err := yaml.Unmarshal(data, cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+	if cfg.Rules == nil {
+		cfg.Rules = make(map[string]Severity)
+	}
+	for id, sev := range cfg.Rules {
+		if err := cfg.Set(id, string(sev)); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	return cfg, nil
+}
+
+// Set overrides the severity of a rule
+func (c *Config) Set(id, severity string) error {
+	if FindRule(id) == nil {
+		return fmt.Errorf("unknown rule %q", id)
+	}
+	sev, err := ParseSeverity(severity)
+	if err != nil {
+		return fmt.Errorf("rule %s: %w", id, err)
+	}
+	c.Rules[id] = sev
+	return nil
+}
+
+// severity returns the configured severity of a rule
+func (c *Config) severity(rule *Rule) Severity {
+	if c != nil {
+		if sev, ok := c.Rules[rule.ID]; ok {
+			return sev
+		}
+	}
+	return rule.Severity
+}
+
+// Run checks doc against every enabled rule
+func Run(doc *openapi3.T, cfg *Config) *Report {
+	report := &Report{Findings: []Finding{}}
+	for _, rule := range Rules {
+		sev := cfg.severity(rule)
+		if sev == Off {
+			continue
+		}
+		rule.check(doc, func(f Finding) {
+			f.Rule = rule.ID
+			f.Severity = sev
+			report.Findings = append(report.Findings, f)
+		})
+	}
+
+	// Most serious first, each rule's findings in document order
+	sort.SliceStable(report.Findings, func(i, j int) bool {
+		return report.Findings[i].Severity.rank() < report.Findings[j].Severity.rank()
+	})
+	return report
+}
+
+// Locate fills in the line of each finding from the spec source, which may be
+// YAML or JSON
+func (r *Report) Locate(data []byte) {
+	pointers := make([]string, len(r.Findings))
+	for i, f := range r.Findings {
+		pointers[i] = f.Location
+	}
+	for i, line := range spec.Lines(data, pointers) {
+		r.Findings[i].Line = line
+	}
+}
@@ -0,0 +1,154 @@
+package lint
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"text/tabwriter"
+)
+
+// WriteText writes a line per finding followed by the totals
+func WriteText(w io.Writer, report *Report) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, f := range report.Findings {
+		location := f.Location
+		if f.Line > 0 {
+			location = fmt.Sprintf("%s:%d", report.Spec, f.Line)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", location, f.Severity, f.Rule, f.Message)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	errors, warnings, infos := report.Counts()
+	if len(report.Findings) > 0 {
+		fmt.Fprintln(w)
+	}
+	_, err := fmt.Fprintf(w, "%d error(s), %d warning(s), %d info\n", errors, warnings, infos)
+	return err
+}
+
+// WriteJSON writes the report as indented JSON
+func WriteJSON(w io.Writer, report *Report) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	errors, warnings, infos := report.Counts()
+	summary := struct {
+		*Report
+		Errors   int `json:"errors"`
+		Warnings int `json:"warnings"`
+		Infos    int `json:"infos"`
+	}{report, errors, warnings, infos}
+	if err := enc.Encode(summary); err != nil {
+		return fmt.Errorf("failed to encode JSON report: %w", err)
+	}
+	return nil
+}
+
+// The subset of SARIF 2.1.0 the report is written as
+type (
+	sarifLog struct {
+		Version string     `json:"version"`
+		Schema  string     `json:"$schema"`
+		Runs    []sarifRun `json:"runs"`
+	}
+	sarifRun struct {
+		Tool    sarifTool     `json:"tool"`
+		Results []sarifResult `json:"results"`
+	}
+	sarifTool struct {
+		Driver sarifDriver `json:"driver"`
+	}
+	sarifDriver struct {
+		Name           string      `json:"name"`
+		InformationURI string      `json:"informationUri"`
+		Rules          []sarifRule `json:"rules"`
+	}
+	sarifRule struct {
+		ID               string       `json:"id"`
+		ShortDescription sarifMessage `json:"shortDescription"`
+		DefaultConfig    struct {
+			Level string `json:"level"`
+		} `json:"defaultConfiguration"`
+	}
+	sarifMessage struct {
+		Text string `json:"text"`
+	}
+	sarifResult struct {
+		RuleID    string          `json:"ruleId"`
+		RuleIndex int             `json:"ruleIndex"`
+		Level     string          `json:"level"`
+		Message   sarifMessage    `json:"message"`
+		Locations []sarifLocation `json:"locations"`
+	}
+	sarifLocation struct {
+		Physical struct {
+			Artifact struct {
+				URI string `json:"uri"`
+			} `json:"artifactLocation"`
+			Region *sarifRegion `json:"region,omitempty"`
+		} `json:"physicalLocation"`
+		Logical []sarifLogical `json:"logicalLocations"`
+	}
+	sarifRegion struct {
+		StartLine int `json:"startLine"`
+	}
+	sarifLogical struct {
+		FullyQualifiedName string `json:"fullyQualifiedName"`
+	}
+)
+
+// sarifLevel maps a severity to a SARIF level
+func sarifLevel(s Severity) string {
+	switch s {
+	case Error:
+		return "error"
+	case Warning:
+		return "warning"
+	}
+	return "note"
+}
+
+// WriteSARIF writes the report as a SARIF 2.1.0 log for code scanning tools
+func WriteSARIF(w io.Writer, report *Report) error {
+	driver := sarifDriver{Name: "apimug", InformationURI: "https://github.com/doganarif/ApiMug"}
+	index := make(map[string]int, len(Rules))
+	for i, rule := range Rules {
+		r := sarifRule{ID: rule.ID, ShortDescription: sarifMessage{rule.Description}}
+		r.DefaultConfig.Level = sarifLevel(rule.Severity)
+		driver.Rules = append(driver.Rules, r)
+		index[rule.ID] = i
+	}
+
+	run := sarifRun{Tool: sarifTool{Driver: driver}, Results: []sarifResult{}}
+	for _, f := range report.Findings {
+		var loc sarifLocation
+		loc.Physical.Artifact.URI = filepath.ToSlash(report.Spec)
+		if f.Line > 0 {
+			loc.Physical.Region = &sarifRegion{StartLine: f.Line}
+		}
+		loc.Logical = []sarifLogical{{FullyQualifiedName: f.Location}}
+
+		run.Results = append(run.Results, sarifResult{
+			RuleID:    f.Rule,
+			RuleIndex: index[f.Rule],
+			Level:     sarifLevel(f.Severity),
+			Message:   sarifMessage{f.Message},
+			Locations: []sarifLocation{loc},
+		})
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	log := sarifLog{
+		Version: "2.1.0",
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Runs:    []sarifRun{run},
+	}
+	if err := enc.Encode(log); err != nil {
+		return fmt.Errorf("failed to encode SARIF report: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,542 @@
+package lint
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/doganarif/ApiMug/pkg/spec"
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// Rule is a check run over the whole document
+type Rule struct {
+	ID          string
+	Description string
+	Severity    Severity // Default severity, a config may override it
+	check       func(doc *openapi3.T, report func(Finding))
+}
+
+// Rules are all the lint rules, in the order they run
+var Rules = []*Rule{
+	{"spec-valid", "The document is valid OpenAPI", Error, checkValid},
+	{"operation-id", "Every operation has an operationId", Warning, checkOperationID},
+	{"operation-id-unique", "operationIds are unique", Error, checkOperationIDUnique},
+	{"path-params", "Path template parameters and declared path parameters match", Error, checkPathParams},
+	{"operation-description", "Every operation has a summary or description", Warning, checkOperationDescription},
+	{"parameter-description", "Every parameter has a description", Info, checkParameterDescription},
+	{"examples", "Request bodies and success responses have examples", Info, checkExamples},
+	{"error-responses", "Every operation documents an error response", Warning, checkErrorResponses},
+	{"unused-components", "Every component is referenced", Warning, checkUnusedComponents},
+	{"naming", "operationIds, path segments, parameters and properties use one naming style", Warning, checkNaming},
+	{"server-https", "Servers use https, except for local hosts", Warning, checkServerHTTPS},
+}
+
+// FindRule returns the rule with an ID, or nil
+func FindRule(id string) *Rule {
+	for _, r := range Rules {
+		if r.ID == id {
+			return r
+		}
+	}
+	return nil
+}
+
+// methodOrder is the order operations of a path are checked in
+var methodOrder = []string{"GET", "PUT", "POST", "DELETE", "OPTIONS", "HEAD", "PATCH", "TRACE"}
+
+type operation struct {
+	path   string
+	method string
+	item   *openapi3.PathItem
+	op     *openapi3.Operation
+}
+
+func (o operation) ref() string {
+	return o.method + " " + o.path
+}
+
+// pointer returns a location within the operation
+func (o operation) pointer(tokens ...string) string {
+	return spec.Pointer(append([]string{"paths", o.path, strings.ToLower(o.method)}, tokens...)...)
+}
+
+func (o operation) finding(message string, tokens ...string) Finding {
+	return Finding{Message: message, Location: o.pointer(tokens...), Operation: o.ref()}
+}
+
+// paths returns the paths of doc, sorted
+func paths(doc *openapi3.T) []string {
+	if doc.Paths == nil {
+		return nil
+	}
+	return sortedKeys(doc.Paths.Map())
+}
+
+// operations returns the operations of doc sorted by path and method
+func operations(doc *openapi3.T) []operation {
+	var ops []operation
+	for _, path := range paths(doc) {
+		item := doc.Paths.Value(path)
+		if item == nil {
+			continue
+		}
+		for _, method := range methodOrder {
+			if op := item.GetOperation(method); op != nil {
+				ops = append(ops, operation{path: path, method: method, item: item, op: op})
+			}
+		}
+	}
+	return ops
+}
+
+// parameters returns the parameters of an operation, including the ones of its
+// path it does not override, with the location of each
+func (o operation) parameters() ([]*openapi3.Parameter, []string) {
+	var params []*openapi3.Parameter
+	var locations []string
+	seen := make(map[string]bool)
+	for i, ref := range o.op.Parameters {
+		if ref == nil || ref.Value == nil {
+			continue
+		}
+		seen[ref.Value.In+" "+ref.Value.Name] = true
+		params = append(params, ref.Value)
+		locations = append(locations, o.pointer("parameters", fmt.Sprint(i)))
+	}
+	for i, ref := range o.item.Parameters {
+		if ref == nil || ref.Value == nil || seen[ref.Value.In+" "+ref.Value.Name] {
+			continue
+		}
+		params = append(params, ref.Value)
+		locations = append(locations, spec.Pointer("paths", o.path, "parameters", fmt.Sprint(i)))
+	}
+	return params, locations
+}
+
+func checkValid(doc *openapi3.T, report func(Finding)) {
+	for _, d := range spec.Diagnose(context.Background(), doc) {
+		if strings.HasSuffix(d.Location, "/operationId") {
+			continue // Reported by operation-id-unique
+		}
+		report(Finding{Message: d.Message, Location: d.Location, Operation: operationAt(d.Location)})
+	}
+}
+
+// operationAt returns the "METHOD /path" of the operation a location is in, or ""
+func operationAt(location string) string {
+	tokens := spec.SplitPointer(location)
+	if len(tokens) < 3 || tokens[0] != "paths" {
+		return ""
+	}
+	for _, method := range methodOrder {
+		if strings.EqualFold(tokens[2], method) {
+			return method + " " + tokens[1]
+		}
+	}
+	return ""
+}
+
+func checkOperationID(doc *openapi3.T, report func(Finding)) {
+	for _, o := range operations(doc) {
+		if o.op.OperationID == "" {
+			report(o.finding(o.ref() + " has no operationId"))
+		}
+	}
+}
+
+func checkOperationIDUnique(doc *openapi3.T, report func(Finding)) {
+	first := make(map[string]operation)
+	for _, o := range operations(doc) {
+		id := o.op.OperationID
+		if id == "" {
+			continue
+		}
+		if prev, ok := first[id]; ok {
+			report(o.finding(fmt.Sprintf("operationId %q is also used by %s", id, prev.ref()), "operationId"))
+			continue
+		}
+		first[id] = o
+	}
+}
+
+var templateParam = regexp.MustCompile(`\{([^{}]+)\}`)
+
+func checkPathParams(doc *openapi3.T, report func(Finding)) {
+	for _, o := range operations(doc) {
+		inTemplate := make(map[string]bool)
+		for _, m := range templateParam.FindAllStringSubmatch(o.path, -1) {
+			inTemplate[m[1]] = true
+		}
+
+		declared := make(map[string]bool)
+		params, locations := o.parameters()
+		for i, p := range params {
+			if p.In != openapi3.ParameterInPath {
+				continue
+			}
+			declared[p.Name] = true
+			if !inTemplate[p.Name] {
+				report(Finding{
+					Message:   fmt.Sprintf("path parameter %q of %s is not in the path", p.Name, o.ref()),
+					Location:  locations[i],
+					Operation: o.ref(),
+				})
+			}
+		}
+
+		for _, m := range templateParam.FindAllStringSubmatch(o.path, -1) {
+			if !declared[m[1]] {
+				report(o.finding(fmt.Sprintf("path parameter %q of %s is not documented", m[1], o.ref())))
+			}
+		}
+	}
+}
+
+func checkOperationDescription(doc *openapi3.T, report func(Finding)) {
+	for _, o := range operations(doc) {
+		if strings.TrimSpace(o.op.Summary) == "" && strings.TrimSpace(o.op.Description) == "" {
+			report(o.finding(o.ref() + " has no summary or description"))
+		}
+	}
+}
+
+func checkParameterDescription(doc *openapi3.T, report func(Finding)) {
+	reported := make(map[string]bool) // Path-level parameters are shared by operations
+	for _, o := range operations(doc) {
+		params, locations := o.parameters()
+		for i, p := range params {
+			if strings.TrimSpace(p.Description) != "" || reported[locations[i]] {
+				continue
+			}
+			reported[locations[i]] = true
+			report(Finding{
+				Message:   fmt.Sprintf("%s parameter %q of %s has no description", p.In, p.Name, o.ref()),
+				Location:  locations[i],
+				Operation: o.ref(),
+			})
+		}
+	}
+}
+
+func checkExamples(doc *openapi3.T, report func(Finding)) {
+	for _, o := range operations(doc) {
+		if o.op.RequestBody != nil && o.op.RequestBody.Value != nil {
+			for _, mt := range sortedKeys(o.op.RequestBody.Value.Content) {
+				if !mediaHasExample(o.op.RequestBody.Value.Content[mt]) {
+					report(o.finding(fmt.Sprintf("%s request body %s has no example", o.ref(), mt), "requestBody", "content", mt))
+				}
+			}
+		}
+
+		if o.op.Responses == nil {
+			continue
+		}
+		for _, code := range sortedKeys(o.op.Responses.Map()) {
+			resp := o.op.Responses.Value(code)
+			if !strings.HasPrefix(code, "2") || resp == nil || resp.Value == nil {
+				continue
+			}
+			for _, mt := range sortedKeys(resp.Value.Content) {
+				if !mediaHasExample(resp.Value.Content[mt]) {
+					report(o.finding(fmt.Sprintf("%s response %s %s has no example", o.ref(), code, mt), "responses", code, "content", mt))
+				}
+			}
+		}
+	}
+}
+
+func mediaHasExample(mt *openapi3.MediaType) bool {
+	if mt == nil || mt.Example != nil || len(mt.Examples) > 0 {
+		return true
+	}
+	if mt.Schema == nil {
+		return true // Nothing to give an example of
+	}
+	return schemaHasExample(mt.Schema, 0)
+}
+
+// schemaHasExample reports whether a schema has an example, or is an object or
+// array built entirely from schemas that do
+func schemaHasExample(ref *openapi3.SchemaRef, depth int) bool {
+	if ref == nil || ref.Value == nil || depth > 8 {
+		return false
+	}
+	s := ref.Value
+	if s.Example != nil {
+		return true
+	}
+	switch {
+	case s.Type.Is(openapi3.TypeObject) && len(s.Properties) > 0:
+		for _, prop := range s.Properties {
+			if !schemaHasExample(prop, depth+1) {
+				return false
+			}
+		}
+		return true
+	case s.Type.Is(openapi3.TypeArray):
+		return schemaHasExample(s.Items, depth+1)
+	}
+	return false
+}
+
+func checkErrorResponses(doc *openapi3.T, report func(Finding)) {
+	for _, o := range operations(doc) {
+		documented := false
+		if o.op.Responses != nil {
+			for code := range o.op.Responses.Map() {
+				if code == "default" || strings.HasPrefix(code, "4") || strings.HasPrefix(code, "5") {
+					documented = true
+					break
+				}
+			}
+		}
+		if !documented {
+			report(o.finding(o.ref()+" documents no 4xx, 5xx or default response", "responses"))
+		}
+	}
+}
+
+var componentRef = regexp.MustCompile(`"\$ref":"#/components/([^/"]+)/([^"]+)"`)
+
+func checkUnusedComponents(doc *openapi3.T, report func(Finding)) {
+	c := doc.Components
+	if c == nil {
+		return
+	}
+
+	// References are kept on the loaded document, so they show up when it is
+	// encoded again
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return
+	}
+	used := make(map[string]bool)
+	for _, m := range componentRef.FindAllStringSubmatch(string(data), -1) {
+		name := spec.SplitPointer("/" + m[2])
+		if len(name) > 0 {
+			used[m[1]+"/"+name[0]] = true
+		}
+	}
+	for _, req := range doc.Security {
+		for name := range req {
+			used["securitySchemes/"+name] = true
+		}
+	}
+	for _, o := range operations(doc) {
+		if o.op.Security != nil {
+			for _, req := range *o.op.Security {
+				for name := range req {
+					used["securitySchemes/"+name] = true
+				}
+			}
+		}
+	}
+
+	kinds := []struct {
+		kind  string
+		names []string
+	}{
+		{"schemas", sortedKeys(c.Schemas)},
+		{"parameters", sortedKeys(c.Parameters)},
+		{"headers", sortedKeys(c.Headers)},
+		{"requestBodies", sortedKeys(c.RequestBodies)},
+		{"responses", sortedKeys(c.Responses)},
+		{"securitySchemes", sortedKeys(c.SecuritySchemes)},
+		{"examples", sortedKeys(c.Examples)},
+		{"links", sortedKeys(c.Links)},
+		{"callbacks", sortedKeys(c.Callbacks)},
+	}
+	for _, k := range kinds {
+		for _, name := range k.names {
+			if !used[k.kind+"/"+name] {
+				report(Finding{
+					Message:  fmt.Sprintf("component %s/%s is never used", k.kind, name),
+					Location: spec.Pointer("components", k.kind, name),
+				})
+			}
+		}
+	}
+}
+
+// namingStyle classifies a name; a single lowercase word fits any style and
+// gives ""
+func namingStyle(name string) string {
+	lower, upper := false, false
+	for _, r := range name {
+		switch {
+		case r >= 'a' && r <= 'z':
+			lower = true
+		case r >= 'A' && r <= 'Z':
+			upper = true
+		}
+	}
+	underscore, dash := strings.Contains(name, "_"), strings.Contains(name, "-")
+
+	switch {
+	case underscore && dash:
+		return "mixed"
+	case underscore && !upper:
+		return "snake_case"
+	case dash && !upper:
+		return "kebab-case"
+	case underscore || dash:
+		return "mixed"
+	case !upper:
+		return ""
+	case !lower:
+		return "UPPERCASE"
+	case name[0] >= 'A' && name[0] <= 'Z':
+		return "PascalCase"
+	}
+	return "camelCase"
+}
+
+// named is a name and the finding to report when it does not fit the style
+type named struct {
+	name    string
+	finding Finding
+}
+
+// reportInconsistent reports the names whose style differs from the most
+// common one, which on a tie is the one seen first
+func reportInconsistent(what string, names []named, report func(Finding)) {
+	counts := make(map[string]int)
+	var order []string
+	for _, n := range names {
+		style := namingStyle(n.name)
+		if style == "" || style == "mixed" {
+			continue
+		}
+		if counts[style] == 0 {
+			order = append(order, style)
+		}
+		counts[style]++
+	}
+	if len(order) == 0 {
+		return
+	}
+	dominant := order[0]
+	for _, style := range order[1:] {
+		if counts[style] > counts[dominant] {
+			dominant = style
+		}
+	}
+
+	for _, n := range names {
+		style := namingStyle(n.name)
+		if style == "" || style == dominant {
+			continue
+		}
+		f := n.finding
+		f.Message = fmt.Sprintf("%s %q is not %s like the others", what, n.name, dominant)
+		report(f)
+	}
+}
+
+func checkNaming(doc *openapi3.T, report func(Finding)) {
+	ops := operations(doc)
+
+	var ids []named
+	for _, o := range ops {
+		if o.op.OperationID != "" {
+			ids = append(ids, named{o.op.OperationID, o.finding("", "operationId")})
+		}
+	}
+	reportInconsistent("operationId", ids, report)
+
+	var segments []named
+	seen := make(map[string]bool)
+	for _, o := range ops {
+		for _, segment := range strings.Split(o.path, "/") {
+			if segment == "" || strings.ContainsAny(segment, "{}") || seen[segment] {
+				continue
+			}
+			seen[segment] = true
+			segments = append(segments, named{segment, Finding{Location: spec.Pointer("paths", o.path)}})
+		}
+	}
+	reportInconsistent("path segment", segments, report)
+
+	// Header names have their own conventions
+	var params []named
+	seen = make(map[string]bool)
+	for _, o := range ops {
+		list, locations := o.parameters()
+		for i, p := range list {
+			if p.In == openapi3.ParameterInHeader || seen[p.Name] {
+				continue
+			}
+			seen[p.Name] = true
+			params = append(params, named{p.Name, Finding{Location: locations[i], Operation: o.ref()}})
+		}
+	}
+	reportInconsistent("parameter", params, report)
+
+	var props []named
+	if doc.Components != nil {
+		for _, name := range sortedKeys(doc.Components.Schemas) {
+			ref := doc.Components.Schemas[name]
+			if ref == nil || ref.Value == nil {
+				continue
+			}
+			for _, prop := range sortedKeys(ref.Value.Properties) {
+				props = append(props, named{prop, Finding{Location: spec.Pointer("components", "schemas", name, "properties", prop)}})
+			}
+		}
+	}
+	reportInconsistent("property", props, report)
+}
+
+func checkServerHTTPS(doc *openapi3.T, report func(Finding)) {
+	check := func(servers openapi3.Servers, tokens ...string) {
+		for i, s := range servers {
+			if s == nil || !strings.HasPrefix(strings.ToLower(s.URL), "http://") {
+				continue
+			}
+			u, err := url.Parse(s.URL)
+			if err == nil && isLocal(u.Hostname()) {
+				continue
+			}
+			report(Finding{
+				Message:  fmt.Sprintf("server %s does not use https", s.URL),
+				Location: spec.Pointer(append(tokens, "servers", fmt.Sprint(i))...),
+			})
+		}
+	}
+
+	check(doc.Servers)
+	for _, path := range paths(doc) {
+		if item := doc.Paths.Value(path); item != nil {
+			check(item.Servers, "paths", path)
+		}
+	}
+	for _, o := range operations(doc) {
+		if o.op.Servers != nil {
+			check(*o.op.Servers, "paths", o.path, strings.ToLower(o.method))
+		}
+	}
+}
+
+func isLocal(host string) bool {
+	switch {
+	case host == "localhost", strings.HasSuffix(host, ".localhost"), strings.HasSuffix(host, ".local"):
+		return true
+	case strings.HasPrefix(host, "127."), host == "::1", host == "0.0.0.0":
+		return true
+	}
+	return false
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,126 @@
+package scenario
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+type junitTestSuites struct {
+	XMLName  xml.Name         `xml:"testsuites"`
+	Tests    int              `xml:"tests,attr"`
+	Failures int              `xml:"failures,attr"`
+	Errors   int              `xml:"errors,attr"`
+	Skipped  int              `xml:"skipped,attr"`
+	Time     string           `xml:"time,attr"`
+	Suites   []junitTestSuite `xml:"testsuite"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Errors    int             `xml:"errors,attr"`
+	Skipped   int             `xml:"skipped,attr"`
+	Time      string          `xml:"time,attr"`
+	Timestamp string          `xml:"timestamp,attr"`
+	Cases     []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitProblem `xml:"failure,omitempty"`
+	Error     *junitProblem `xml:"error,omitempty"`
+	Skipped   *struct{}     `xml:"skipped,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitProblem struct {
+	Message string `xml:"message,attr"`
+	Body    string `xml:",chardata"`
+}
+
+// WriteJUnit writes the report as JUnit XML, one test case per step
+func WriteJUnit(w io.Writer, report *Report) error {
+	suite := junitTestSuite{
+		Name:      report.Name,
+		Time:      seconds(report.Duration.Seconds()),
+		Timestamp: report.Started.Format("2006-01-02T15:04:05"),
+	}
+
+	for _, step := range report.Steps {
+		tc := junitTestCase{
+			Name:      step.Name,
+			ClassName: report.Name,
+			Time:      seconds(step.Duration.Seconds()),
+		}
+		if step.Method != "" {
+			tc.SystemOut = fmt.Sprintf("%s %s -> %d", step.Method, step.Path, step.Status)
+		}
+
+		suite.Tests++
+		switch {
+		case step.Skipped:
+			tc.Skipped = &struct{}{}
+			suite.Skipped++
+		case step.Error != "":
+			tc.Error = &junitProblem{Message: step.Error}
+			suite.Errors++
+		case !step.Passed():
+			var failed []string
+			for _, a := range step.Assertions {
+				if !a.Passed {
+					failed = append(failed, fmt.Sprintf("%s: %s", a.Assertion, a.Message))
+				}
+			}
+			tc.Failure = &junitProblem{Message: failed[0], Body: strings.Join(failed, "\n")}
+			suite.Failures++
+		}
+		suite.Cases = append(suite.Cases, tc)
+	}
+
+	doc := junitTestSuites{
+		Tests:    suite.Tests,
+		Failures: suite.Failures,
+		Errors:   suite.Errors,
+		Skipped:  suite.Skipped,
+		Time:     suite.Time,
+		Suites:   []junitTestSuite{suite},
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return fmt.Errorf("failed to encode JUnit report: %w", err)
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// WriteJSON writes the report as indented JSON
+func WriteJSON(w io.Writer, report *Report) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	passed, failed, skipped := report.Counts()
+	summary := struct {
+		*Report
+		Passed  int `json:"passed"`
+		Failed  int `json:"failed"`
+		Skipped int `json:"skipped"`
+	}{report, passed, failed, skipped}
+	if err := enc.Encode(summary); err != nil {
+		return fmt.Errorf("failed to encode JSON report: %w", err)
+	}
+	return nil
+}
+
+func seconds(s float64) string {
+	return fmt.Sprintf("%.3f", s)
+}
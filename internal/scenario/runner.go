@@ -0,0 +1,275 @@
+package scenario
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/doganarif/ApiMug/internal/api"
+)
+
+// Report is the outcome of a scenario run
+type Report struct {
+	Name     string        `json:"name"`
+	Spec     string        `json:"spec"`
+	Started  time.Time     `json:"started"`
+	Duration time.Duration `json:"duration_ns"`
+	Steps    []*StepResult `json:"steps"`
+}
+
+// StepResult is the outcome of one step
+type StepResult struct {
+	Name       string            `json:"name"`
+	Operation  string            `json:"operation"`
+	Method     string            `json:"method,omitempty"`
+	Path       string            `json:"path,omitempty"`
+	Status     int               `json:"status,omitempty"`
+	Duration   time.Duration     `json:"duration_ns"`
+	Assertions []AssertionResult `json:"assertions,omitempty"`
+	Extracted  map[string]string `json:"extracted,omitempty"`
+	Error      string            `json:"error,omitempty"` // The step could not be sent or evaluated
+	Skipped    bool              `json:"skipped,omitempty"`
+}
+
+// AssertionResult is the outcome of one assertion
+type AssertionResult struct {
+	Assertion string `json:"assertion"`
+	Passed    bool   `json:"passed"`
+	Message   string `json:"message,omitempty"`
+}
+
+// Passed reports whether the step ran and all of its assertions held
+func (r *StepResult) Passed() bool {
+	if r.Skipped || r.Error != "" {
+		return false
+	}
+	for _, a := range r.Assertions {
+		if !a.Passed {
+			return false
+		}
+	}
+	return true
+}
+
+// Counts returns the number of passed, failed and skipped steps
+func (r *Report) Counts() (passed, failed, skipped int) {
+	for _, step := range r.Steps {
+		switch {
+		case step.Skipped:
+			skipped++
+		case step.Passed():
+			passed++
+		default:
+			failed++
+		}
+	}
+	return
+}
+
+// Passed reports whether every step passed
+func (r *Report) Passed() bool {
+	_, failed, skipped := r.Counts()
+	return failed == 0 && skipped == 0
+}
+
+// Run sends the steps in order, starting from the scenario's variables on top
+// of vars. progress, when set, is called as each step completes. After a failed
+// step the remaining steps are skipped unless the scenario continues on failure.
+func Run(ctx context.Context, client *api.Client, spec *api.Spec, sc *Scenario, vars api.Variables, progress func(index int, result *StepResult)) *Report {
+	report := &Report{Name: sc.Name, Spec: sc.Spec, Started: time.Now()}
+
+	vars = vars.Clone()
+	for name, value := range sc.Variables {
+		vars[name] = value
+	}
+
+	endpoints := spec.GetEndpoints()
+	failed := false
+	for i, step := range sc.Steps {
+		result := &StepResult{Name: step.Name, Operation: step.Operation}
+
+		switch {
+		case ctx.Err() != nil:
+			result.Skipped = true
+		case failed && !sc.ContinueOnFailure:
+			result.Skipped = true
+		default:
+			runStep(ctx, client, endpoints, step, vars, result)
+			failed = failed || !result.Passed()
+		}
+
+		report.Steps = append(report.Steps, result)
+		if progress != nil {
+			progress(i, result)
+		}
+	}
+
+	report.Duration = time.Since(report.Started)
+	return report
+}
+
+func runStep(ctx context.Context, client *api.Client, endpoints []api.Endpoint, step *Step, vars api.Variables, result *StepResult) {
+	endpoint, err := step.Endpoint(endpoints)
+	if err != nil {
+		result.Error = err.Error()
+		return
+	}
+
+	req := step.Build(endpoint, vars)
+	result.Method = req.Method
+	result.Path = req.Path
+
+	resp := client.Send(ctx, req)
+	result.Duration = resp.Duration
+	if resp.Error != nil {
+		result.Error = resp.Error.Error()
+		return
+	}
+	result.Status = resp.StatusCode
+
+	hasStatus := false
+	for _, a := range step.Assert {
+		hasStatus = hasStatus || a.Status != 0
+		result.Assertions = append(result.Assertions, a.check(endpoint, resp, vars))
+	}
+	if !hasStatus {
+		// Without an explicit expectation, error statuses fail the step
+		check := AssertionResult{Assertion: "status below 400", Passed: resp.StatusCode < 400}
+		if !check.Passed {
+			check.Message = fmt.Sprintf("got %s", resp.Status)
+		}
+		result.Assertions = append([]AssertionResult{check}, result.Assertions...)
+	}
+
+	for _, rule := range step.Extract {
+		value, err := rule.Extract(resp)
+		if err != nil {
+			result.Error = "extract " + err.Error()
+			return
+		}
+		vars[rule.Var] = value
+		if result.Extracted == nil {
+			result.Extracted = make(map[string]string)
+		}
+		result.Extracted[rule.Var] = value
+	}
+}
+
+// check evaluates the assertion against a response
+func (a Assertion) check(endpoint *api.Endpoint, resp *api.Response, vars api.Variables) AssertionResult {
+	result := AssertionResult{Assertion: a.String()}
+	fail := func(format string, args ...any) AssertionResult {
+		result.Message = fmt.Sprintf(format, args...)
+		return result
+	}
+
+	switch {
+	case a.Status != 0:
+		if resp.StatusCode != a.Status {
+			return fail("got %s", resp.Status)
+		}
+
+	case a.Latency != 0:
+		if resp.Duration > time.Duration(a.Latency)*time.Millisecond {
+			return fail("took %s", resp.Duration.Round(time.Millisecond))
+		}
+
+	case a.Schema:
+		if err := endpoint.ValidateResponse(resp); err != nil {
+			return fail("%v", err)
+		}
+
+	default:
+		body, err := api.DecodeJSON(resp.BodyBytes)
+		if err != nil {
+			return fail("body is not JSON")
+		}
+		actual, err := api.JSONPath(body, a.Path)
+		exists := err == nil
+
+		switch {
+		case a.Exists != nil && *a.Exists != exists:
+			if exists {
+				return fail("found %s", render(actual))
+			}
+			return fail("%v", err)
+		case a.Exists != nil:
+		case !exists:
+			return fail("%v", err)
+		case a.hasEquals:
+			if !equal(actual, expand(a.Equals, vars)) {
+				return fail("got %s", render(actual))
+			}
+		case a.Contains != nil:
+			if !contains(actual, expand(a.Contains, vars)) {
+				return fail("got %s", render(actual))
+			}
+		}
+	}
+
+	result.Passed = true
+	return result
+}
+
+// expand replaces variable references in an expected string value
+func expand(expected any, vars api.Variables) any {
+	if s, ok := expected.(string); ok {
+		return vars.Expand(s)
+	}
+	return expected
+}
+
+// equal compares JSON values. A string expectation also matches a scalar
+// written the same way, so that expanded variables compare with numbers.
+func equal(actual, expected any) bool {
+	if s, ok := expected.(string); ok {
+		if _, isString := actual.(string); !isString {
+			return s == render(actual)
+		}
+	}
+	return reflect.DeepEqual(normalize(actual), normalize(expected))
+}
+
+// contains checks a substring, an array element or an object key
+func contains(actual, expected any) bool {
+	switch v := actual.(type) {
+	case string:
+		return strings.Contains(v, fmt.Sprint(expected))
+	case []any:
+		for _, item := range v {
+			if equal(item, expected) {
+				return true
+			}
+		}
+	case map[string]any:
+		_, ok := v[fmt.Sprint(expected)]
+		return ok
+	}
+	return false
+}
+
+// normalize converts a value decoded from YAML or JSON to its JSON form
+func normalize(v any) any {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return v
+	}
+	var out any
+	json.Unmarshal(data, &out)
+	return out
+}
+
+// render formats a JSON value for messages, strings unquoted
+func render(v any) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
@@ -0,0 +1,164 @@
+// Package scenario runs ordered sequences of requests with assertions, passing
+// variables from one step to the next.
+package scenario
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/doganarif/ApiMug/internal/api"
+	"github.com/doganarif/ApiMug/internal/collection"
+	"gopkg.in/yaml.v3"
+)
+
+// Scenario is a multi-step workflow read from YAML
+type Scenario struct {
+	Name              string        `yaml:"name"`
+	Spec              string        `yaml:"spec"` // File relative to the scenario, or a URL
+	Variables         api.Variables `yaml:"variables"`
+	ContinueOnFailure bool          `yaml:"continue_on_failure"` // Run later steps after a failed one
+	Steps             []*Step       `yaml:"steps"`
+}
+
+// Step is a request, as saved in collections, with assertions on its response
+type Step struct {
+	collection.Request `yaml:",inline"`
+	Assert             []Assertion `yaml:"assert"`
+}
+
+// Assertion checks one property of a response. Set a single check per
+// assertion, except that equals, contains and exists go with path.
+type Assertion struct {
+	Status   int    `yaml:"status,omitempty"`
+	Path     string `yaml:"path,omitempty"` // JSON path into the body
+	Equals   any    `yaml:"equals,omitempty"`
+	Contains any    `yaml:"contains,omitempty"`
+	Exists   *bool  `yaml:"exists,omitempty"`
+	Schema   bool   `yaml:"schema,omitempty"`  // Body matches the documented schema
+	Latency  int    `yaml:"latency,omitempty"` // Maximum duration in milliseconds
+
+	hasEquals bool // equals was given, possibly as null
+}
+
+// UnmarshalYAML records whether equals was given, so that equals: null
+// expects a null rather than no value
+func (a *Assertion) UnmarshalYAML(node *yaml.Node) error {
+	type plain Assertion
+	if err := node.Decode((*plain)(a)); err != nil {
+		return err
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == "equals" {
+			a.hasEquals = true
+		}
+	}
+	return nil
+}
+
+// IsScenario reports whether a file holds a scenario rather than a spec
+func IsScenario(path string) bool {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return false
+	}
+	var probe struct {
+		Steps   []any `yaml:"steps"`
+		OpenAPI any   `yaml:"openapi"`
+		Swagger any   `yaml:"swagger"`
+	}
+	if yaml.Unmarshal(data, &probe) != nil {
+		return false
+	}
+	return probe.Steps != nil && probe.OpenAPI == nil && probe.Swagger == nil
+}
+
+// Load reads a scenario file, resolving the spec path against its directory
+func Load(path string) (*Scenario, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("scenario %s not found", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read scenario: %w", err)
+	}
+
+	sc := &Scenario{}
+	if err := yaml.Unmarshal(data, sc); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	if sc.Spec == "" {
+		return nil, fmt.Errorf("%s: no spec given", path)
+	}
+	if !strings.HasPrefix(sc.Spec, "http://") && !strings.HasPrefix(sc.Spec, "https://") && !filepath.IsAbs(sc.Spec) {
+		sc.Spec = filepath.Join(filepath.Dir(path), sc.Spec)
+	}
+	if sc.Name == "" {
+		sc.Name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	if len(sc.Steps) == 0 {
+		return nil, fmt.Errorf("%s: no steps", path)
+	}
+
+	for i, step := range sc.Steps {
+		if step.Operation == "" {
+			return nil, fmt.Errorf("%s: step %d has no operation", path, i+1)
+		}
+		if step.Name == "" {
+			step.Name = step.Operation
+		}
+		for _, e := range step.Extract {
+			if e.Var == "" {
+				return nil, fmt.Errorf("%s: extraction in step %q without a var", path, step.Name)
+			}
+			if e.Save {
+				// Extracted values are the scenario's own, they never reach the environment
+				return nil, fmt.Errorf("%s: step %q: save is not supported in scenarios", path, step.Name)
+			}
+		}
+		for _, a := range step.Assert {
+			if err := a.validate(); err != nil {
+				return nil, fmt.Errorf("%s: step %q: %w", path, step.Name, err)
+			}
+		}
+	}
+	return sc, nil
+}
+
+func (a Assertion) validate() error {
+	checks := 0
+	for _, set := range []bool{a.Status != 0, a.Path != "", a.Schema, a.Latency != 0} {
+		if set {
+			checks++
+		}
+	}
+	if checks != 1 {
+		return fmt.Errorf("an assertion needs exactly one of status, path, schema or latency")
+	}
+	if a.Path != "" && !a.hasEquals && a.Contains == nil && a.Exists == nil {
+		return fmt.Errorf("assertion on %s needs equals, contains or exists", a.Path)
+	}
+	return nil
+}
+
+// String describes the assertion for reports
+func (a Assertion) String() string {
+	switch {
+	case a.Status != 0:
+		return fmt.Sprintf("status is %d", a.Status)
+	case a.Schema:
+		return "body matches schema"
+	case a.Latency != 0:
+		return fmt.Sprintf("latency under %dms", a.Latency)
+	case a.Exists != nil && !*a.Exists:
+		return fmt.Sprintf("%s does not exist", a.Path)
+	case a.hasEquals:
+		return fmt.Sprintf("%s equals %s", a.Path, render(a.Equals))
+	case a.Contains != nil:
+		return fmt.Sprintf("%s contains %v", a.Path, a.Contains)
+	}
+	return fmt.Sprintf("%s exists", a.Path)
+}
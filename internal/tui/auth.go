@@ -0,0 +1,423 @@
+package tui
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	"github.com/doganarif/ApiMug/internal/api"
+)
+
+// authField describes an input of the auth form and where its value is stored
+type authField struct {
+	key         string
+	label       string
+	placeholder string
+	optional    bool
+	get         func(c *api.AuthConfig) string
+	set         func(c *api.AuthConfig, v string)
+}
+
+// authFieldsFor returns the inputs needed to configure a scheme
+func authFieldsFor(config *api.AuthConfig) []authField {
+	switch config.Type {
+	case api.AuthTypeBearer, api.AuthTypeOAuth2:
+		return []authField{
+			{key: "token", label: "Token", placeholder: "Enter token",
+				get: func(c *api.AuthConfig) string { return c.Token },
+				set: func(c *api.AuthConfig, v string) { c.Token = v }},
+		}
+
+	case api.AuthTypeAPIKey:
+		return []authField{
+			{key: "apikey", label: fmt.Sprintf("API Key (%s in %s)", config.KeyName, config.APIKeyIn), placeholder: "Enter API key",
+				get: func(c *api.AuthConfig) string { return c.APIKey },
+				set: func(c *api.AuthConfig, v string) { c.APIKey = v }},
+		}
+
+	case api.AuthTypeBasic, api.AuthTypeDigest:
+		return []authField{
+			{key: "username", label: "Username", placeholder: "Enter username",
+				get: func(c *api.AuthConfig) string { return c.Username },
+				set: func(c *api.AuthConfig, v string) { c.Username = v }},
+			{key: "password", label: "Password", placeholder: "Enter password",
+				get: func(c *api.AuthConfig) string { return c.Password },
+				set: func(c *api.AuthConfig, v string) { c.Password = v }},
+		}
+
+	case api.AuthTypeSigV4:
+		return []authField{
+			{key: "accessKey", label: "Access Key ID", placeholder: "AKIA...",
+				get: func(c *api.AuthConfig) string { return c.AccessKey },
+				set: func(c *api.AuthConfig, v string) { c.AccessKey = v }},
+			{key: "secretKey", label: "Secret Access Key", placeholder: "Enter secret key",
+				get: func(c *api.AuthConfig) string { return c.SecretKey },
+				set: func(c *api.AuthConfig, v string) { c.SecretKey = v }},
+			{key: "sessionToken", label: "Session Token", placeholder: "For temporary credentials", optional: true,
+				get: func(c *api.AuthConfig) string { return c.SessionToken },
+				set: func(c *api.AuthConfig, v string) { c.SessionToken = v }},
+			{key: "region", label: "Region", placeholder: "us-east-1",
+				get: func(c *api.AuthConfig) string { return c.Region },
+				set: func(c *api.AuthConfig, v string) { c.Region = v }},
+			{key: "service", label: "Service", placeholder: "execute-api",
+				get: func(c *api.AuthConfig) string { return c.Service },
+				set: func(c *api.AuthConfig, v string) { c.Service = v }},
+		}
+
+	case api.AuthTypeMutualTLS:
+		return []authField{
+			{key: "certFile", label: "Client Certificate (PEM file)", placeholder: "/path/to/client.pem",
+				get: func(c *api.AuthConfig) string { return c.CertFile },
+				set: func(c *api.AuthConfig, v string) { c.CertFile = v }},
+			{key: "keyFile", label: "Client Key (PEM file)", placeholder: "Defaults to the certificate file", optional: true,
+				get: func(c *api.AuthConfig) string { return c.KeyFile },
+				set: func(c *api.AuthConfig, v string) { c.KeyFile = v }},
+		}
+
+	case api.AuthTypeHMAC:
+		return []authField{
+			{key: "signingSecret", label: "Secret", placeholder: "Shared secret",
+				get: func(c *api.AuthConfig) string { return c.SigningSecret },
+				set: func(c *api.AuthConfig, v string) { c.SigningSecret = v }},
+			{key: "signingTemplate", label: "Canonical String", placeholder: api.DefaultSigningTemplate,
+				get: func(c *api.AuthConfig) string { return c.SigningTemplate },
+				set: func(c *api.AuthConfig, v string) { c.SigningTemplate = v }},
+			{key: "signingAlgorithm", label: "Algorithm (sha256, sha512, sha1, md5)", placeholder: api.DefaultSigningAlgorithm,
+				get: func(c *api.AuthConfig) string { return c.SigningAlgorithm },
+				set: func(c *api.AuthConfig, v string) { c.SigningAlgorithm = v }},
+			{key: "signingEncoding", label: "Encoding (hex, base64, base64url)", placeholder: api.DefaultSigningEncoding,
+				get: func(c *api.AuthConfig) string { return c.SigningEncoding },
+				set: func(c *api.AuthConfig, v string) { c.SigningEncoding = v }},
+			{key: "signingHeaders", label: "Headers", placeholder: api.DefaultSigningHeaders,
+				get: func(c *api.AuthConfig) string { return c.SigningHeaders },
+				set: func(c *api.AuthConfig, v string) { c.SigningHeaders = v }},
+			{key: "signingTimestamp", label: "Timestamp (unix, unix_ms, rfc3339, rfc1123)", placeholder: api.DefaultSigningTimestamp,
+				get: func(c *api.AuthConfig) string { return c.SigningTimestamp },
+				set: func(c *api.AuthConfig, v string) { c.SigningTimestamp = v }},
+		}
+	}
+	return nil
+}
+
+func (m *Model) selectedSchemeName() string {
+	if m.selectedScheme >= len(m.authSchemes) {
+		return ""
+	}
+	return m.authSchemes[m.selectedScheme]
+}
+
+// schemeCredentials returns the pending or saved credentials for a scheme
+func (m *Model) schemeCredentials(name string) *api.AuthConfig {
+	if draft, ok := m.authDrafts[name]; ok {
+		return draft
+	}
+	return m.authMgr.GetAuth(name)
+}
+
+func (m *Model) initAuthInputs() {
+	m.authInputs = make(map[string]*InputField)
+	m.authOrder = nil
+	m.focusedInput = 0
+
+	schemeName := m.selectedSchemeName()
+	if schemeName == "" {
+		return
+	}
+
+	config, err := m.authMgr.ParseAuthScheme(schemeName)
+	if err != nil {
+		return
+	}
+	// Prefill from pending or saved credentials, else from defaults found in the spec
+	current := m.schemeCredentials(schemeName)
+	if current == nil {
+		current = config
+	}
+
+	for i, f := range authFieldsFor(config) {
+		field := NewInputField(f.label, f.placeholder, !f.optional)
+		field.SetValue(f.get(current))
+		if i == 0 {
+			field.Focus()
+		}
+		m.authInputs[f.key] = &field
+		m.authOrder = append(m.authOrder, f.key)
+	}
+}
+
+// stashAuthInputs keeps the form values of the selected scheme until the form is saved
+func (m *Model) stashAuthInputs() {
+	schemeName := m.selectedSchemeName()
+	if schemeName == "" {
+		return
+	}
+
+	config, err := m.authMgr.ParseAuthScheme(schemeName)
+	if err != nil {
+		return
+	}
+
+	// Defaults prefilled from the spec alone do not count as configured
+	defaults := *config
+	empty := true
+	for _, f := range authFieldsFor(config) {
+		if input, ok := m.authInputs[f.key]; ok {
+			f.set(config, input.Value())
+			if input.Value() != "" && input.Value() != f.get(&defaults) {
+				empty = false
+			}
+		}
+	}
+
+	if empty {
+		config = nil
+	} else {
+		// Typed in by the user, so env: and exec: references are honoured
+		config.SecretRefs = true
+	}
+	m.authDrafts[schemeName] = config
+}
+
+// clearAuthInputs empties the form of the selected scheme
+func (m *Model) clearAuthInputs() {
+	for _, input := range m.authInputs {
+		input.SetValue("")
+	}
+}
+
+// applyAuth saves the credentials of every scheme edited in the form and, when
+// remembering is enabled, persists them. It reports whether the form can close.
+func (m *Model) applyAuth() bool {
+	m.stashAuthInputs()
+	for name, config := range m.authDrafts {
+		m.authMgr.SetAuth(name, config)
+	}
+	m.authDrafts = make(map[string]*api.AuthConfig)
+	// Connections made with a previous client certificate must not be reused
+	m.client.CloseIdleConnections()
+
+	if m.credStore == nil {
+		return true
+	}
+
+	specKey := api.SpecKey(m.spec)
+	if !m.rememberAuth {
+		if m.credStore.Has(specKey, m.env) {
+			if err := m.credStore.Delete(specKey, m.env); err != nil {
+				m.authStatus = errorStyle.Render("Error: ") + err.Error()
+				return false
+			}
+		}
+		return true
+	}
+
+	if err := m.authMgr.SaveCredentials(m.credStore, m.env, m.passphrase.Value()); err != nil {
+		m.authStatus = errorStyle.Render("Error: ") + err.Error()
+		return false
+	}
+	return true
+}
+
+// initCredentialStore opens the on-disk credential store and unlocks it when
+// the passphrase is provided through the environment
+func (m *Model) initCredentialStore() {
+	m.passphrase = NewInputField("Passphrase", "Encrypts stored credentials", true)
+	m.passphrase.Input.EchoMode = textinput.EchoPassword
+
+	path, err := api.DefaultCredentialStorePath()
+	if err != nil {
+		return
+	}
+	m.credStore = api.NewCredentialStore(path)
+
+	if !m.credStore.Has(api.SpecKey(m.spec), m.env) {
+		return
+	}
+	m.rememberAuth = true
+
+	if passphrase := os.Getenv(api.PassphraseEnvVar); passphrase != "" {
+		m.passphrase.SetValue(passphrase)
+		if err := m.authMgr.LoadCredentials(m.credStore, m.env, passphrase); err != nil {
+			m.unlockErr = errorStyle.Render("Error: ") + fmt.Sprintf("%s: %v", api.PassphraseEnvVar, err)
+			m.authStatus = m.unlockErr
+		}
+	}
+}
+
+func (m *Model) toggleRememberAuth() {
+	if m.credStore == nil {
+		m.authStatus = errorStyle.Render("Credential store unavailable")
+		return
+	}
+	m.rememberAuth = !m.rememberAuth
+	if !m.rememberAuth {
+		m.passphrase.Blur()
+		if items := m.focusables(); m.focusedInput >= len(items) {
+			m.focusedInput = 0
+			if len(items) > 0 {
+				items[0].Focus()
+			}
+		}
+	}
+}
+
+// loadStoredCredentials unlocks the credential store with the entered passphrase
+func (m *Model) loadStoredCredentials() {
+	if m.credStore == nil {
+		m.authStatus = errorStyle.Render("Credential store unavailable")
+		return
+	}
+	if err := m.authMgr.LoadCredentials(m.credStore, m.env, m.passphrase.Value()); err != nil {
+		m.authStatus = errorStyle.Render("Error: ") + err.Error()
+		return
+	}
+
+	m.authDrafts = make(map[string]*api.AuthConfig)
+	m.initAuthInputs()
+	m.unlockErr = ""
+	m.authStatus = successStyle.Render("Loaded stored credentials")
+}
+
+func (m Model) authView() string {
+	var b strings.Builder
+
+	b.WriteString(titleStyle.Render("Configure Authentication"))
+	b.WriteString("\n\n")
+
+	b.WriteString(headerStyle.Render("Security Schemes"))
+	b.WriteString("\n\n")
+
+	// The custom signing scheme is always listed last
+	if len(m.authSchemes) == 1 {
+		b.WriteString(infoStyle.Render("  The spec defines no security schemes"))
+		b.WriteString("\n")
+	}
+
+	for i, scheme := range m.authSchemes {
+		status := "  "
+		if m.schemeCredentials(scheme) != nil {
+			status = successStyle.Render("✓ ")
+		}
+
+		label := scheme
+		if config, err := m.authMgr.ParseAuthScheme(scheme); err == nil && config.Type != "" {
+			label += infoStyle.Render(fmt.Sprintf("  (%s)", config.Type))
+		}
+
+		if i == m.selectedScheme {
+			b.WriteString(selectedStyle.Render("► ") + status + label)
+		} else {
+			b.WriteString("  " + status + label)
+		}
+		b.WriteString("\n")
+	}
+
+	b.WriteString("\n")
+
+	if len(m.authInputs) > 0 {
+		b.WriteString(headerStyle.Render("Configuration"))
+		b.WriteString("\n\n")
+
+		for _, input := range orderedFields(m.authOrder, m.authInputs) {
+			b.WriteString(input.View())
+			b.WriteString("\n")
+		}
+	}
+
+	if m.credStore != nil {
+		b.WriteString("\n")
+		b.WriteString(headerStyle.Render("Storage"))
+		b.WriteString("\n")
+		if m.rememberAuth {
+			b.WriteString(fmt.Sprintf("[x] Remember credentials for environment %q\n", m.env))
+			b.WriteString(m.passphrase.View())
+			b.WriteString("\n")
+		} else {
+			b.WriteString(fmt.Sprintf("[ ] Remember credentials for environment %q\n", m.env))
+		}
+		b.WriteString(infoStyle.Render("Values may reference secrets instead: env:VAR or exec:command"))
+		b.WriteString("\n")
+	}
+
+	if m.selectedSchemeName() == api.CustomSigningScheme {
+		b.WriteString("\n")
+		b.WriteString(infoStyle.Render("Placeholders: {method} {path} {query} {uri} {host} {content_type} {timestamp} {nonce}\n" +
+			"{body} {body_sha256} {body_md5} {header:Name}; headers may also use {signature}"))
+		b.WriteString("\n")
+	}
+
+	if m.authStatus != "" {
+		b.WriteString("\n")
+		b.WriteString(m.authStatus)
+	}
+
+	b.WriteString(helpStyle.Render("\n\n↑/↓: select scheme • tab: next field • ctrl+d: clear scheme • ctrl+r: remember • ctrl+l: load stored • ctrl+s: save all • esc: cancel"))
+
+	return b.String()
+}
+
+// securityView describes the selected operation's security requirements and
+// whether the configured credentials satisfy them
+func (m Model) securityView() string {
+	alternatives, declared := m.authMgr.Requirements(m.selected.Security)
+	if !declared {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString(headerStyle.Render("Security"))
+	b.WriteString("\n")
+
+	if len(alternatives) == 0 {
+		b.WriteString("  Public endpoint, no authentication\n\n")
+		return b.String()
+	}
+
+	for i, alternative := range alternatives {
+		if i > 0 {
+			b.WriteString(infoStyle.Render("  or"))
+			b.WriteString("\n")
+		}
+
+		if len(alternative) == 0 {
+			b.WriteString("  • anonymous access\n")
+			continue
+		}
+
+		parts := make([]string, len(alternative))
+		for j, name := range alternative {
+			if m.authMgr.IsConfigured(name) {
+				parts[j] = successStyle.Render("✓ ") + name
+			} else {
+				parts[j] = errorStyle.Render("✗ ") + name
+			}
+		}
+
+		line := "  • " + strings.Join(parts, " + ")
+		if m.authMgr.Satisfied(alternative) {
+			line += infoStyle.Render("  (satisfied)")
+		}
+		b.WriteString(line)
+		b.WriteString("\n")
+	}
+	b.WriteString("\n")
+
+	return b.String()
+}
+
+// renderSignatures shows the strings signed by request signing schemes
+func renderSignatures(signatures []api.Signature) string {
+	if len(signatures) == 0 {
+		return infoStyle.Render("  No request signing configured")
+	}
+
+	var b strings.Builder
+	for _, sig := range signatures {
+		b.WriteString(headerStyle.Render(sig.Scheme))
+		b.WriteString("\n")
+		b.WriteString(codeStyle.Render(sig.Canonical))
+		b.WriteString("\n\n")
+	}
+	return b.String()
+}
@@ -0,0 +1,309 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/doganarif/ApiMug/internal/bench"
+)
+
+// benchRefreshInterval is how often the results of a running benchmark are redrawn
+const benchRefreshInterval = 250 * time.Millisecond
+
+// benchTickMsg redraws the results of the benchmark recorded by rec
+type benchTickMsg struct {
+	rec *bench.Recorder
+}
+
+type benchDoneMsg struct {
+	rec *bench.Recorder
+}
+
+// startBenchmarkForm opens the benchmark view for the request in the form
+func (m *Model) startBenchmarkForm() {
+	req, err := m.buildRequest()
+	if err != nil {
+		m.statusMsg = errorStyle.Render("Error: ") + err.Error()
+		return
+	}
+
+	m.benchRequest = req
+	m.benchResult = nil
+	m.statusMsg = ""
+	m.mode = viewBenchmark
+	m.focusedInput = 0
+	if m.benchInputs == nil {
+		m.benchInputs = make(map[string]*InputField)
+		m.benchOrder = nil
+		for _, f := range []struct{ name, label, value string }{
+			{"concurrency", "Concurrency", "10"},
+			{"rate", "Rate (requests per second, 0 = no limit)", "0"},
+			{"duration", "Duration (e.g. 10s, 0 = until the request count)", "10s"},
+			{"requests", "Requests (0 = until the duration is over)", "0"},
+		} {
+			field := NewInputField(f.label, f.value, false)
+			field.SetValue(f.value)
+			m.benchInputs[f.name] = &field
+			m.benchOrder = append(m.benchOrder, f.name)
+		}
+	}
+	for _, field := range m.benchInputs {
+		field.Blur()
+	}
+	m.benchInputs[m.benchOrder[0]].Focus()
+}
+
+// benchOptions parses the benchmark form
+func (m *Model) benchOptions() (bench.Options, error) {
+	var opts bench.Options
+	value := func(name string) string {
+		return strings.TrimSpace(m.benchInputs[name].Value())
+	}
+
+	n, err := strconv.Atoi(value("concurrency"))
+	if err != nil || n < 1 {
+		return opts, fmt.Errorf("invalid concurrency %q: expected a positive number", value("concurrency"))
+	}
+	opts.Concurrency = n
+
+	if v := value("rate"); v != "" {
+		rate, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return opts, fmt.Errorf("invalid rate %q: expected requests per second", v)
+		}
+		if err := bench.CheckRate(rate); err != nil {
+			return opts, err
+		}
+		opts.Rate = rate
+	}
+
+	if v := value("duration"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil {
+			// Plain numbers are seconds, as for timeouts
+			if d, err = parseSeconds(v); err != nil {
+				return opts, fmt.Errorf("invalid duration %q", v)
+			}
+		}
+		opts.Duration = d
+	}
+
+	if v := value("requests"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			return opts, fmt.Errorf("invalid request count %q", v)
+		}
+		opts.Requests = n
+	}
+
+	if opts.Duration <= 0 && opts.Requests <= 0 {
+		return opts, fmt.Errorf("set a duration or a request count")
+	}
+	return opts, nil
+}
+
+// startBenchmark replays the request in the background, redrawing the results as they come in
+func (m *Model) startBenchmark() tea.Cmd {
+	opts, err := m.benchOptions()
+	if err != nil {
+		m.statusMsg = errorStyle.Render("Error: ") + err.Error()
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	rec := bench.NewRecorder()
+	m.benchRecorder = rec
+	m.benchCancel = cancel
+	m.benchResult = rec.Result()
+	m.statusMsg = ""
+
+	client, req := bench.NewClient(m.client, opts), m.benchRequest
+	return tea.Batch(func() tea.Msg {
+		bench.Run(ctx, client, req, opts, rec)
+		client.CloseIdleConnections()
+		return benchDoneMsg{rec: rec}
+	}, benchTick(rec))
+}
+
+func benchTick(rec *bench.Recorder) tea.Cmd {
+	return tea.Tick(benchRefreshInterval, func(time.Time) tea.Msg {
+		return benchTickMsg{rec: rec}
+	})
+}
+
+// benchRunning reports whether a benchmark is in progress
+func (m *Model) benchRunning() bool {
+	return m.benchCancel != nil
+}
+
+// stopBenchmark cancels the running benchmark, its results are kept
+func (m *Model) stopBenchmark() {
+	if m.benchCancel != nil {
+		m.benchCancel()
+	}
+}
+
+// handleBenchMsg updates the benchmark results, reporting whether msg belonged to it
+func (m *Model) handleBenchMsg(msg tea.Msg) (tea.Cmd, bool) {
+	switch msg := msg.(type) {
+	case benchTickMsg:
+		if msg.rec != m.benchRecorder || !m.benchRunning() {
+			return nil, true
+		}
+		m.benchResult = msg.rec.Result()
+		return benchTick(msg.rec), true
+
+	case benchDoneMsg:
+		if msg.rec != m.benchRecorder {
+			return nil, true
+		}
+		m.stopBenchmark()
+		m.benchCancel = nil
+		m.benchResult = msg.rec.Result()
+		return nil, true
+	}
+	return nil, false
+}
+
+func (m Model) handleBenchKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if m.benchRunning() {
+		switch msg.String() {
+		case "esc", "ctrl+c":
+			m.stopBenchmark()
+		}
+		return m, nil
+	}
+
+	switch msg.String() {
+	case "esc":
+		m.mode = viewRequest
+		m.focusedInput = 0
+		if items := m.focusables(); len(items) > 0 {
+			for _, item := range items {
+				item.Blur()
+			}
+			items[0].Focus()
+		}
+		return m, nil
+	case "ctrl+s":
+		cmd := m.startBenchmark()
+		return m, cmd
+	case "tab", "shift+tab":
+		m.cycleFocus(msg.String() == "shift+tab")
+		return m, nil
+	}
+	return m.updateCurrentView(msg)
+}
+
+func (m Model) benchView() string {
+	var b strings.Builder
+
+	b.WriteString(titleStyle.Render("Benchmark"))
+	b.WriteString("\n\n")
+
+	style := getMethodStyle(m.benchRequest.Method)
+	b.WriteString(fmt.Sprintf("%s %s\n\n", style.Render(m.benchRequest.Method), m.client.URL(m.benchRequest)))
+
+	if !m.benchRunning() {
+		for _, input := range orderedFields(m.benchOrder, m.benchInputs) {
+			b.WriteString(input.View())
+			b.WriteString("\n")
+		}
+		b.WriteString("\n")
+	}
+
+	if r := m.benchResult; r != nil {
+		b.WriteString(renderBenchResult(r, m.width))
+	}
+
+	if m.statusMsg != "" {
+		b.WriteString("\n")
+		b.WriteString(m.statusMsg)
+		b.WriteString("\n")
+	}
+
+	if m.benchRunning() {
+		b.WriteString(helpStyle.Render("\nesc/ctrl+c: stop"))
+	} else {
+		b.WriteString(helpStyle.Render("\ntab: next field • ctrl+s: start • esc: back"))
+	}
+	return b.String()
+}
+
+// renderBenchResult renders the summary, latency histogram, statuses and errors
+func renderBenchResult(r *bench.Result, width int) string {
+	var b strings.Builder
+
+	state := "Running"
+	if r.Done {
+		state = "Finished"
+	}
+	b.WriteString(headerStyle.Render(fmt.Sprintf("%s: %s", state, formatDuration(r.Elapsed))))
+	b.WriteString("\n\n")
+
+	b.WriteString(fmt.Sprintf("  %-12s %d", "Requests", r.Requests))
+	if r.Errors > 0 {
+		b.WriteString(errorStyle.Render(fmt.Sprintf("  (%d errors)", r.Errors)))
+	}
+	b.WriteString("\n")
+	b.WriteString(fmt.Sprintf("  %-12s %.1f req/s\n", "Throughput", r.Throughput))
+
+	if len(r.Histogram) > 0 {
+		b.WriteString(fmt.Sprintf("  %-12s p50 %s • p90 %s • p99 %s\n", "Latency",
+			formatDuration(r.P50), formatDuration(r.P90), formatDuration(r.P99)))
+		b.WriteString(infoStyle.Render(fmt.Sprintf("  %-12s min %s • mean %s • max %s", "",
+			formatDuration(r.Min), formatDuration(r.Mean), formatDuration(r.Max))))
+		b.WriteString("\n\n")
+
+		barWidth := chartWidth(width, 30)
+		most := 0
+		for _, bucket := range r.Histogram {
+			most = max(most, bucket.Count)
+		}
+		for _, bucket := range r.Histogram {
+			length := bucket.Count * barWidth / max(most, 1)
+			if length == 0 && bucket.Count > 0 {
+				length = 1
+			}
+			b.WriteString(fmt.Sprintf("  %10s │%s %d\n", formatDuration(bucket.From),
+				timingBarStyle.Render(strings.Repeat("█", length)), bucket.Count))
+		}
+	}
+
+	if len(r.Statuses) > 0 {
+		codes := make([]int, 0, len(r.Statuses))
+		for code := range r.Statuses {
+			codes = append(codes, code)
+		}
+		sort.Ints(codes)
+		parts := make([]string, len(codes))
+		for i, code := range codes {
+			style := statusCodeSuccessStyle
+			if code >= 400 {
+				style = statusCodeErrorStyle
+			}
+			parts[i] = style.Render(strconv.Itoa(code)) + fmt.Sprintf(": %d", r.Statuses[code])
+		}
+		b.WriteString(fmt.Sprintf("\n  %-12s %s\n", "Statuses", strings.Join(parts, "  ")))
+	}
+
+	if len(r.ErrorKinds) > 0 {
+		messages := make([]string, 0, len(r.ErrorKinds))
+		for msg := range r.ErrorKinds {
+			messages = append(messages, msg)
+		}
+		sort.Strings(messages)
+		b.WriteString("\n")
+		for _, msg := range messages {
+			b.WriteString(errorStyle.Render(fmt.Sprintf("  %d × ", r.ErrorKinds[msg])))
+			b.WriteString(msg)
+			b.WriteString("\n")
+		}
+	}
+	return b.String()
+}
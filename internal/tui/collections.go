@@ -0,0 +1,223 @@
+package tui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/doganarif/ApiMug/internal/api"
+	"github.com/doganarif/ApiMug/internal/collection"
+)
+
+// savedRow is a saved request as listed in the collections view
+type savedRow struct {
+	collection *collection.Collection
+	request    *collection.Request
+}
+
+func (m *Model) savedRows() []savedRow {
+	var rows []savedRow
+	if m.collections == nil {
+		return rows
+	}
+	for _, c := range m.collections.Collections {
+		for _, r := range c.Requests {
+			rows = append(rows, savedRow{collection: c, request: r})
+		}
+	}
+	return rows
+}
+
+func (m Model) handleCollectionsKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	rows := m.savedRows()
+
+	switch msg.String() {
+	case "esc":
+		m.mode = viewList
+		return m, nil
+	case "up", "k":
+		if m.savedCursor > 0 {
+			m.savedCursor--
+		}
+		return m, nil
+	case "down", "j":
+		if m.savedCursor < len(rows)-1 {
+			m.savedCursor++
+		}
+		return m, nil
+	case "enter", "ctrl+s":
+		if m.savedCursor >= len(rows) {
+			return m, nil
+		}
+		row := rows[m.savedCursor]
+		if err := m.openSaved(row.collection, row.request); err != nil {
+			m.statusMsg = errorStyle.Render("Error: ") + err.Error()
+			return m, nil
+		}
+		if msg.String() == "ctrl+s" {
+			if m.selected.WebSocket {
+				return m, m.startWebSocket()
+			}
+			return m, m.startRequest()
+		}
+		return m, nil
+	case "q", "ctrl+c":
+		return m, tea.Quit
+	}
+	return m, nil
+}
+
+// openSaved fills the request form with a saved request
+func (m *Model) openSaved(c *collection.Collection, r *collection.Request) error {
+	endpoint, err := r.Endpoint(m.spec.GetEndpoints())
+	if err != nil {
+		return err
+	}
+
+	m.selected = endpoint
+	m.mode = viewRequest
+	m.statusMsg = ""
+	m.initRequestInputs()
+	for name, value := range r.Params {
+		if input, ok := m.paramInputs[name]; ok {
+			input.SetValue(value)
+		}
+	}
+	m.bodyInput.SetValue(r.Body)
+
+	m.savedRequest = r
+	m.savedRef = c.Name + "/" + r.Name
+	return nil
+}
+
+// startSaveRequest prompts for the collection and name to save the form as
+func (m *Model) startSaveRequest() {
+	field := NewInputField("Save as (collection/name)", "collection/name", true)
+	if m.savedRequest != nil && m.selected.Matches(m.savedRequest.Operation) {
+		field.SetValue(m.savedRef)
+	} else {
+		field.SetValue("requests/" + strings.ReplaceAll(strings.ToLower(m.selected.Ref()), " ", ""))
+	}
+	field.Focus()
+	m.saveRequestInput = &field
+	m.statusMsg = ""
+}
+
+// saveRequest saves the request form to the collections file
+func (m *Model) saveRequest() {
+	ref := strings.TrimSpace(m.saveRequestInput.Value())
+	name, reqName, ok := strings.Cut(ref, "/")
+	if !ok || name == "" || reqName == "" {
+		m.statusMsg = errorStyle.Render("Error: ") + "expected collection/name"
+		return
+	}
+	m.saveRequestInput = nil
+
+	saved := &collection.Request{
+		Name:      reqName,
+		Operation: m.selected.Ref(),
+		Params:    m.paramValues(),
+	}
+	if m.selected.HasBody {
+		saved.Body = m.bodyInput.Value()
+	}
+	if m.savedRequest != nil && m.selected.Matches(m.savedRequest.Operation) {
+		saved.Headers = m.savedRequest.Headers
+		saved.Auth = m.savedRequest.Auth
+	}
+
+	m.collections.Put(name, saved)
+	if err := m.collections.Save(); err != nil {
+		m.statusMsg = errorStyle.Render("Save failed: ") + err.Error()
+		return
+	}
+	m.savedRequest = saved
+	m.savedRef = name + "/" + reqName
+	m.statusMsg = successStyle.Render("Saved ") + fmt.Sprintf("%s to %s", m.savedRef, m.collections.Path())
+}
+
+func (m Model) collectionsView() string {
+	var b strings.Builder
+
+	b.WriteString(titleStyle.Render("Collections"))
+	b.WriteString("\n\n")
+	b.WriteString(infoStyle.Render(m.collections.Path()))
+	b.WriteString("\n\n")
+
+	rows := m.savedRows()
+	if len(rows) == 0 {
+		b.WriteString(infoStyle.Render("No saved requests. Press ctrl+w in the request form to save one."))
+		b.WriteString(helpStyle.Render("\n\nesc: back • q: quit"))
+		return b.String()
+	}
+
+	endpoints := m.spec.GetEndpoints()
+	var current *collection.Collection
+	for i, row := range rows {
+		if row.collection != current {
+			if current != nil {
+				b.WriteString("\n")
+			}
+			current = row.collection
+			b.WriteString(headerStyle.Render(current.Name))
+			b.WriteString("\n")
+		}
+
+		cursor := "  "
+		name := row.request.Name
+		if i == m.savedCursor {
+			cursor = "> "
+			name = selectedStyle.Render(name)
+		}
+		b.WriteString(cursor + name + "  ")
+
+		if endpoint, err := row.request.Endpoint(endpoints); err != nil {
+			b.WriteString(errorStyle.Render(fmt.Sprintf("%s (not in spec)", row.request.Operation)))
+		} else {
+			b.WriteString(fmt.Sprintf("%s %s", getMethodStyle(endpoint.Method).Render(strings.ToUpper(endpoint.Method)), endpoint.Path))
+		}
+		b.WriteString("\n")
+	}
+
+	if m.statusMsg != "" {
+		b.WriteString("\n")
+		b.WriteString(m.statusMsg)
+	}
+
+	b.WriteString(helpStyle.Render("\n↑/↓: select • enter: open • ctrl+s: send • esc: back • q: quit"))
+	return b.String()
+}
+
+// savedRequestView summarises the saved request loaded in the form
+func (m Model) savedRequestView() string {
+	if m.savedRequest == nil || !m.selected.Matches(m.savedRequest.Operation) {
+		return ""
+	}
+
+	var b strings.Builder
+	b.WriteString(infoStyle.Render("Saved request: " + m.savedRef))
+	b.WriteString("\n")
+
+	names := make([]string, 0, len(m.savedRequest.Headers))
+	for name := range m.savedRequest.Headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		b.WriteString(infoStyle.Render(fmt.Sprintf("  %s: %s", name, m.savedRequest.Headers[name])))
+		b.WriteString("\n")
+	}
+	if m.savedRequest.Auth != "" {
+		b.WriteString(infoStyle.Render("  Auth: " + m.savedRequest.Auth))
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// applySavedRequest adds the loaded saved request's headers and auth override
+func (m *Model) applySavedRequest(req *api.Request) {
+	if m.savedRequest != nil && m.selected.Matches(m.savedRequest.Operation) {
+		m.savedRequest.Apply(req)
+	}
+}
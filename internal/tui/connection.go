@@ -0,0 +1,105 @@
+package tui
+
+import (
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	"github.com/doganarif/ApiMug/internal/api"
+)
+
+// initTLSInputs adds the TLS options to the settings form
+func (m *Model) initTLSInputs() {
+	current := m.client.TLSConfig()
+
+	insecure := "n"
+	if current.InsecureSkipVerify {
+		insecure = "y"
+	}
+
+	fields := []struct {
+		key, label, placeholder, value string
+	}{
+		{"tls:cert", "Client Certificate (PEM file)", "/path/to/client.pem", current.CertFile},
+		{"tls:key", "Client Key (PEM file)", "Defaults to the certificate file", current.KeyFile},
+		{"tls:ca", "CA Bundle (PEM file)", "Trusted in addition to system roots", current.CAFile},
+		{"tls:serverName", "TLS Server Name", "Defaults to the URL host", current.ServerName},
+		{"tls:insecure", "Skip Certificate Verification (y/n)", "n", insecure},
+		{"tls:minVersion", "Minimum TLS Version (1.0-1.3)", "1.2", current.MinVersion},
+	}
+
+	for _, f := range fields {
+		field := NewInputField(f.label, f.placeholder, false)
+		field.SetValue(f.value)
+		m.settingsInputs[f.key] = &field
+		m.settingsOrder = append(m.settingsOrder, f.key)
+	}
+}
+
+// settingsTLSConfig reads the TLS options from the settings form
+func (m *Model) settingsTLSConfig() api.TLSConfig {
+	value := func(key string) string {
+		if input, ok := m.settingsInputs[key]; ok {
+			return strings.TrimSpace(input.Value())
+		}
+		return ""
+	}
+
+	insecure := strings.ToLower(value("tls:insecure"))
+	return api.TLSConfig{
+		CertFile:           value("tls:cert"),
+		KeyFile:            value("tls:key"),
+		CAFile:             value("tls:ca"),
+		ServerName:         value("tls:serverName"),
+		InsecureSkipVerify: insecure == "y" || insecure == "yes" || insecure == "true",
+		MinVersion:         value("tls:minVersion"),
+	}
+}
+
+// initProxyInputs adds the proxy settings to the settings form
+func (m *Model) initProxyInputs() {
+	current := m.client.ProxyConfig()
+
+	fields := []struct {
+		key, label, placeholder, value string
+	}{
+		{"proxy:url", "Proxy URL (http, https, socks5)", "From HTTP_PROXY / HTTPS_PROXY", current.URL},
+		{"proxy:username", "Proxy Username", "Optional", current.Username},
+		{"proxy:password", "Proxy Password", "Optional, may be env:VAR or exec:command", current.Password},
+		{"proxy:noProxy", "No Proxy (hosts, .domains, CIDRs, *)", "From NO_PROXY", current.NoProxy},
+	}
+
+	for _, f := range fields {
+		field := NewInputField(f.label, f.placeholder, false)
+		field.SetValue(f.value)
+		if f.key == "proxy:password" {
+			field.Input.EchoMode = textinput.EchoPassword
+		}
+		m.settingsInputs[f.key] = &field
+		m.settingsOrder = append(m.settingsOrder, f.key)
+	}
+}
+
+// settingsProxyConfig reads the proxy settings from the settings form
+func (m *Model) settingsProxyConfig() api.ProxyConfig {
+	value := func(key string) string {
+		if input, ok := m.settingsInputs[key]; ok {
+			return strings.TrimSpace(input.Value())
+		}
+		return ""
+	}
+
+	return api.ProxyConfig{
+		URL:      value("proxy:url"),
+		Username: value("proxy:username"),
+		Password: value("proxy:password"),
+		NoProxy:  value("proxy:noProxy"),
+	}
+}
+
+// tlsWarning is shown while certificate verification is disabled
+func (m Model) tlsWarning() string {
+	if !m.client.TLSConfig().InsecureSkipVerify {
+		return ""
+	}
+	return errorStyle.Render("⚠ TLS certificate verification is disabled")
+}
@@ -0,0 +1,124 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/doganarif/ApiMug/internal/api"
+	"github.com/doganarif/ApiMug/pkg/spec"
+)
+
+// endpointAt returns the endpoint a JSON pointer into the spec is in, or nil
+func (m *Model) endpointAt(location string) *api.Endpoint {
+	tokens := spec.SplitPointer(location)
+	if len(tokens) < 3 || tokens[0] != "paths" {
+		return nil
+	}
+	endpoints := m.spec.GetEndpoints()
+	for i := range endpoints {
+		if endpoints[i].Path == tokens[1] && strings.EqualFold(endpoints[i].Method, tokens[2]) {
+			return &endpoints[i]
+		}
+	}
+	return nil
+}
+
+func (m Model) handleDiagnosticsKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	diags := m.spec.Warnings
+
+	switch msg.String() {
+	case "esc":
+		m.mode = viewList
+		return m, nil
+	case "up", "k":
+		if m.diagCursor > 0 {
+			m.diagCursor--
+		}
+		return m, nil
+	case "down", "j":
+		if m.diagCursor < len(diags)-1 {
+			m.diagCursor++
+		}
+		return m, nil
+	case "enter":
+		if m.diagCursor >= len(diags) {
+			return m, nil
+		}
+		if endpoint := m.endpointAt(diags[m.diagCursor].Location); endpoint != nil {
+			m.selected = endpoint
+			m.savedRequest = nil
+			m.mode = viewDetail
+		}
+		return m, nil
+	case "q", "ctrl+c":
+		return m, tea.Quit
+	}
+	return m, nil
+}
+
+// diagnosticsWarning is shown in the endpoint list while the spec has
+// validation problems
+func (m Model) diagnosticsWarning() string {
+	if len(m.spec.Warnings) == 0 {
+		return ""
+	}
+	return warningStyle.Render(fmt.Sprintf("⚠ %d spec problem(s), press ! to review", len(m.spec.Warnings)))
+}
+
+func (m Model) diagnosticsView() string {
+	var b strings.Builder
+
+	b.WriteString(titleStyle.Render("Diagnostics"))
+	b.WriteString("\n\n")
+
+	diags := m.spec.Warnings
+	if len(diags) == 0 {
+		b.WriteString(successStyle.Render("The spec passed validation"))
+		b.WriteString(helpStyle.Render("\n\nesc: back • q: quit"))
+		return b.String()
+	}
+
+	b.WriteString(infoStyle.Render(fmt.Sprintf("%s failed validation in %d place(s). It was opened anyway; operations with problems may not work as documented.", m.spec.Source, len(diags))))
+	b.WriteString("\n\n")
+
+	wrap := lipgloss.NewStyle().PaddingLeft(4)
+	if m.width > 10 {
+		wrap = wrap.Width(m.width - 2)
+	}
+
+	// Each entry takes a few lines, keep the cursor in view
+	visible := (m.height - 10) / 3
+	if visible < 3 {
+		visible = 3
+	}
+	start := 0
+	if m.diagCursor >= visible {
+		start = m.diagCursor - visible + 1
+	}
+	end := min(start+visible, len(diags))
+
+	for i := start; i < end; i++ {
+		d := diags[i]
+		cursor := "  "
+		location := d.Location
+		if d.Line > 0 {
+			location = fmt.Sprintf("line %d  %s", d.Line, d.Location)
+		}
+		if i == m.diagCursor {
+			cursor = "> "
+			location = selectedStyle.Render(location)
+		}
+		b.WriteString(cursor + location + "\n")
+		b.WriteString(wrap.Render(d.Message))
+		b.WriteString("\n")
+	}
+	if end < len(diags) {
+		b.WriteString(infoStyle.Render(fmt.Sprintf("  … %d more", len(diags)-end)))
+		b.WriteString("\n")
+	}
+
+	b.WriteString(helpStyle.Render("↑/↓: select • enter: open operation • esc: back • q: quit"))
+	return b.String()
+}
@@ -0,0 +1,220 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/doganarif/ApiMug/internal/diff"
+	"github.com/doganarif/ApiMug/pkg/spec"
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// diffLoadedMsg carries the spec to compare the loaded one with
+type diffLoadedMsg struct {
+	source string
+	doc    *openapi3.T
+	err    error
+}
+
+// openDiff shows the compare view, asking for the other spec the first time
+func (m *Model) openDiff() tea.Cmd {
+	m.mode = viewDiff
+	m.statusMsg = ""
+	if m.diffInput == nil {
+		field := NewInputField("Compare with (file or URL)", "new-spec.yaml", true)
+		m.diffInput = &field
+	}
+	if m.diffReport == nil {
+		return m.diffInput.Focus()
+	}
+	return nil
+}
+
+// loadDiff loads the other spec in the background, without validating it
+func loadDiff(source string) tea.Cmd {
+	return func() tea.Msg {
+		loader := spec.NewLoader().WithoutValidation()
+		load := loader.LoadFromFile
+		if strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://") {
+			load = loader.LoadFromURL
+		}
+		doc, err := load(context.Background(), source)
+		return diffLoadedMsg{source: source, doc: doc, err: err}
+	}
+}
+
+// compare lists the changes between the loaded spec and the other one, in
+// the chosen direction
+func (m *Model) compare() {
+	from, to := m.spec.Doc, m.diffDoc
+	fromName, toName := m.spec.Source, m.diffSource
+	if m.diffReversed {
+		from, to = to, from
+		fromName, toName = toName, fromName
+	}
+	m.diffReport = diff.Compare(from, to)
+	m.diffReport.Old, m.diffReport.New = fromName, toName
+	m.diffCursor = 0
+}
+
+// handleDiffMsg shows the comparison once the other spec is loaded
+func (m *Model) handleDiffMsg(msg tea.Msg) bool {
+	loaded, ok := msg.(diffLoadedMsg)
+	if !ok {
+		return false
+	}
+	m.diffLoading = false
+	if loaded.err != nil {
+		m.statusMsg = errorStyle.Render("Error: ") + loaded.err.Error()
+		return true
+	}
+	m.diffDoc = loaded.doc
+	m.diffSource = loaded.source
+	m.statusMsg = ""
+	m.diffInput.Blur()
+	m.compare()
+	return true
+}
+
+func (m Model) handleDiffKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if m.diffInput.Input.Focused() {
+		switch msg.String() {
+		case "esc":
+			if m.diffReport == nil {
+				m.mode = viewList
+			}
+			m.diffInput.Blur()
+			return m, nil
+		case "enter":
+			source := strings.TrimSpace(m.diffInput.Value())
+			if source == "" || m.diffLoading {
+				return m, nil
+			}
+			m.diffLoading = true
+			m.statusMsg = infoStyle.Render("Loading " + source + "…")
+			return m, loadDiff(source)
+		case "ctrl+c":
+			return m, tea.Quit
+		}
+		cmd := m.diffInput.Update(msg)
+		return m, cmd
+	}
+
+	var changes []diff.Change
+	if m.diffReport != nil {
+		changes = m.diffReport.Changes
+	}
+	switch msg.String() {
+	case "esc":
+		m.mode = viewList
+		return m, nil
+	case "tab":
+		return m, m.diffInput.Focus()
+	case "x":
+		if m.diffDoc != nil {
+			m.diffReversed = !m.diffReversed
+			m.compare()
+		}
+		return m, nil
+	case "up", "k":
+		if m.diffCursor > 0 {
+			m.diffCursor--
+		}
+		return m, nil
+	case "down", "j":
+		if m.diffCursor < len(changes)-1 {
+			m.diffCursor++
+		}
+		return m, nil
+	case "enter":
+		if m.diffCursor >= len(changes) {
+			return m, nil
+		}
+		endpoints := m.spec.GetEndpoints()
+		for i := range endpoints {
+			if endpoints[i].Matches(changes[m.diffCursor].Operation) {
+				m.selected = &endpoints[i]
+				m.savedRequest = nil
+				m.mode = viewDetail
+				return m, nil
+			}
+		}
+		return m, nil
+	case "q", "ctrl+c":
+		return m, tea.Quit
+	}
+	return m, nil
+}
+
+func (m Model) diffView() string {
+	var b strings.Builder
+
+	b.WriteString(titleStyle.Render("Compare"))
+	b.WriteString("\n")
+	b.WriteString(m.diffInput.View())
+	b.WriteString("\n\n")
+
+	if m.statusMsg != "" {
+		b.WriteString(m.statusMsg)
+		b.WriteString("\n\n")
+	}
+
+	r := m.diffReport
+	if r == nil {
+		b.WriteString(helpStyle.Render("enter: compare • esc: back"))
+		return b.String()
+	}
+
+	b.WriteString(infoStyle.Render(fmt.Sprintf("From %s to %s", r.Old, r.New)))
+	b.WriteString("\n")
+	summary := fmt.Sprintf("%d change(s)", len(r.Changes))
+	if n := r.Breaking(); n > 0 {
+		summary += " • " + errorStyle.Render(fmt.Sprintf("%d breaking", n))
+	} else {
+		summary += " • " + successStyle.Render("no breaking changes")
+	}
+	b.WriteString(summary)
+	b.WriteString("\n\n")
+
+	// Keep the cursor in view, leaving room for the header and help
+	visible := m.height - 14
+	if visible < 5 {
+		visible = 5
+	}
+	start := 0
+	if m.diffCursor >= visible {
+		start = m.diffCursor - visible + 1
+	}
+	end := min(start+visible, len(r.Changes))
+
+	operation := ""
+	for i := start; i < end; i++ {
+		c := r.Changes[i]
+		if c.Operation != operation || i == start {
+			operation = c.Operation
+			method, path, _ := strings.Cut(c.Operation, " ")
+			b.WriteString(getMethodStyle(strings.ToLower(method)).Render(method) + " " + path + "\n")
+		}
+
+		cursor := "  "
+		message := c.Message
+		if i == m.diffCursor {
+			cursor = "> "
+			message = selectedStyle.Render(message)
+		}
+		marker := "         "
+		if c.Breaking {
+			marker = errorStyle.Render("BREAKING") + " "
+		}
+		b.WriteString(cursor + marker + message + "\n")
+	}
+	if end < len(r.Changes) {
+		b.WriteString(infoStyle.Render(fmt.Sprintf("  … %d more", len(r.Changes)-end)))
+		b.WriteString("\n")
+	}
+
+	b.WriteString(helpStyle.Render("↑/↓: select • enter: open operation • x: swap sides • tab: compare another spec • esc: back"))
+	return b.String()
+}
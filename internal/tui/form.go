@@ -0,0 +1,101 @@
+package tui
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// focusable is a form element that can receive keyboard focus
+type focusable interface {
+	Focus() tea.Cmd
+	Blur()
+}
+
+// orderedFields returns the fields named in order, skipping missing ones
+func orderedFields(order []string, fields map[string]*InputField) []*InputField {
+	result := make([]*InputField, 0, len(order))
+	for _, name := range order {
+		if field, ok := fields[name]; ok {
+			result = append(result, field)
+		}
+	}
+	return result
+}
+
+// focusables returns the focusable elements of the current view in tab order
+func (m *Model) focusables() []focusable {
+	var items []focusable
+
+	switch m.mode {
+	case viewRequest:
+		for _, field := range orderedFields(m.paramOrder, m.paramInputs) {
+			items = append(items, field)
+		}
+		if m.selected != nil && m.selected.HasBody {
+			items = append(items, &m.bodyInput)
+		}
+		items = append(items, &m.timeoutInput, &m.redirectInput)
+
+	case viewAuth:
+		for _, field := range orderedFields(m.authOrder, m.authInputs) {
+			items = append(items, field)
+		}
+		if m.rememberAuth {
+			items = append(items, &m.passphrase)
+		}
+
+	case viewSettings:
+		for _, field := range orderedFields(m.settingsOrder, m.settingsInputs) {
+			items = append(items, field)
+		}
+
+	case viewBenchmark:
+		for _, field := range orderedFields(m.benchOrder, m.benchInputs) {
+			items = append(items, field)
+		}
+	}
+
+	return items
+}
+
+// cycleFocus moves focus to the next or previous element of the current view
+func (m *Model) cycleFocus(reverse bool) {
+	items := m.focusables()
+	if len(items) == 0 {
+		return
+	}
+
+	if m.focusedInput < len(items) {
+		items[m.focusedInput].Blur()
+	}
+
+	if reverse {
+		m.focusedInput--
+		if m.focusedInput < 0 {
+			m.focusedInput = len(items) - 1
+		}
+	} else {
+		m.focusedInput++
+		if m.focusedInput >= len(items) {
+			m.focusedInput = 0
+		}
+	}
+
+	items[m.focusedInput].Focus()
+}
+
+// updateFocused forwards a message to the focused element of the current view
+func (m *Model) updateFocused(msg tea.Msg) tea.Cmd {
+	items := m.focusables()
+	if m.focusedInput >= len(items) {
+		return nil
+	}
+
+	switch item := items[m.focusedInput].(type) {
+	case *InputField:
+		return item.Update(msg)
+	default:
+		var cmd tea.Cmd
+		m.bodyInput, cmd = m.bodyInput.Update(msg)
+		return cmd
+	}
+}
@@ -0,0 +1,120 @@
+package tui
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/doganarif/ApiMug/internal/api"
+)
+
+// responseLinks returns the links documented for the current response
+func (m *Model) responseLinks() []api.Link {
+	if m.response == nil || m.response.Error != nil || m.selected == nil {
+		return nil
+	}
+	return m.selected.LinksFor(m.response.StatusCode)
+}
+
+// runExtractions applies the loaded saved request's extraction rules to the response
+func (m *Model) runExtractions() {
+	m.extracted = nil
+	if m.savedRequest == nil || !m.selected.Matches(m.savedRequest.Operation) || m.response.Error != nil {
+		return
+	}
+
+	save := make(api.Variables)
+	for _, rule := range m.savedRequest.Extract {
+		value, err := rule.Extract(m.response)
+		if err != nil {
+			m.extracted = append(m.extracted, errorStyle.Render("✗ ")+err.Error())
+			continue
+		}
+		m.variables[rule.Var] = value
+		m.extracted = append(m.extracted, successStyle.Render("✓ ")+fmt.Sprintf("%s = %s", rule.Var, value))
+		if rule.Save {
+			save[rule.Var] = value
+		}
+	}
+
+	if len(save) > 0 {
+		if err := m.environment.SaveVariables(save); err != nil {
+			m.extracted = append(m.extracted, errorStyle.Render("✗ ")+err.Error())
+			return
+		}
+		m.extracted = append(m.extracted, infoStyle.Render(fmt.Sprintf("Saved %d variable(s) to environment %q", len(save), m.env)))
+	}
+}
+
+// followLink opens the linked operation's request form, prefilled from the response
+func (m *Model) followLink(link api.Link) {
+	target, err := link.Target(m.spec.GetEndpoints())
+	if err != nil {
+		m.statusMsg = errorStyle.Render("Error: ") + err.Error()
+		return
+	}
+	params, body, err := link.Resolve(m.lastRequest, m.response)
+	if err != nil {
+		m.statusMsg = errorStyle.Render("Error: ") + err.Error()
+		return
+	}
+
+	m.selected = target
+	m.savedRequest = nil
+	m.mode = viewRequest
+	m.initRequestInputs()
+	for name, value := range params {
+		if input, ok := m.paramInputs[name]; ok {
+			input.SetValue(value)
+		}
+	}
+	if body != "" {
+		m.bodyInput.SetValue(body)
+	}
+	m.statusMsg = successStyle.Render("Prefilled from link ") + link.Name
+}
+
+// renderLinks lists the links of the response with the values they would pass
+func (m Model) renderLinks(links []api.Link) string {
+	if len(links) == 0 {
+		return infoStyle.Render("  No links documented for this response")
+	}
+
+	endpoints := m.spec.GetEndpoints()
+	var b strings.Builder
+	for i, link := range links {
+		b.WriteString(fmt.Sprintf("%d. %s", i+1, selectedStyle.Render(link.Name)))
+		if target, err := link.Target(endpoints); err != nil {
+			b.WriteString("  " + errorStyle.Render(err.Error()))
+		} else {
+			b.WriteString(fmt.Sprintf("  → %s %s", getMethodStyle(target.Method).Render(strings.ToUpper(target.Method)), target.Path))
+		}
+		b.WriteString("\n")
+		if link.Description != "" {
+			b.WriteString(infoStyle.Render("   " + link.Description))
+			b.WriteString("\n")
+		}
+
+		params, body, err := link.Resolve(m.lastRequest, m.response)
+		if err != nil {
+			b.WriteString(errorStyle.Render("   " + err.Error()))
+			b.WriteString("\n\n")
+			continue
+		}
+		names := make([]string, 0, len(params))
+		for name := range params {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			b.WriteString(infoStyle.Render(fmt.Sprintf("   %s = %s", name, params[name])))
+			b.WriteString("\n")
+		}
+		if body != "" {
+			b.WriteString(infoStyle.Render("   body = " + body))
+			b.WriteString("\n")
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
@@ -0,0 +1,141 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/doganarif/ApiMug/internal/lint"
+)
+
+// openLint lints the loaded spec with the config next to it
+func (m *Model) openLint() {
+	m.mode = viewLint
+	m.lintCursor = 0
+	m.statusMsg = ""
+
+	cfg, err := lint.LoadConfig(lint.PathFor(m.spec.Source), false)
+	if err != nil {
+		m.lintReport = nil
+		m.statusMsg = errorStyle.Render("Error: ") + err.Error()
+		return
+	}
+	m.lintReport = lint.Run(m.spec.Doc, cfg)
+	m.lintReport.Spec = m.spec.Source
+}
+
+func (m Model) handleLintKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	var findings []lint.Finding
+	if m.lintReport != nil {
+		findings = m.lintReport.Findings
+	}
+
+	switch msg.String() {
+	case "esc":
+		m.mode = viewList
+		m.statusMsg = ""
+		return m, nil
+	case "up", "k":
+		if m.lintCursor > 0 {
+			m.lintCursor--
+		}
+		return m, nil
+	case "down", "j":
+		if m.lintCursor < len(findings)-1 {
+			m.lintCursor++
+		}
+		return m, nil
+	case "enter":
+		if m.lintCursor >= len(findings) || findings[m.lintCursor].Operation == "" {
+			return m, nil
+		}
+		endpoints := m.spec.GetEndpoints()
+		for i := range endpoints {
+			if endpoints[i].Matches(findings[m.lintCursor].Operation) {
+				m.selected = &endpoints[i]
+				m.savedRequest = nil
+				m.mode = viewDetail
+				return m, nil
+			}
+		}
+		return m, nil
+	case "q", "ctrl+c":
+		return m, tea.Quit
+	}
+	return m, nil
+}
+
+// severityStyle renders a severity
+func severityStyle(s lint.Severity) string {
+	switch s {
+	case lint.Error:
+		return errorStyle.Render("error  ")
+	case lint.Warning:
+		return warningStyle.Render("warning")
+	}
+	return infoStyle.Render("info   ")
+}
+
+func (m Model) lintView() string {
+	var b strings.Builder
+
+	b.WriteString(titleStyle.Render("Lint"))
+	b.WriteString("\n\n")
+
+	if m.lintReport == nil {
+		b.WriteString(m.statusMsg)
+		b.WriteString(helpStyle.Render("\n\nesc: back • q: quit"))
+		return b.String()
+	}
+
+	errors, warnings, infos := m.lintReport.Counts()
+	b.WriteString(fmt.Sprintf("%s • %s • %s",
+		errorStyle.Render(fmt.Sprintf("%d error(s)", errors)),
+		warningStyle.Render(fmt.Sprintf("%d warning(s)", warnings)),
+		infoStyle.Render(fmt.Sprintf("%d info", infos))))
+	b.WriteString("\n")
+	b.WriteString(infoStyle.Render("Rules can be configured in " + lint.PathFor(m.spec.Source)))
+	b.WriteString("\n\n")
+
+	findings := m.lintReport.Findings
+	if len(findings) == 0 {
+		b.WriteString(successStyle.Render("No problems found"))
+		b.WriteString(helpStyle.Render("\n\nesc: back • q: quit"))
+		return b.String()
+	}
+
+	// Keep the cursor in view, leaving room for the header and help
+	visible := m.height - 10
+	if visible < 5 {
+		visible = 5
+	}
+	start := 0
+	if m.lintCursor >= visible {
+		start = m.lintCursor - visible + 1
+	}
+	end := min(start+visible, len(findings))
+
+	for i := start; i < end; i++ {
+		f := findings[i]
+		cursor := "  "
+		rule := fmt.Sprintf("%-21s", f.Rule)
+		if i == m.lintCursor {
+			cursor = "> "
+			rule = selectedStyle.Render(rule)
+		}
+		b.WriteString(fmt.Sprintf("%s%s  %s  %s\n", cursor, severityStyle(f.Severity), rule, f.Message))
+	}
+	if end < len(findings) {
+		b.WriteString(infoStyle.Render(fmt.Sprintf("  … %d more", len(findings)-end)))
+		b.WriteString("\n")
+	}
+
+	if f := findings[m.lintCursor]; f.Location != "" {
+		b.WriteString("\n")
+		b.WriteString(infoStyle.Render(f.Location))
+		b.WriteString("\n")
+	}
+
+	b.WriteString(helpStyle.Render("↑/↓: select • enter: open operation • esc: back • q: quit"))
+	return b.String()
+}
@@ -2,14 +2,24 @@ package tui
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"os"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/spinner"
 	"github.com/charmbracelet/bubbles/textarea"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/doganarif/ApiMug/internal/api"
+	"github.com/doganarif/ApiMug/internal/bench"
+	"github.com/doganarif/ApiMug/internal/collection"
+	"github.com/doganarif/ApiMug/internal/diff"
+	"github.com/doganarif/ApiMug/internal/lint"
+	"github.com/getkin/kin-openapi/openapi3"
 )
 
 type viewMode int
@@ -21,8 +31,27 @@ const (
 	viewResponse
 	viewAuth
 	viewSettings
+	viewWebSocket
+	viewCollections
+	viewBenchmark
+	viewLint
+	viewDiagnostics
+	viewDiff
 )
 
+type responseTab int
+
+const (
+	tabBody responseTab = iota
+	tabHeaders
+	tabTiming
+	tabRedirects
+	tabSigning
+	tabLinks
+)
+
+var responseTabNames = []string{"Body", "Headers", "Timing", "Redirects", "Signing", "Links"}
+
 type responseMsg struct {
 	response *api.Response
 }
@@ -35,6 +64,10 @@ type keyMap struct {
 	Quit     key.Binding
 	Server   key.Binding
 	Settings key.Binding
+	Saved    key.Binding
+	Lint     key.Binding
+	Problems key.Binding
+	Diff     key.Binding
 }
 
 var keys = keyMap{
@@ -62,6 +95,22 @@ var keys = keyMap{
 		key.WithKeys("c"),
 		key.WithHelp("c", "settings"),
 	),
+	Saved: key.NewBinding(
+		key.WithKeys("r"),
+		key.WithHelp("r", "collections"),
+	),
+	Lint: key.NewBinding(
+		key.WithKeys("L"),
+		key.WithHelp("L", "lint"),
+	),
+	Problems: key.NewBinding(
+		key.WithKeys("!"),
+		key.WithHelp("!", "diagnostics"),
+	),
+	Diff: key.NewBinding(
+		key.WithKeys("D"),
+		key.WithHelp("D", "compare"),
+	),
 	Quit: key.NewBinding(
 		key.WithKeys("q", "ctrl+c"),
 		key.WithHelp("q", "quit"),
@@ -102,22 +151,97 @@ type Model struct {
 
 	// Request form state
 	paramInputs    map[string]*InputField
+	paramOrder     []string
 	bodyInput      textarea.Model
+	timeoutInput   InputField
+	redirectInput  InputField
 	focusedInput   int
 	baseURL        string
 
 	// Auth state
 	authInputs     map[string]*InputField
+	authOrder      []string
 	authSchemes    []string
 	selectedScheme int
+	authDrafts     map[string]*api.AuthConfig
+	env            string
+	credStore      *api.CredentialStore
+	rememberAuth   bool
+	passphrase     InputField
+	authStatus     string
+	unlockErr      string // Why stored credentials could not be loaded at startup
+
+	// In-flight request state
+	sending        bool
+	cancelSend     context.CancelFunc
+	sendStarted    time.Time
+	progress       *api.Progress
+	spinner        spinner.Model
+	requestCh      chan tea.Msg
+	streaming      bool
+	streamEvents   []api.StreamEvent // The latest events
+	streamCount    int               // Events received, including dropped ones
+
+	// Saved request state
+	collections      *collection.File
+	savedCursor      int
+	savedRequest     *collection.Request // Loaded into the request form
+	savedRef         string
+	saveRequestInput *InputField
+	variables        api.Variables // Referenced as {{name}}, updated by extraction rules
+	lastRequest      *api.Request
+	extracted        []string // Results of the saved request's extraction rules
+	environment      *api.Environment // Receives extracted values marked save
+
+	// WebSocket session state
+	ws             *api.WebSocket
+	wsMessages     []api.WebSocketMessage
+	wsInput        textarea.Model
+	wsOpen         bool
+	wsClosing      bool
+	wsErr          error
+
+	// Benchmark state
+	benchInputs    map[string]*InputField
+	benchOrder     []string
+	benchRequest   *api.Request
+	benchRecorder  *bench.Recorder
+	benchResult    *bench.Result
+	benchCancel    context.CancelFunc
+
+	// Lint state
+	lintReport     *lint.Report
+	lintCursor     int
+	diagCursor     int
+
+	// Compare state
+	diffInput      *InputField
+	diffSource     string
+	diffDoc        *openapi3.T
+	diffReport     *diff.Report
+	diffReversed   bool
+	diffLoading    bool
+	diffCursor     int
+
+	// Response state
+	responseTab    responseTab
+	saveInput      *InputField
+	saveOverwrite  string // Path the user was warned exists, enter again replaces it
+	statusMsg      string
 
 	// Settings state
 	settingsInputs map[string]*InputField
+	settingsOrder  []string
+	settingsStatus string
+	servers        []api.Server
+	selectedServer int
+	serverVars     map[string]map[string]string // By server URL template
+	requestTimeout time.Duration
 	port           int
 	onSettingsChange func(baseURL string, port int)
 }
 
-func NewModel(spec *api.Spec, baseURL string, port int, onSettingsChange func(string, int)) Model {
+func NewModel(spec *api.Spec, env *api.Environment, collections *collection.File, baseURL string, port int, onSettingsChange func(string, int)) Model {
 	endpoints := spec.GetEndpoints()
 	items := make([]list.Item, len(endpoints))
 	for i, ep := range endpoints {
@@ -135,20 +259,43 @@ func NewModel(spec *api.Spec, baseURL string, port int, onSettingsChange func(st
 	bodyInput.Placeholder = `{"key": "value"}`
 	bodyInput.CharLimit = 5000
 
-	return Model{
+	s := spinner.New()
+	s.Spinner = spinner.Dot
+	s.Style = selectedStyle
+
+	m := Model{
 		spec:             spec,
+		env:              env.Name,
 		authMgr:          authMgr,
+		collections:      collections,
+		variables:        env.Variables.Clone(),
+		environment:      env,
+		client:           api.NewClient(baseURL, authMgr),
+		spinner:          s,
+		requestTimeout:   api.DefaultTimeout,
 		list:             l,
 		mode:             viewList,
 		baseURL:          baseURL,
 		bodyInput:        bodyInput,
 		paramInputs:      make(map[string]*InputField),
 		authInputs:       make(map[string]*InputField),
-		authSchemes:      authMgr.GetAvailableAuthSchemes(),
+		authSchemes:      append(authMgr.GetAvailableAuthSchemes(), api.CustomSigningScheme),
 		settingsInputs:   make(map[string]*InputField),
+		servers:          spec.GetServers(),
+		serverVars:       make(map[string]map[string]string),
 		port:             port,
 		onSettingsChange: onSettingsChange,
 	}
+	m.initCredentialStore()
+	m.client.PinBaseURL(spec.BaseURL != "")
+	if err := m.client.SetTLSConfig(env.TLS); err != nil {
+		m.err = err
+	}
+	if err := m.client.SetProxyConfig(env.Proxy); err != nil {
+		m.err = err
+	}
+
+	return m
 }
 
 func (m Model) Init() tea.Cmd {
@@ -163,15 +310,57 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.list.SetSize(msg.Width, msg.Height-4)
 		return m, nil
 
+	case spinner.TickMsg:
+		if !m.sending {
+			return m, nil
+		}
+		var cmd tea.Cmd
+		m.spinner, cmd = m.spinner.Update(msg)
+		return m, cmd
+
+	case streamStartedMsg:
+		m.response = msg.response
+		m.mode = viewResponse
+		m.responseTab = tabBody
+		m.saveInput = nil
+		m.statusMsg = ""
+		m.streaming = true
+		m.streamEvents = nil
+		m.streamCount = 0
+		m.extracted = nil
+		return m, listenRequest(m.requestCh)
+
+	case streamEventMsg:
+		m.streamEvents = api.KeepLast(append(m.streamEvents, msg.event), api.MaxStreamEvents)
+		m.streamCount++
+		return m, listenRequest(m.requestCh)
+
 	case responseMsg:
+		m.cancelRequest()
+		m.sending = false
+		m.streaming = false
+		m.cancelSend = nil
 		m.response = msg.response
 		m.mode = viewResponse
+		m.saveInput = nil
+		m.statusMsg = ""
+		m.runExtractions()
 		return m, nil
 
 	case tea.KeyMsg:
 		return m.handleKeyPress(msg)
 	}
 
+	if cmd, ok := m.handleWebSocketMsg(msg); ok {
+		return m, cmd
+	}
+	if cmd, ok := m.handleBenchMsg(msg); ok {
+		return m, cmd
+	}
+	if m.handleDiffMsg(msg) {
+		return m, nil
+	}
+
 	return m.updateCurrentView(msg)
 }
 
@@ -184,15 +373,32 @@ func (m Model) handleKeyPress(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		case key.Matches(msg, keys.Select):
 			if i, ok := m.list.SelectedItem().(item); ok {
 				m.selected = &i.endpoint
+				m.savedRequest = nil
 				m.mode = viewDetail
 			}
 			return m, nil
+		case key.Matches(msg, keys.Saved):
+			m.mode = viewCollections
+			m.statusMsg = ""
+			return m, nil
+		case key.Matches(msg, keys.Lint):
+			m.openLint()
+			return m, nil
+		case key.Matches(msg, keys.Problems):
+			m.mode = viewDiagnostics
+			return m, nil
+		case key.Matches(msg, keys.Diff):
+			cmd := m.openDiff()
+			return m, cmd
 		case key.Matches(msg, keys.Server):
 			m.mode = viewAuth
+			m.authDrafts = make(map[string]*api.AuthConfig)
+			m.authStatus = m.unlockErr
 			m.initAuthInputs()
 			return m, nil
 		case key.Matches(msg, keys.Settings):
 			m.mode = viewSettings
+			m.settingsStatus = ""
 			m.initSettingsInputs()
 			return m, nil
 		}
@@ -212,43 +418,155 @@ func (m Model) handleKeyPress(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 		}
 
 	case viewRequest:
+		if m.sending {
+			switch msg.String() {
+			case "esc", "ctrl+c":
+				m.cancelRequest()
+			}
+			return m, nil
+		}
+
+		if m.saveRequestInput != nil {
+			switch msg.String() {
+			case "esc":
+				m.saveRequestInput = nil
+				return m, nil
+			case "enter":
+				m.saveRequest()
+				return m, nil
+			}
+			cmd := m.saveRequestInput.Update(msg)
+			return m, cmd
+		}
+
 		switch msg.String() {
 		case "esc":
 			m.mode = viewDetail
+			m.statusMsg = ""
+			return m, nil
+		case "ctrl+w":
+			m.startSaveRequest()
+			return m, nil
+		case "ctrl+b":
+			if !m.selected.WebSocket {
+				m.startBenchmarkForm()
+			}
 			return m, nil
 		case "ctrl+s":
-			return m, m.sendRequest()
+			if m.selected.WebSocket {
+				return m, m.startWebSocket()
+			}
+			cmd := m.startRequest()
+			return m, cmd
 		case "tab", "shift+tab":
 			m.cycleFocus(msg.String() == "shift+tab")
 			return m, nil
 		}
 
 	case viewResponse:
+		if m.streaming {
+			switch msg.String() {
+			case "esc", "ctrl+c":
+				m.cancelRequest()
+			}
+			return m, nil
+		}
+
+		if m.saveInput != nil {
+			switch msg.String() {
+			case "esc":
+				m.saveInput = nil
+				m.saveOverwrite = ""
+				m.statusMsg = ""
+				return m, nil
+			case "enter":
+				m.saveResponseBody()
+				return m, nil
+			}
+			cmd := m.saveInput.Update(msg)
+			if m.saveOverwrite != "" && m.saveInput.Value() != m.saveOverwrite {
+				m.saveOverwrite = ""
+				m.statusMsg = ""
+			}
+			return m, cmd
+		}
+
 		switch {
+		case msg.String() == "w":
+			if m.response != nil && m.response.Error == nil {
+				field := NewInputField("Save body to", "path/to/file", true)
+				field.SetValue(m.response.SuggestedFilename())
+				field.Focus()
+				m.saveInput = &field
+				m.statusMsg = ""
+			}
+			return m, nil
+		case msg.String() == "tab":
+			m.responseTab = (m.responseTab + 1) % responseTab(len(responseTabNames))
+			return m, nil
+		case msg.String() == "shift+tab":
+			m.responseTab = (m.responseTab + responseTab(len(responseTabNames)) - 1) % responseTab(len(responseTabNames))
+			return m, nil
 		case key.Matches(msg, keys.Back):
 			m.mode = viewRequest
 			return m, nil
 		case key.Matches(msg, keys.Quit):
 			return m, tea.Quit
+		case m.responseTab == tabLinks && len(msg.String()) == 1 && msg.String() >= "1" && msg.String() <= "9":
+			if links := m.responseLinks(); int(msg.String()[0]-'1') < len(links) {
+				m.followLink(links[msg.String()[0]-'1'])
+			}
+			return m, nil
 		}
 
+	case viewWebSocket:
+		return m.handleWebSocketKey(msg)
+
+	case viewCollections:
+		return m.handleCollectionsKey(msg)
+
+	case viewBenchmark:
+		return m.handleBenchKey(msg)
+
+	case viewLint:
+		return m.handleLintKey(msg)
+
+	case viewDiagnostics:
+		return m.handleDiagnosticsKey(msg)
+
+	case viewDiff:
+		return m.handleDiffKey(msg)
+
 	case viewAuth:
 		switch msg.String() {
 		case "esc":
+			m.authDrafts = nil
 			m.mode = viewList
 			return m, nil
 		case "ctrl+s":
-			m.applyAuth()
-			m.mode = viewList
+			if m.applyAuth() {
+				m.mode = viewList
+			}
+			return m, nil
+		case "ctrl+d":
+			m.clearAuthInputs()
+			return m, nil
+		case "ctrl+r":
+			m.toggleRememberAuth()
+			return m, nil
+		case "ctrl+l":
+			m.loadStoredCredentials()
 			return m, nil
 		case "tab", "shift+tab":
 			m.cycleFocus(msg.String() == "shift+tab")
 			return m, nil
 		case "up", "down":
 			if msg.String() == "up" && m.selectedScheme > 0 {
+				m.stashAuthInputs()
 				m.selectedScheme--
 				m.initAuthInputs()
 			} else if msg.String() == "down" && m.selectedScheme < len(m.authSchemes)-1 {
+				m.stashAuthInputs()
 				m.selectedScheme++
 				m.initAuthInputs()
 			}
@@ -261,12 +579,24 @@ func (m Model) handleKeyPress(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
 			m.mode = viewList
 			return m, nil
 		case "ctrl+s":
-			m.applySettings()
-			m.mode = viewList
+			if m.applySettings() {
+				m.mode = viewList
+			}
 			return m, nil
 		case "tab", "shift+tab":
 			m.cycleFocus(msg.String() == "shift+tab")
 			return m, nil
+		case "up", "down":
+			if msg.String() == "up" && m.selectedServer > 0 {
+				m.selectedServer--
+				m.initSettingsInputs()
+				m.syncServerURL()
+			} else if msg.String() == "down" && m.selectedServer < len(m.servers)-1 {
+				m.selectedServer++
+				m.initSettingsInputs()
+				m.syncServerURL()
+			}
+			return m, nil
 		}
 	}
 
@@ -280,38 +610,13 @@ func (m Model) updateCurrentView(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case viewList:
 		m.list, cmd = m.list.Update(msg)
 
-	case viewRequest:
-		if m.focusedInput == len(m.paramInputs) && m.selected.HasBody {
-			m.bodyInput, cmd = m.bodyInput.Update(msg)
-		} else {
-			idx := 0
-			for _, input := range m.paramInputs {
-				if idx == m.focusedInput {
-					cmd = input.Update(msg)
-					break
-				}
-				idx++
-			}
-		}
-
-	case viewAuth:
-		idx := 0
-		for _, input := range m.authInputs {
-			if idx == m.focusedInput {
-				cmd = input.Update(msg)
-				break
-			}
-			idx++
-		}
+	case viewRequest, viewAuth, viewBenchmark:
+		cmd = m.updateFocused(msg)
 
 	case viewSettings:
-		idx := 0
-		for _, input := range m.settingsInputs {
-			if idx == m.focusedInput {
-				cmd = input.Update(msg)
-				break
-			}
-			idx++
+		cmd = m.updateFocused(msg)
+		if _, isKey := msg.(tea.KeyMsg); isKey && m.focusedInput > 0 && m.focusedInput <= len(m.selectedServerVariables()) {
+			m.syncServerURL()
 		}
 	}
 
@@ -332,12 +637,30 @@ func (m Model) View() string {
 		return m.authView()
 	case viewSettings:
 		return m.settingsView()
+	case viewWebSocket:
+		return m.webSocketView()
+	case viewCollections:
+		return m.collectionsView()
+	case viewBenchmark:
+		return m.benchView()
+	case viewLint:
+		return m.lintView()
+	case viewDiagnostics:
+		return m.diagnosticsView()
+	case viewDiff:
+		return m.diffView()
 	}
 	return ""
 }
 
 func (m Model) listView() string {
-	help := helpStyle.Render("\n↑/↓: navigate • enter: view details • s: auth • c: settings • q: quit")
+	help := helpStyle.Render("\n↑/↓: navigate • enter: view details • r: collections • L: lint • D: compare • s: auth • c: settings • q: quit")
+	if warning := m.tlsWarning(); warning != "" {
+		help += "  " + warning
+	}
+	if warning := m.diagnosticsWarning(); warning != "" {
+		help += "  " + warning
+	}
 	return m.list.View() + help
 }
 
@@ -348,7 +671,11 @@ func (m Model) detailView() string {
 	b.WriteString("\n\n")
 
 	style := getMethodStyle(m.selected.Method)
-	b.WriteString(fmt.Sprintf("%s %s\n\n", style.Render(strings.ToUpper(m.selected.Method)), m.selected.Path))
+	b.WriteString(fmt.Sprintf("%s %s", style.Render(strings.ToUpper(m.selected.Method)), m.selected.Path))
+	if m.selected.WebSocket {
+		b.WriteString(infoStyle.Render("  (WebSocket)"))
+	}
+	b.WriteString("\n\n")
 
 	if m.selected.Summary != "" {
 		b.WriteString(headerStyle.Render("Summary"))
@@ -364,6 +691,19 @@ func (m Model) detailView() string {
 		b.WriteString("\n\n")
 	}
 
+	if len(m.selected.Servers) > 0 {
+		b.WriteString(headerStyle.Render("Servers"))
+		b.WriteString("\n")
+		for _, server := range m.selected.Servers {
+			b.WriteString(fmt.Sprintf("  • %s", server.URL))
+			if server.Description != "" {
+				b.WriteString(infoStyle.Render("  " + server.Description))
+			}
+			b.WriteString("\n")
+		}
+		b.WriteString("\n")
+	}
+
 	if len(m.selected.Parameters) > 0 {
 		b.WriteString(headerStyle.Render("Parameters"))
 		b.WriteString("\n")
@@ -377,19 +717,26 @@ func (m Model) detailView() string {
 		b.WriteString("\n")
 	}
 
+	b.WriteString(m.securityView())
+
 	if m.selected.HasBody {
 		b.WriteString(headerStyle.Render("Request Body"))
 		b.WriteString("\n")
 		b.WriteString("  application/json\n\n")
 	}
 
-	b.WriteString(helpStyle.Render("\nenter: send request • esc: back • q: quit"))
+	if m.selected.WebSocket {
+		b.WriteString(helpStyle.Render("\nenter: open session • esc: back • q: quit"))
+	} else {
+		b.WriteString(helpStyle.Render("\nenter: send request • esc: back • q: quit"))
+	}
 
 	return b.String()
 }
 
 func (m *Model) initRequestInputs() {
 	m.paramInputs = make(map[string]*InputField)
+	m.paramOrder = nil
 	m.focusedInput = 0
 
 	for _, param := range m.selected.Parameters {
@@ -399,71 +746,16 @@ func (m *Model) initRequestInputs() {
 			param.Required,
 		)
 		m.paramInputs[param.Name] = &field
+		m.paramOrder = append(m.paramOrder, param.Name)
 	}
 
-	// Focus first input
-	if len(m.paramInputs) > 0 {
-		idx := 0
-		for _, input := range m.paramInputs {
-			if idx == 0 {
-				input.Focus()
-			}
-			idx++
-		}
-	} else if m.selected.HasBody {
-		m.bodyInput.Focus()
-	}
-}
-
-func (m *Model) cycleFocus(reverse bool) {
-	totalInputs := len(m.paramInputs)
-	if m.selected.HasBody {
-		totalInputs++
-	}
-
-	if totalInputs == 0 {
-		return
-	}
-
-	// Blur current
-	if m.focusedInput == len(m.paramInputs) {
-		m.bodyInput.Blur()
-	} else {
-		idx := 0
-		for _, input := range m.paramInputs {
-			if idx == m.focusedInput {
-				input.Blur()
-				break
-			}
-			idx++
-		}
-	}
-
-	// Update focus index
-	if reverse {
-		m.focusedInput--
-		if m.focusedInput < 0 {
-			m.focusedInput = totalInputs - 1
-		}
-	} else {
-		m.focusedInput++
-		if m.focusedInput >= totalInputs {
-			m.focusedInput = 0
-		}
-	}
+	m.timeoutInput = NewInputField("Timeout (seconds)", fmt.Sprintf("%g (default)", m.requestTimeout.Seconds()), false)
+	m.redirectInput = NewInputField("Follow Redirects (y/n or max hops)", describeRedirectPolicy(m.client.RedirectPolicy())+" (default)", false)
+	m.bodyInput.Blur()
 
-	// Focus new
-	if m.focusedInput == len(m.paramInputs) {
-		m.bodyInput.Focus()
-	} else {
-		idx := 0
-		for _, input := range m.paramInputs {
-			if idx == m.focusedInput {
-				input.Focus()
-				break
-			}
-			idx++
-		}
+	// Focus first input
+	if items := m.focusables(); len(items) > 0 {
+		items[0].Focus()
 	}
 }
 
@@ -476,10 +768,15 @@ func (m Model) requestView() string {
 	style := getMethodStyle(m.selected.Method)
 	b.WriteString(fmt.Sprintf("%s %s\n\n", style.Render(strings.ToUpper(m.selected.Method)), m.selected.Path))
 
+	if saved := m.savedRequestView(); saved != "" {
+		b.WriteString(saved)
+		b.WriteString("\n")
+	}
+
 	if len(m.paramInputs) > 0 {
 		b.WriteString(headerStyle.Render("Parameters"))
 		b.WriteString("\n\n")
-		for _, input := range m.paramInputs {
+		for _, input := range orderedFields(m.paramOrder, m.paramInputs) {
 			b.WriteString(input.View())
 			b.WriteString("\n")
 		}
@@ -490,62 +787,132 @@ func (m Model) requestView() string {
 		b.WriteString(headerStyle.Render("Request Body (JSON)"))
 		b.WriteString("\n\n")
 		b.WriteString(m.bodyInput.View())
+		b.WriteString("\n")
 	}
 
-	b.WriteString(helpStyle.Render("\n\ntab: next field • ctrl+s: send • esc: back"))
+	b.WriteString("\n")
+	b.WriteString(headerStyle.Render("Options"))
+	b.WriteString("\n")
+	b.WriteString(m.timeoutInput.View())
+	b.WriteString("\n")
+	b.WriteString(m.redirectInput.View())
+
+	if m.sending {
+		b.WriteString("\n\n")
+		b.WriteString(m.spinner.View())
+		if m.selected.WebSocket {
+			b.WriteString(fmt.Sprintf(" Connecting... %s elapsed",
+				formatDuration(time.Since(m.sendStarted).Truncate(100*time.Millisecond))))
+		} else {
+			b.WriteString(fmt.Sprintf(" Sending... %s elapsed, %s received",
+				formatDuration(time.Since(m.sendStarted).Truncate(100*time.Millisecond)),
+				api.FormatBytes(m.progress.BytesRead())))
+		}
+		b.WriteString(helpStyle.Render("\n\nesc/ctrl+c: cancel request"))
+		return b.String()
+	}
+
+	if m.saveRequestInput != nil {
+		b.WriteString("\n")
+		b.WriteString(m.saveRequestInput.View())
+		b.WriteString(helpStyle.Render("\nenter: save • esc: cancel"))
+		return b.String()
+	}
+
+	if m.statusMsg != "" {
+		b.WriteString("\n\n")
+		b.WriteString(m.statusMsg)
+	}
+
+	if m.selected.WebSocket {
+		b.WriteString(helpStyle.Render("\n\ntab: next field • ctrl+s: connect • ctrl+w: save to collection • esc: back"))
+	} else {
+		b.WriteString(helpStyle.Render("\n\ntab: next field • ctrl+s: send • ctrl+w: save to collection • ctrl+b: benchmark • esc: back"))
+	}
 
 	return b.String()
 }
 
-func (m *Model) sendRequest() tea.Cmd {
-	return func() tea.Msg {
-		// Build request
-		req := &api.Request{
-			Method:      strings.ToUpper(m.selected.Method),
-			Path:        m.selected.Path,
-			QueryParams: make(map[string]string),
-			Headers:     make(map[string]string),
-		}
-
-		// Apply parameters
-		for name, input := range m.paramInputs {
-			val := input.Value()
-			if val == "" {
-				continue
-			}
+// startRequest builds the request from the form and sends it in the background
+func (m *Model) startRequest() tea.Cmd {
+	req, err := m.buildRequest()
+	if err != nil {
+		m.statusMsg = errorStyle.Render("Error: ") + err.Error()
+		return nil
+	}
 
-			// Find param type
-			for _, p := range m.selected.Parameters {
-				if p.Name == name {
-					switch p.In {
-					case "query":
-						req.QueryParams[name] = val
-					case "header":
-						req.Headers[name] = val
-					case "path":
-						req.Path = strings.ReplaceAll(req.Path, "{"+name+"}", val)
-					}
-					break
-				}
-			}
+	ctx, cancel := context.WithCancel(context.Background())
+	m.sending = true
+	m.cancelSend = cancel
+	m.sendStarted = time.Now()
+	m.progress = &api.Progress{}
+	m.statusMsg = ""
+	m.requestCh = make(chan tea.Msg, 1)
+	req.Progress = m.progress
+	req.Stream = newStreamHandler(ctx, m.requestCh)
+	m.lastRequest = req
+
+	return tea.Batch(m.spinner.Tick, sendRequest(ctx, m.client, req, m.requestCh), listenRequest(m.requestCh))
+}
+
+// cancelRequest aborts the in-flight request, the client reports the cancellation
+func (m *Model) cancelRequest() {
+	if m.cancelSend != nil {
+		m.cancelSend()
+	}
+}
+
+func (m *Model) buildRequest() (*api.Request, error) {
+	req := m.selected.NewRequest(m.paramValues(), m.bodyInput.Value())
+	m.applySavedRequest(req)
+	m.variables.ExpandRequest(req)
+
+	if v := strings.TrimSpace(m.timeoutInput.Value()); v != "" {
+		timeout, err := parseSeconds(v)
+		if err != nil {
+			return nil, err
 		}
+		req.Timeout = timeout
+	}
 
-		// Apply body
-		if m.selected.HasBody {
-			req.Body = m.bodyInput.Value()
-			req.ContentType = "application/json"
+	if v := strings.TrimSpace(m.redirectInput.Value()); v != "" {
+		policy, err := parseRedirectPolicy(v, m.client.RedirectPolicy())
+		if err != nil {
+			return nil, err
 		}
+		req.Redirects = &policy
+	}
 
-		// Ensure client is initialized
-		if m.client == nil {
-			m.client = api.NewClient(m.baseURL, m.authMgr)
+	return req, nil
+}
+
+// paramValues returns the parameter values entered in the request form
+func (m *Model) paramValues() map[string]string {
+	values := make(map[string]string, len(m.paramInputs))
+	for name, input := range m.paramInputs {
+		if v := input.Value(); v != "" {
+			values[name] = v
 		}
+	}
+	return values
+}
 
-		// Send request
-		resp := m.client.Send(context.Background(), req)
+// sendRequest sends req in the background; the response, like stream events,
+// is delivered through ch so that it arrives after them
+func sendRequest(ctx context.Context, client *api.Client, req *api.Request, ch chan<- tea.Msg) tea.Cmd {
+	return func() tea.Msg {
+		ch <- responseMsg{response: client.Send(ctx, req)}
+		return nil
+	}
+}
 
-		return responseMsg{response: resp}
+// parseSeconds parses a timeout given in (fractional) seconds
+func parseSeconds(v string) (time.Duration, error) {
+	seconds, err := strconv.ParseFloat(v, 64)
+	if err != nil || seconds < 0 {
+		return 0, fmt.Errorf("invalid timeout %q: expected seconds", v)
 	}
+	return time.Duration(seconds * float64(time.Second)), nil
 }
 
 func (m Model) responseView() string {
@@ -554,6 +921,11 @@ func (m Model) responseView() string {
 	b.WriteString(titleStyle.Render("Response"))
 	b.WriteString("\n\n")
 
+	if warning := m.tlsWarning(); warning != "" {
+		b.WriteString(warning)
+		b.WriteString("\n\n")
+	}
+
 	if m.response.Error != nil {
 		b.WriteString(errorStyle.Render("Error: "))
 		b.WriteString(m.response.Error.Error())
@@ -565,170 +937,279 @@ func (m Model) responseView() string {
 			statusStyle = statusCodeErrorStyle
 		}
 		b.WriteString(statusStyle.Render(fmt.Sprintf("%d %s", m.response.StatusCode, m.response.Status)))
-		b.WriteString(infoStyle.Render(fmt.Sprintf("  (%s)", m.response.Duration)))
-		b.WriteString("\n\n")
-
-		// Headers
-		b.WriteString(headerStyle.Render("Headers"))
-		b.WriteString("\n")
-		for k, v := range m.response.Headers {
-			b.WriteString(fmt.Sprintf("  %s: %s\n", k, strings.Join(v, ", ")))
+		if m.streaming {
+			b.WriteString("  " + m.spinner.View())
+			b.WriteString(fmt.Sprintf(" Streaming... %d events, %s received",
+				m.streamCount, api.FormatBytes(m.progress.BytesRead())))
+		} else {
+			b.WriteString(infoStyle.Render(fmt.Sprintf("  (%s)", m.response.Duration)))
 		}
-		b.WriteString("\n")
+		if n := len(m.response.Redirects); n > 0 && m.response.Truncated {
+			b.WriteString(warningStyle.Render(fmt.Sprintf("  stopped after %d redirect(s)", n)))
+		} else if n > 0 {
+			b.WriteString(infoStyle.Render(fmt.Sprintf("  after %d redirect(s)", n)))
+		}
+		if n := len(m.responseLinks()); n > 0 && !m.streaming {
+			b.WriteString(infoStyle.Render(fmt.Sprintf("  %d link(s), see Links", n)))
+		}
+		b.WriteString("\n\n")
+	}
 
-		// Body
-		b.WriteString(headerStyle.Render("Body"))
+	if len(m.extracted) > 0 && !m.streaming {
+		b.WriteString(strings.Join(m.extracted, "\n"))
 		b.WriteString("\n\n")
-		b.WriteString(codeStyle.Render(m.response.FormatResponseBody()))
 	}
 
-	b.WriteString(helpStyle.Render("\n\nesc: back • q: quit"))
+	b.WriteString(renderTabs(responseTabNames, int(m.responseTab)))
+	b.WriteString("\n\n")
 
-	return b.String()
-}
+	switch m.responseTab {
+	case tabHeaders:
+		if m.response.Error == nil {
+			for k, v := range m.response.Headers {
+				b.WriteString(fmt.Sprintf("  %s: %s\n", k, strings.Join(v, ", ")))
+			}
+		}
 
-func (m *Model) initAuthInputs() {
-	m.authInputs = make(map[string]*InputField)
-	m.focusedInput = 0
+	case tabTiming:
+		b.WriteString(renderTiming(m.response.Timing, m.width))
 
-	schemeName := m.authSchemes[m.selectedScheme]
-	if schemeName == "none" {
-		return
-	}
+	case tabRedirects:
+		b.WriteString(renderRedirects(m.response))
 
-	config, err := m.authMgr.ParseAuthScheme(schemeName)
-	if err != nil {
-		return
+	case tabSigning:
+		b.WriteString(renderSignatures(m.response.Signatures))
+
+	case tabLinks:
+		b.WriteString(m.renderLinks(m.responseLinks()))
+
+	default:
+		switch {
+		case m.streaming:
+			b.WriteString(renderStreamEvents(m.streamEvents, m.height-12))
+		case len(m.response.Events) > 0:
+			b.WriteString(renderStreamEvents(m.response.Events, m.height-12))
+		case m.response.Error == nil:
+			b.WriteString(codeStyle.Render(m.response.FormatResponseBody()))
+		}
 	}
 
-	switch config.Type {
-	case api.AuthTypeBearer, api.AuthTypeOAuth2:
-		field := NewInputField("Token", "Enter token", true)
-		field.Focus()
-		m.authInputs["token"] = &field
+	if m.streaming {
+		b.WriteString(helpStyle.Render("\n\nesc/ctrl+c: stop stream"))
+		return b.String()
+	}
 
-	case api.AuthTypeAPIKey:
-		field := NewInputField(fmt.Sprintf("API Key (%s in %s)", config.KeyName, config.APIKeyIn), "Enter API key", true)
-		field.Focus()
-		m.authInputs["apikey"] = &field
+	if m.saveInput != nil {
+		b.WriteString("\n")
+		b.WriteString(m.saveInput.View())
+		if m.statusMsg != "" {
+			b.WriteString("\n")
+			b.WriteString(m.statusMsg)
+		}
+		b.WriteString(helpStyle.Render("\nenter: save • esc: cancel"))
+		return b.String()
+	}
 
-	case api.AuthTypeBasic:
-		userField := NewInputField("Username", "Enter username", true)
-		userField.Focus()
-		m.authInputs["username"] = &userField
+	if m.statusMsg != "" {
+		b.WriteString("\n\n")
+		b.WriteString(m.statusMsg)
+	}
 
-		passField := NewInputField("Password", "Enter password", true)
-		m.authInputs["password"] = &passField
+	if m.responseTab == tabLinks && len(m.responseLinks()) > 0 {
+		b.WriteString(helpStyle.Render("\n\n1-9: follow link • tab: switch view • w: save body • esc: back • q: quit"))
+	} else {
+		b.WriteString(helpStyle.Render("\n\ntab: switch view • w: save body • esc: back • q: quit"))
 	}
+
+	return b.String()
 }
 
-func (m *Model) applyAuth() {
-	schemeName := m.authSchemes[m.selectedScheme]
-	if schemeName == "none" {
-		m.authMgr.SetAuth(&api.AuthConfig{Type: api.AuthTypeNone})
+// saveResponseBody saves the body to the entered path. Replacing an existing
+// file takes a second enter.
+func (m *Model) saveResponseBody() {
+	value := m.saveInput.Value()
+	path, err := m.response.SaveBody(value, m.saveOverwrite != "" && m.saveOverwrite == value)
+	if errors.Is(err, os.ErrExist) {
+		m.saveOverwrite = value
+		m.statusMsg = warningStyle.Render(path+" already exists") + ", press enter again to overwrite it"
 		return
 	}
-
-	config, err := m.authMgr.ParseAuthScheme(schemeName)
+	m.saveInput = nil
+	m.saveOverwrite = ""
 	if err != nil {
+		m.statusMsg = errorStyle.Render("Save failed: ") + err.Error()
 		return
 	}
+	m.statusMsg = successStyle.Render("Saved ") + fmt.Sprintf("%s (%s)", path, api.FormatBytes(int64(len(m.response.BodyBytes))))
+}
 
-	switch config.Type {
-	case api.AuthTypeBearer, api.AuthTypeOAuth2:
-		if input, ok := m.authInputs["token"]; ok {
-			config.Token = input.Value()
-		}
+func (m *Model) initSettingsInputs() {
+	m.settingsInputs = make(map[string]*InputField)
+	m.settingsOrder = []string{"baseURL"}
+	m.focusedInput = 0
 
-	case api.AuthTypeAPIKey:
-		if input, ok := m.authInputs["apikey"]; ok {
-			config.APIKey = input.Value()
-		}
+	baseURLField := NewInputField("Base URL", "https://api.example.com", false)
+	baseURLField.SetValue(m.baseURL)
+	baseURLField.Focus()
+	m.settingsInputs["baseURL"] = &baseURLField
 
-	case api.AuthTypeBasic:
-		if input, ok := m.authInputs["username"]; ok {
-			config.Username = input.Value()
+	for _, v := range m.selectedServerVariables() {
+		label := "{" + v.Name + "}"
+		if len(v.Enum) > 0 {
+			label += " [" + strings.Join(v.Enum, ", ") + "]"
 		}
-		if input, ok := m.authInputs["password"]; ok {
-			config.Password = input.Value()
+		if v.Description != "" {
+			label += " - " + v.Description
+		}
+
+		field := NewInputField(label, v.Default, false)
+		if value, ok := m.serverVars[m.servers[m.selectedServer].URL][v.Name]; ok {
+			field.SetValue(value)
+		} else {
+			field.SetValue(v.Default)
 		}
+		key := "var:" + v.Name
+		m.settingsInputs[key] = &field
+		m.settingsOrder = append(m.settingsOrder, key)
 	}
 
-	m.authMgr.SetAuth(config)
-}
+	portField := NewInputField("Swagger UI Port", "8080", false)
+	portField.SetValue(fmt.Sprintf("%d", m.port))
+	m.settingsInputs["port"] = &portField
 
-func (m Model) authView() string {
-	var b strings.Builder
+	timeoutField := NewInputField("Request Timeout (seconds, 0 = none)", fmt.Sprintf("%g", api.DefaultTimeout.Seconds()), false)
+	timeoutField.SetValue(fmt.Sprintf("%g", m.requestTimeout.Seconds()))
+	m.settingsInputs["timeout"] = &timeoutField
 
-	b.WriteString(titleStyle.Render("Configure Authentication"))
-	b.WriteString("\n\n")
+	redirectField := NewInputField("Follow Redirects (y/n or max hops, 0 = don't follow)", describeRedirectPolicy(api.DefaultRedirectPolicy), false)
+	redirectField.SetValue(describeRedirectPolicy(m.client.RedirectPolicy()))
+	m.settingsInputs["redirects"] = &redirectField
 
-	b.WriteString(headerStyle.Render("Available Schemes"))
-	b.WriteString("\n\n")
+	m.settingsOrder = append(m.settingsOrder, "port", "timeout", "redirects")
+	m.initTLSInputs()
+	m.initProxyInputs()
+}
 
-	for i, scheme := range m.authSchemes {
-		if i == m.selectedScheme {
-			b.WriteString(selectedStyle.Render("► " + scheme))
-		} else {
-			b.WriteString("  " + scheme)
+func (m *Model) selectedServerVariables() []api.ServerVariable {
+	if m.selectedServer >= len(m.servers) {
+		return nil
+	}
+	return m.servers[m.selectedServer].Variables
+}
+
+// settingsServerVars reads the server variable values from the settings form
+func (m *Model) settingsServerVars() map[string]string {
+	values := make(map[string]string)
+	for _, v := range m.selectedServerVariables() {
+		if input, ok := m.settingsInputs["var:"+v.Name]; ok {
+			values[v.Name] = input.Value()
 		}
-		b.WriteString("\n")
 	}
+	return values
+}
 
-	b.WriteString("\n")
+// syncServerURL fills the base URL field from the selected server and its variables
+func (m *Model) syncServerURL() {
+	if m.selectedServer >= len(m.servers) {
+		return
+	}
+	if input, ok := m.settingsInputs["baseURL"]; ok {
+		input.SetValue(m.servers[m.selectedServer].Resolve(m.settingsServerVars()))
+	}
+}
 
-	if m.selectedScheme < len(m.authSchemes) && m.authSchemes[m.selectedScheme] != "none" {
-		b.WriteString(headerStyle.Render("Configuration"))
-		b.WriteString("\n\n")
+// applySettings applies the settings form and reports whether it can close.
+// Every field is checked before any is applied, so an error changes nothing.
+func (m *Model) applySettings() bool {
+	fail := func(err error) bool {
+		m.settingsStatus = errorStyle.Render("Error: ") + err.Error()
+		return false
+	}
 
-		for _, input := range m.authInputs {
-			b.WriteString(input.View())
-			b.WriteString("\n")
+	timeout := m.requestTimeout
+	if timeoutInput, ok := m.settingsInputs["timeout"]; ok {
+		if value := strings.TrimSpace(timeoutInput.Value()); value != "" {
+			var err error
+			if timeout, err = parseSeconds(value); err != nil {
+				return fail(err)
+			}
 		}
 	}
 
-	b.WriteString(helpStyle.Render("\n\n↑/↓: select scheme • tab: next field • ctrl+s: save • esc: cancel"))
-
-	return b.String()
-}
+	policy := m.client.RedirectPolicy()
+	if redirectInput, ok := m.settingsInputs["redirects"]; ok {
+		var err error
+		if policy, err = parseRedirectPolicy(redirectInput.Value(), policy); err != nil {
+			return fail(err)
+		}
+	}
 
-func (m *Model) initSettingsInputs() {
-	m.settingsInputs = make(map[string]*InputField)
-	m.focusedInput = 0
+	tlsConfig := m.settingsTLSConfig()
+	if tlsConfig != m.client.TLSConfig() {
+		if _, err := tlsConfig.ClientConfig(); err != nil {
+			return fail(err)
+		}
+	}
+	proxyConfig := m.settingsProxyConfig()
+	if proxyConfig != m.client.ProxyConfig() {
+		if err := proxyConfig.Validate(); err != nil {
+			return fail(err)
+		}
+	}
 
-	baseURLField := NewInputField("Base URL", "https://api.example.com", false)
-	baseURLField.SetValue(m.baseURL)
-	baseURLField.Focus()
-	m.settingsInputs["baseURL"] = &baseURLField
+	newPort := m.port
+	if portInput, ok := m.settingsInputs["port"]; ok {
+		if portStr := strings.TrimSpace(portInput.Value()); portStr != "" {
+			port, err := strconv.Atoi(portStr)
+			if err != nil || port < 1 || port > 65535 {
+				return fail(fmt.Errorf("invalid port %q", portStr))
+			}
+			newPort = port
+		}
+	}
 
-	portField := NewInputField("Swagger UI Port", "8080", false)
-	portField.SetValue(fmt.Sprintf("%d", m.port))
-	m.settingsInputs["port"] = &portField
-}
+	// Everything is valid, apply it
+	if tlsConfig != m.client.TLSConfig() {
+		if err := m.client.SetTLSConfig(tlsConfig); err != nil {
+			return fail(err)
+		}
+	}
+	if proxyConfig != m.client.ProxyConfig() {
+		if err := m.client.SetProxyConfig(proxyConfig); err != nil {
+			return fail(err)
+		}
+	}
+	m.client.SetRedirectPolicy(policy)
+
+	var selected *api.Server
+	if m.selectedServer < len(m.servers) {
+		selected = &m.servers[m.selectedServer]
+		vars := m.settingsServerVars()
+		m.serverVars[selected.URL] = vars
+		m.client.SetServerVariables(selected.URL, vars)
+	}
 
-func (m *Model) applySettings() {
+	oldBaseURL := m.baseURL
 	if urlInput, ok := m.settingsInputs["baseURL"]; ok {
 		newBaseURL := urlInput.Value()
 		if newBaseURL != "" {
 			m.baseURL = newBaseURL
-			// Recreate client with new base URL
-			m.client = api.NewClient(m.baseURL, m.authMgr)
+			m.client.SetBaseURL(m.baseURL)
+			// A typed URL wins over the servers of operations, one of the
+			// spec's servers doesn't
+			m.client.PinBaseURL(selected == nil || newBaseURL != selected.Resolve(m.serverVars[selected.URL]))
 		}
 	}
 
-	newPort := m.port
-	if portInput, ok := m.settingsInputs["port"]; ok {
-		portStr := portInput.Value()
-		if portStr != "" {
-			fmt.Sscanf(portStr, "%d", &newPort)
-		}
-	}
+	m.requestTimeout = timeout
+	m.client.SetTimeout(timeout)
 
 	// Call the callback to notify about settings change
-	if m.onSettingsChange != nil && (m.port != newPort || m.baseURL != m.settingsInputs["baseURL"].Value()) {
+	if m.onSettingsChange != nil && (m.port != newPort || m.baseURL != oldBaseURL) {
 		m.onSettingsChange(m.baseURL, newPort)
 		m.port = newPort
 	}
+	return true
 }
 
 func (m Model) settingsView() string {
@@ -737,21 +1218,47 @@ func (m Model) settingsView() string {
 	b.WriteString(titleStyle.Render("Settings"))
 	b.WriteString("\n\n")
 
+	if len(m.servers) > 0 {
+		b.WriteString(headerStyle.Render("Servers"))
+		b.WriteString("\n\n")
+
+		for i, server := range m.servers {
+			line := server.URL
+			if server.Description != "" {
+				line += infoStyle.Render("  " + server.Description)
+			}
+			if i == m.selectedServer {
+				b.WriteString(selectedStyle.Render("► ") + line)
+			} else {
+				b.WriteString("  " + line)
+			}
+			b.WriteString("\n")
+		}
+		b.WriteString("\n")
+	}
+
 	b.WriteString(headerStyle.Render("API Configuration"))
 	b.WriteString("\n\n")
 
-	// Maintain order: baseURL then port
-	if input, ok := m.settingsInputs["baseURL"]; ok {
+	for _, input := range orderedFields(m.settingsOrder, m.settingsInputs) {
 		b.WriteString(input.View())
 		b.WriteString("\n")
 	}
 
-	if input, ok := m.settingsInputs["port"]; ok {
-		b.WriteString(input.View())
+	if warning := m.tlsWarning(); warning != "" {
+		b.WriteString("\n")
+		b.WriteString(warning)
+	}
+	if m.settingsStatus != "" {
 		b.WriteString("\n")
+		b.WriteString(m.settingsStatus)
 	}
 
-	b.WriteString(helpStyle.Render("\n\ntab: next field • ctrl+s: save • esc: cancel"))
+	help := "tab: next field • ctrl+s: save • esc: cancel"
+	if len(m.servers) > 1 {
+		help = "↑/↓: select server • " + help
+	}
+	b.WriteString(helpStyle.Render("\n\n" + help))
 
 	return b.String()
 }
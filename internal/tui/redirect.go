@@ -0,0 +1,83 @@
+package tui
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/doganarif/ApiMug/internal/api"
+)
+
+// parseRedirectPolicy reads "y", "n" or a maximum number of hops, where 0
+// disables following. Other settings are taken from base.
+func parseRedirectPolicy(v string, base api.RedirectPolicy) (api.RedirectPolicy, error) {
+	switch strings.ToLower(strings.TrimSpace(v)) {
+	case "y", "yes", "true":
+		base.Follow = true
+		return base, nil
+	case "n", "no", "false":
+		base.Follow = false
+		return base, nil
+	}
+
+	hops, err := strconv.Atoi(strings.TrimSpace(v))
+	if err != nil || hops < 0 {
+		return base, fmt.Errorf("invalid redirect setting %q: expected y, n or a number of hops", v)
+	}
+	return api.RedirectPolicy{Follow: hops > 0, MaxHops: hops}, nil
+}
+
+// describeRedirectPolicy is the inverse of parseRedirectPolicy
+func describeRedirectPolicy(policy api.RedirectPolicy) string {
+	if !policy.Follow {
+		return "0"
+	}
+	if policy.MaxHops <= 0 {
+		return strconv.Itoa(api.DefaultMaxRedirects)
+	}
+	return strconv.Itoa(policy.MaxHops)
+}
+
+// renderRedirects shows every redirect followed, ending with the final URL
+func renderRedirects(resp *api.Response) string {
+	if len(resp.Redirects) == 0 {
+		if location := resp.Headers.Get("Location"); location != "" && resp.StatusCode >= 300 && resp.StatusCode < 400 {
+			return infoStyle.Render("  Redirect not followed, Location: ") + location
+		}
+		return infoStyle.Render("  No redirects")
+	}
+
+	var b strings.Builder
+	for i, hop := range resp.Redirects {
+		b.WriteString(fmt.Sprintf("%d. ", i+1))
+		b.WriteString(statusCodeErrorStyle.Render(hop.Status))
+		b.WriteString(fmt.Sprintf("  %s %s\n", hop.Method, hop.URL))
+		b.WriteString(fmt.Sprintf("   → %s\n", hop.Location))
+
+		names := make([]string, 0, len(hop.Headers))
+		for name := range hop.Headers {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			b.WriteString(infoStyle.Render(fmt.Sprintf("     %s: %s", name, strings.Join(hop.Headers[name], ", "))))
+			b.WriteString("\n")
+		}
+		b.WriteString("\n")
+	}
+
+	if resp.URL != "" {
+		statusStyle := statusCodeSuccessStyle
+		if resp.StatusCode >= 400 {
+			statusStyle = statusCodeErrorStyle
+		}
+		b.WriteString(fmt.Sprintf("%d. ", len(resp.Redirects)+1))
+		b.WriteString(statusStyle.Render(resp.Status))
+		b.WriteString("  " + resp.URL + "\n")
+	}
+	if resp.Truncated {
+		b.WriteString(warningStyle.Render(fmt.Sprintf("   Stopped after %d redirects, Location: ", len(resp.Redirects))) + resp.Headers.Get("Location") + "\n")
+	}
+	return b.String()
+}
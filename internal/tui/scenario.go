@@ -0,0 +1,196 @@
+package tui
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/spinner"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/doganarif/ApiMug/internal/scenario"
+)
+
+type stepDoneMsg struct {
+	index  int
+	result *scenario.StepResult
+}
+
+type scenarioDoneMsg struct {
+	report *scenario.Report
+}
+
+// ScenarioRunner runs a scenario, reporting each step as it completes
+type ScenarioRunner func(ctx context.Context, progress func(index int, result *scenario.StepResult)) *scenario.Report
+
+// ScenarioModel shows the progress of a scenario run
+type ScenarioModel struct {
+	scenario *scenario.Scenario
+	run      ScenarioRunner
+	ctx      context.Context
+	cancel   context.CancelFunc
+	ch       chan tea.Msg
+	results  []*scenario.StepResult
+	report   *scenario.Report
+	started  time.Time
+	spinner  spinner.Model
+	height   int
+}
+
+// NewScenarioModel creates the progress view of a scenario run
+func NewScenarioModel(sc *scenario.Scenario, run ScenarioRunner) *ScenarioModel {
+	s := spinner.New()
+	s.Spinner = spinner.Dot
+	s.Style = selectedStyle
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &ScenarioModel{
+		scenario: sc,
+		run:      run,
+		ctx:      ctx,
+		cancel:   cancel,
+		ch:       make(chan tea.Msg, 1),
+		results:  make([]*scenario.StepResult, len(sc.Steps)),
+		started:  time.Now(),
+		spinner:  s,
+	}
+}
+
+// Report returns the report once the run finished, or nil
+func (m *ScenarioModel) Report() *scenario.Report {
+	return m.report
+}
+
+func (m *ScenarioModel) Init() tea.Cmd {
+	ch, ctx, run := m.ch, m.ctx, m.run
+	start := func() tea.Msg {
+		report := run(ctx, func(index int, result *scenario.StepResult) {
+			ch <- stepDoneMsg{index: index, result: result}
+		})
+		ch <- scenarioDoneMsg{report: report}
+		return nil
+	}
+	return tea.Batch(m.spinner.Tick, start, listenRequest(ch))
+}
+
+func (m *ScenarioModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.height = msg.Height
+		return m, nil
+
+	case spinner.TickMsg:
+		if m.report != nil {
+			return m, nil
+		}
+		var cmd tea.Cmd
+		m.spinner, cmd = m.spinner.Update(msg)
+		return m, cmd
+
+	case stepDoneMsg:
+		m.results[msg.index] = msg.result
+		return m, listenRequest(m.ch)
+
+	case scenarioDoneMsg:
+		m.report = msg.report
+		m.cancel()
+		return m, nil
+
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "q", "esc", "ctrl+c":
+			if m.report == nil {
+				// Remaining steps are skipped, the run then finishes
+				m.cancel()
+				return m, nil
+			}
+			return m, tea.Quit
+		}
+	}
+	return m, nil
+}
+
+func (m *ScenarioModel) View() string {
+	var b strings.Builder
+
+	b.WriteString(titleStyle.Render("Scenario: " + m.scenario.Name))
+	b.WriteString("\n\n")
+
+	var lines []string
+	running := false
+	for i, step := range m.scenario.Steps {
+		result := m.results[i]
+		if result == nil {
+			if !running && m.report == nil {
+				running = true
+				lines = append(lines, fmt.Sprintf("%s %s", m.spinner.View(), step.Name))
+			} else {
+				lines = append(lines, infoStyle.Render("· "+step.Name))
+			}
+			continue
+		}
+		lines = append(lines, renderStepResult(result)...)
+	}
+
+	if m.height > 8 && len(lines) > m.height-8 {
+		lines = lines[len(lines)-(m.height-8):]
+	}
+	b.WriteString(strings.Join(lines, "\n"))
+	b.WriteString("\n\n")
+
+	if m.report == nil {
+		b.WriteString(infoStyle.Render(fmt.Sprintf("Running... %s", formatDuration(time.Since(m.started).Truncate(100*time.Millisecond)))))
+		b.WriteString(helpStyle.Render("\n\nq/esc: stop"))
+		return b.String()
+	}
+
+	passed, failed, skipped := m.report.Counts()
+	summary := fmt.Sprintf("%d passed, %d failed, %d skipped in %s", passed, failed, skipped, formatDuration(m.report.Duration))
+	if m.report.Passed() {
+		b.WriteString(successStyle.Render(summary))
+	} else {
+		b.WriteString(errorStyle.Render(summary))
+	}
+	b.WriteString(helpStyle.Render("\n\nq: quit"))
+	return b.String()
+}
+
+// renderStepResult renders a completed step with its failed checks
+func renderStepResult(result *scenario.StepResult) []string {
+	if result.Skipped {
+		return []string{infoStyle.Render("⊘ " + result.Name + " (skipped)")}
+	}
+
+	icon := successStyle.Render("✓")
+	if !result.Passed() {
+		icon = errorStyle.Render("✗")
+	}
+	line := fmt.Sprintf("%s %s", icon, result.Name)
+	if result.Method != "" {
+		line += infoStyle.Render(fmt.Sprintf("  %s %s", result.Method, result.Path))
+	}
+	if result.Status != 0 {
+		line += infoStyle.Render(fmt.Sprintf(" → %d (%s)", result.Status, formatDuration(result.Duration)))
+	}
+	lines := []string{line}
+
+	for _, a := range result.Assertions {
+		if !a.Passed {
+			lines = append(lines, errorStyle.Render("    ✗ ")+a.Assertion+infoStyle.Render(": "+a.Message))
+		}
+	}
+	if result.Error != "" {
+		lines = append(lines, errorStyle.Render("    ✗ ")+result.Error)
+	}
+
+	names := make([]string, 0, len(result.Extracted))
+	for name := range result.Extracted {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		lines = append(lines, infoStyle.Render(fmt.Sprintf("    %s = %s", name, result.Extracted[name])))
+	}
+	return lines
+}
@@ -0,0 +1,89 @@
+package tui
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/doganarif/ApiMug/internal/api"
+)
+
+// streamStartedMsg switches to the response view once a stream opens
+type streamStartedMsg struct {
+	response *api.Response
+}
+
+type streamEventMsg struct {
+	event api.StreamEvent
+}
+
+// newStreamHandler forwards stream callbacks to the TUI through ch
+func newStreamHandler(ctx context.Context, ch chan<- tea.Msg) *api.StreamHandler {
+	send := func(msg tea.Msg) {
+		select {
+		case ch <- msg:
+		case <-ctx.Done():
+		}
+	}
+	return &api.StreamHandler{
+		Started: func(resp *api.Response) { send(streamStartedMsg{response: resp}) },
+		Event:   func(event api.StreamEvent) { send(streamEventMsg{event: event}) },
+	}
+}
+
+// listenRequest waits for the next message of the in-flight request
+func listenRequest(ch <-chan tea.Msg) tea.Cmd {
+	return func() tea.Msg {
+		return <-ch
+	}
+}
+
+// renderStreamEvents renders the latest events that fit in maxLines
+func renderStreamEvents(events []api.StreamEvent, maxLines int) string {
+	if len(events) == 0 {
+		return infoStyle.Render("  Waiting for events...")
+	}
+
+	var lines []string
+	for _, event := range events {
+		stamp := infoStyle.Render(event.Time.Format("15:04:05.000"))
+
+		if event.Reconnect {
+			lines = append(lines, fmt.Sprintf("%s %s", stamp, errorStyle.Render("reconnected ("+event.Data+")")))
+			if event.ID != "" {
+				lines = append(lines, infoStyle.Render("  Last-Event-ID: "+event.ID))
+			}
+			continue
+		}
+
+		header := stamp
+		if event.Event != "" {
+			header += " " + selectedStyle.Render(event.Event)
+		}
+		if event.ID != "" {
+			header += infoStyle.Render(" id=" + event.ID)
+		}
+		lines = append(lines, header)
+
+		for _, line := range strings.Split(formatEventData(event.Data), "\n") {
+			lines = append(lines, "  "+line)
+		}
+	}
+
+	if maxLines > 0 && len(lines) > maxLines {
+		lines = lines[len(lines)-maxLines:]
+	}
+	return strings.Join(lines, "\n")
+}
+
+// formatEventData pretty-prints JSON event data
+func formatEventData(data string) string {
+	var buf bytes.Buffer
+	if json.Indent(&buf, []byte(data), "", "  ") == nil {
+		return buf.String()
+	}
+	return data
+}
@@ -61,6 +61,10 @@ var (
 			Foreground(lipgloss.Color("#FF0000")).
 			Bold(true)
 
+	warningStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#FFAF00")).
+			Bold(true)
+
 	codeStyle = lipgloss.NewStyle().
 			Foreground(lipgloss.Color("#FAFAFA")).
 			Background(lipgloss.Color("#2E3440")).
@@ -73,6 +77,19 @@ var (
 	statusCodeErrorStyle = lipgloss.NewStyle().
 				Foreground(lipgloss.Color("#FF0000")).
 				Bold(true)
+
+	tabStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#626262")).
+			Padding(0, 1)
+
+	activeTabStyle = lipgloss.NewStyle().
+			Bold(true).
+			Foreground(lipgloss.Color("#FAFAFA")).
+			Background(lipgloss.Color("#7D56F4")).
+			Padding(0, 1)
+
+	timingBarStyle = lipgloss.NewStyle().
+			Foreground(lipgloss.Color("#7D56F4"))
 )
 
 func getMethodStyle(method string) lipgloss.Style {
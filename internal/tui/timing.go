@@ -0,0 +1,103 @@
+package tui
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/doganarif/ApiMug/internal/api"
+)
+
+// renderTabs renders a horizontal tab bar with the active tab highlighted
+func renderTabs(names []string, active int) string {
+	tabs := make([]string, len(names))
+	for i, name := range names {
+		if i == active {
+			tabs[i] = activeTabStyle.Render(name)
+		} else {
+			tabs[i] = tabStyle.Render(name)
+		}
+	}
+	return strings.Join(tabs, " ")
+}
+
+// chartWidth returns the room left for a bar chart once reserved columns are
+// taken from the terminal width, keeping a few columns on narrow terminals
+func chartWidth(width, reserved int) int {
+	return max(width-reserved, 10)
+}
+
+// renderTiming renders the request timing breakdown as a waterfall
+func renderTiming(t *api.Timing, width int) string {
+	if t == nil {
+		return infoStyle.Render("No timing information available")
+	}
+
+	var b strings.Builder
+
+	barWidth := chartWidth(width, 40)
+
+	scale := func(d time.Duration) int {
+		if t.Total <= 0 {
+			return 0
+		}
+		return int(float64(d) / float64(t.Total) * float64(barWidth))
+	}
+
+	b.WriteString(headerStyle.Render("Waterfall"))
+	b.WriteString("\n\n")
+
+	if len(t.Phases) == 0 {
+		b.WriteString(infoStyle.Render("  No phases recorded"))
+		b.WriteString("\n")
+	}
+
+	for _, phase := range t.Phases {
+		offset := scale(phase.Offset)
+		length := scale(phase.Duration)
+		if length == 0 && phase.Duration > 0 {
+			length = 1
+		}
+		if offset+length > barWidth {
+			length = barWidth - offset
+		}
+
+		bar := strings.Repeat(" ", offset) + timingBarStyle.Render(strings.Repeat("█", length))
+		b.WriteString(fmt.Sprintf("  %-17s %10s  %s\n", phase.Name, formatDuration(phase.Duration), bar))
+	}
+
+	b.WriteString("\n")
+	b.WriteString(headerStyle.Render("Summary"))
+	b.WriteString("\n\n")
+	b.WriteString(fmt.Sprintf("  %-17s %10s\n", "Time to first byte", formatDuration(t.TTFB)))
+	b.WriteString(fmt.Sprintf("  %-17s %10s\n", "Total", formatDuration(t.Total)))
+
+	reused := "no"
+	if t.ConnReused {
+		reused = "yes"
+	}
+	b.WriteString(fmt.Sprintf("  %-17s %10s\n", "Connection reused", reused))
+
+	if t.RemoteAddr != "" {
+		b.WriteString(fmt.Sprintf("  %-17s %s\n", "Remote address", t.RemoteAddr))
+	}
+
+	proxy := "none (direct)"
+	if t.Proxy != "" {
+		proxy = t.Proxy
+	}
+	b.WriteString(fmt.Sprintf("  %-17s %s\n", "Proxy", proxy))
+
+	return b.String()
+}
+
+func formatDuration(d time.Duration) string {
+	switch {
+	case d >= time.Second:
+		return fmt.Sprintf("%.2fs", d.Seconds())
+	case d >= time.Millisecond:
+		return fmt.Sprintf("%.1fms", float64(d)/float64(time.Millisecond))
+	default:
+		return fmt.Sprintf("%dµs", d.Microseconds())
+	}
+}
@@ -0,0 +1,238 @@
+package tui
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/bubbles/textarea"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/doganarif/ApiMug/internal/api"
+)
+
+type wsConnectedMsg struct {
+	ws  *api.WebSocket
+	err error
+}
+
+// wsMessageMsg carries a message received on ws; messages of an earlier
+// session are ignored
+type wsMessageMsg struct {
+	ws      *api.WebSocket
+	message api.WebSocketMessage
+}
+
+type wsClosedMsg struct {
+	ws  *api.WebSocket
+	err error
+}
+
+// startWebSocket builds the request from the form and opens a session in the background
+func (m *Model) startWebSocket() tea.Cmd {
+	req, err := m.buildRequest()
+	if err != nil {
+		m.statusMsg = errorStyle.Render("Error: ") + err.Error()
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	m.sending = true
+	m.cancelSend = cancel
+	m.sendStarted = time.Now()
+	m.progress = &api.Progress{}
+	m.statusMsg = ""
+
+	client := m.client
+	return tea.Batch(m.spinner.Tick, func() tea.Msg {
+		ws, err := client.DialWebSocket(ctx, req)
+		return wsConnectedMsg{ws: ws, err: err}
+	})
+}
+
+// readWebSocket waits for the next message of the session
+func readWebSocket(ws *api.WebSocket) tea.Cmd {
+	return func() tea.Msg {
+		message, err := ws.ReadMessage()
+		if err != nil {
+			return wsClosedMsg{ws: ws, err: err}
+		}
+		return wsMessageMsg{ws: ws, message: message}
+	}
+}
+
+// handleWebSocketMsg updates the session state, reporting whether msg belonged to it
+func (m *Model) handleWebSocketMsg(msg tea.Msg) (tea.Cmd, bool) {
+	switch msg := msg.(type) {
+	case wsConnectedMsg:
+		m.cancelRequest()
+		m.sending = false
+		m.cancelSend = nil
+		if msg.err != nil {
+			m.statusMsg = errorStyle.Render("Connection failed: ") + msg.err.Error()
+			return nil, true
+		}
+
+		input := textarea.New()
+		input.Placeholder = `{"type": "subscribe"}`
+		input.CharLimit = 5000
+		input.SetHeight(3)
+		input.Focus()
+
+		m.ws = msg.ws
+		m.wsMessages = nil
+		m.wsInput = input
+		m.wsOpen = true
+		m.wsClosing = false
+		m.wsErr = nil
+		m.mode = viewWebSocket
+		return readWebSocket(msg.ws), true
+
+	case wsMessageMsg:
+		if msg.ws != m.ws {
+			return nil, true
+		}
+		m.wsMessages = append(m.wsMessages, msg.message)
+		return readWebSocket(msg.ws), true
+
+	case wsClosedMsg:
+		if msg.ws != m.ws {
+			return nil, true
+		}
+		m.wsOpen = false
+		if !errors.Is(msg.err, api.ErrWebSocketClosed) {
+			m.wsErr = msg.err
+		}
+		return nil, true
+	}
+	return nil, false
+}
+
+func (m Model) handleWebSocketKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "esc":
+		if !m.wsOpen || m.wsClosing {
+			// A pending closing handshake times out on its own
+			m.ws = nil
+			m.mode = viewRequest
+			return m, nil
+		}
+		m.wsClosing = true
+		m.recordWebSocket(m.ws.Close())
+		return m, nil
+	case "ctrl+s":
+		text := m.wsInput.Value()
+		if !m.wsOpen || m.wsClosing || strings.TrimSpace(text) == "" {
+			return m, nil
+		}
+		if m.recordWebSocket(m.ws.SendText(text)) {
+			m.wsInput.Reset()
+		}
+		return m, nil
+	case "ctrl+p":
+		if m.wsOpen && !m.wsClosing {
+			m.recordWebSocket(m.ws.Ping())
+		}
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.wsInput, cmd = m.wsInput.Update(msg)
+	return m, cmd
+}
+
+// recordWebSocket adds a sent message to the timeline, reporting whether it was sent
+func (m *Model) recordWebSocket(message api.WebSocketMessage, err error) bool {
+	if err != nil {
+		m.wsErr = err
+		return false
+	}
+	m.wsMessages = append(m.wsMessages, message)
+	return true
+}
+
+func (m Model) webSocketView() string {
+	var b strings.Builder
+
+	b.WriteString(titleStyle.Render("WebSocket Session"))
+	b.WriteString("\n\n")
+
+	b.WriteString(fmt.Sprintf("%s %s  ", getMethodStyle("get").Render("WS"), m.ws.Response.URL))
+	switch {
+	case m.wsClosing && m.wsOpen:
+		b.WriteString(statusCodeErrorStyle.Render("closing"))
+	case m.wsOpen:
+		b.WriteString(statusCodeSuccessStyle.Render("open"))
+	default:
+		b.WriteString(statusCodeErrorStyle.Render("closed"))
+	}
+	b.WriteString(infoStyle.Render(fmt.Sprintf("  (handshake %s)", m.ws.Response.Duration)))
+	b.WriteString("\n\n")
+
+	if m.wsErr != nil {
+		b.WriteString(errorStyle.Render("Error: "))
+		b.WriteString(m.wsErr.Error())
+		b.WriteString("\n\n")
+	}
+
+	b.WriteString(headerStyle.Render("Timeline"))
+	b.WriteString("\n")
+	b.WriteString(renderWebSocketMessages(m.wsMessages, m.height-18))
+	b.WriteString("\n\n")
+
+	if !m.wsOpen || m.wsClosing {
+		b.WriteString(helpStyle.Render("esc: back"))
+		return b.String()
+	}
+
+	b.WriteString(headerStyle.Render("Message"))
+	b.WriteString("\n\n")
+	b.WriteString(m.wsInput.View())
+	b.WriteString(helpStyle.Render("\n\nctrl+s: send • ctrl+p: ping • esc: close connection"))
+
+	return b.String()
+}
+
+// renderWebSocketMessages renders the latest messages that fit in maxLines
+func renderWebSocketMessages(messages []api.WebSocketMessage, maxLines int) string {
+	if len(messages) == 0 {
+		return infoStyle.Render("  No messages yet")
+	}
+
+	var lines []string
+	for _, message := range messages {
+		direction := "←"
+		if message.Outgoing {
+			direction = "→"
+		}
+		header := fmt.Sprintf("%s %s ", infoStyle.Render(message.Time.Format("15:04:05.000")), direction)
+
+		switch message.Opcode {
+		case api.OpText:
+			lines = append(lines, header+selectedStyle.Render("text"))
+			for _, line := range strings.Split(formatEventData(string(message.Data)), "\n") {
+				lines = append(lines, "  "+line)
+			}
+		case api.OpBinary:
+			lines = append(lines, header+selectedStyle.Render("binary")+infoStyle.Render(" "+api.FormatBytes(int64(len(message.Data)))))
+		case api.OpPing, api.OpPong:
+			label := "ping"
+			if message.Opcode == api.OpPong {
+				label = "pong"
+			}
+			lines = append(lines, header+infoStyle.Render(label))
+		case api.OpClose:
+			detail := fmt.Sprintf("close %d", message.CloseCode)
+			if len(message.Data) > 0 {
+				detail += " " + string(message.Data)
+			}
+			lines = append(lines, header+errorStyle.Render(detail))
+		}
+	}
+
+	if maxLines > 0 && len(lines) > maxLines {
+		lines = lines[len(lines)-maxLines:]
+	}
+	return strings.Join(lines, "\n")
+}
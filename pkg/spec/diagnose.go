@@ -0,0 +1,301 @@
+package spec
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"gopkg.in/yaml.v3"
+)
+
+// Diagnostic is a validation problem at a location in a spec
+type Diagnostic struct {
+	Location string `json:"location"`       // JSON pointer, e.g. #/paths/~1orders/get
+	Line     int    `json:"line,omitempty"` // Line in the spec source, when known
+	Message  string `json:"message"`
+}
+
+func (d Diagnostic) String() string {
+	if d.Line > 0 {
+		return fmt.Sprintf("line %d (%s): %s", d.Line, d.Location, d.Message)
+	}
+	return fmt.Sprintf("%s: %s", d.Location, d.Message)
+}
+
+// Pointer builds a JSON pointer from unescaped reference tokens
+func Pointer(tokens ...string) string {
+	var b strings.Builder
+	b.WriteString("#")
+	for _, t := range tokens {
+		b.WriteString("/")
+		b.WriteString(strings.NewReplacer("~", "~0", "/", "~1").Replace(t))
+	}
+	return b.String()
+}
+
+// SplitPointer returns the unescaped reference tokens of a JSON pointer
+func SplitPointer(p string) []string {
+	p = strings.TrimPrefix(strings.TrimPrefix(p, "#"), "/")
+	if p == "" {
+		return nil
+	}
+	tokens := strings.Split(p, "/")
+	for i, t := range tokens {
+		tokens[i] = strings.NewReplacer("~1", "/", "~0", "~").Replace(t)
+	}
+	return tokens
+}
+
+// Diagnose validates doc part by part, so that one problem does not hide the
+// others as it does with Validate. It returns nothing for a valid document.
+func Diagnose(ctx context.Context, doc *openapi3.T) []Diagnostic {
+	if Validate(ctx, doc) == nil {
+		return nil
+	}
+	doc = withoutMutualTLS(doc)
+
+	var diags []Diagnostic
+	add := func(err error, tokens ...string) {
+		if err != nil {
+			diags = append(diags, Diagnostic{Location: Pointer(tokens...), Message: err.Error()})
+		}
+	}
+
+	if doc.OpenAPI == "" {
+		add(fmt.Errorf("value of openapi must be a non-empty string"), "openapi")
+	}
+	if doc.Info == nil {
+		add(fmt.Errorf("must be an object"), "info")
+	} else {
+		add(doc.Info.Validate(ctx), "info")
+	}
+	if doc.Components != nil {
+		for _, c := range components(doc.Components) {
+			add(c.value.Validate(ctx), "components", c.kind, c.name)
+		}
+	}
+	if doc.Paths == nil {
+		add(fmt.Errorf("must be an object"), "paths")
+	} else {
+		diags = append(diags, diagnosePaths(ctx, doc.Paths)...)
+	}
+	add(doc.Security.Validate(ctx), "security")
+	for i, server := range doc.Servers {
+		if server != nil {
+			add(server.Validate(ctx), "servers", strconv.Itoa(i))
+		}
+	}
+	add(doc.Tags.Validate(ctx), "tags")
+	if doc.ExternalDocs != nil {
+		add(doc.ExternalDocs.Validate(ctx), "externalDocs")
+	}
+
+	// Checks across parts, such as conflicting paths, only show up as a whole
+	if len(diags) == 0 {
+		add(Validate(ctx, doc))
+	}
+	return diags
+}
+
+// component is a single entry of the components object, wrapped in a
+// components object of its own to validate it alone
+type component struct {
+	kind  string
+	name  string
+	value *openapi3.Components
+}
+
+func components(c *openapi3.Components) []component {
+	var list []component
+	add := func(kind string, names []string, one func(name string) *openapi3.Components) {
+		for _, name := range names {
+			list = append(list, component{kind, name, one(name)})
+		}
+	}
+
+	add("schemas", keys(c.Schemas), func(n string) *openapi3.Components {
+		return &openapi3.Components{Schemas: openapi3.Schemas{n: c.Schemas[n]}}
+	})
+	add("parameters", keys(c.Parameters), func(n string) *openapi3.Components {
+		return &openapi3.Components{Parameters: openapi3.ParametersMap{n: c.Parameters[n]}}
+	})
+	add("headers", keys(c.Headers), func(n string) *openapi3.Components {
+		return &openapi3.Components{Headers: openapi3.Headers{n: c.Headers[n]}}
+	})
+	add("requestBodies", keys(c.RequestBodies), func(n string) *openapi3.Components {
+		return &openapi3.Components{RequestBodies: openapi3.RequestBodies{n: c.RequestBodies[n]}}
+	})
+	add("responses", keys(c.Responses), func(n string) *openapi3.Components {
+		return &openapi3.Components{Responses: openapi3.ResponseBodies{n: c.Responses[n]}}
+	})
+	add("securitySchemes", keys(c.SecuritySchemes), func(n string) *openapi3.Components {
+		return &openapi3.Components{SecuritySchemes: openapi3.SecuritySchemes{n: c.SecuritySchemes[n]}}
+	})
+	add("examples", keys(c.Examples), func(n string) *openapi3.Components {
+		return &openapi3.Components{Examples: openapi3.Examples{n: c.Examples[n]}}
+	})
+	add("links", keys(c.Links), func(n string) *openapi3.Components {
+		return &openapi3.Components{Links: openapi3.Links{n: c.Links[n]}}
+	})
+	add("callbacks", keys(c.Callbacks), func(n string) *openapi3.Components {
+		return &openapi3.Components{Callbacks: openapi3.Callbacks{n: c.Callbacks[n]}}
+	})
+	return list
+}
+
+// diagnosePaths validates each operation on its own, with the parameters and
+// servers of its path, then reports duplicate operationIds
+func diagnosePaths(ctx context.Context, paths *openapi3.Paths) []Diagnostic {
+	var diags []Diagnostic
+	names := keys(paths.Map())
+	for _, path := range names {
+		item := paths.Value(path)
+		if item == nil {
+			continue
+		}
+		if err := openapi3.NewPaths(openapi3.WithPath(path, item)).Validate(ctx); err == nil {
+			continue
+		}
+
+		found := false
+		for _, method := range methods(item) {
+			single := &openapi3.PathItem{
+				Extensions: item.Extensions,
+				Servers:    item.Servers,
+				Parameters: item.Parameters,
+			}
+			single.SetOperation(method, item.GetOperation(method))
+			if err := openapi3.NewPaths(openapi3.WithPath(path, single)).Validate(ctx); err != nil {
+				found = true
+				diags = append(diags, Diagnostic{
+					Location: Pointer("paths", path, strings.ToLower(method)),
+					Message:  trimPathPrefix(err.Error(), path),
+				})
+			}
+		}
+		if !found {
+			// Duplicate operationIds are reported below, within a path too
+			if err := openapi3.NewPaths(openapi3.WithPath(path, withoutOperationIDs(item))).Validate(ctx); err != nil {
+				diags = append(diags, Diagnostic{Location: Pointer("paths", path), Message: trimPathPrefix(err.Error(), path)})
+			}
+		}
+	}
+
+	first := make(map[string]string)
+	for _, path := range names {
+		item := paths.Value(path)
+		if item == nil {
+			continue
+		}
+		for _, method := range methods(item) {
+			id := item.GetOperation(method).OperationID
+			if id == "" {
+				continue
+			}
+			if prev, ok := first[id]; ok {
+				diags = append(diags, Diagnostic{
+					Location: Pointer("paths", path, strings.ToLower(method), "operationId"),
+					Message:  fmt.Sprintf("operationId %q is also used by %s", id, prev),
+				})
+				continue
+			}
+			first[id] = method + " " + path
+		}
+	}
+	return diags
+}
+
+// withoutOperationIDs copies a path item with the operationIds of its
+// operations cleared
+func withoutOperationIDs(item *openapi3.PathItem) *openapi3.PathItem {
+	copied := *item
+	for method, op := range item.Operations() {
+		cleared := *op
+		cleared.OperationID = ""
+		copied.SetOperation(method, &cleared)
+	}
+	return &copied
+}
+
+// trimPathPrefix removes the path from an error the location already names
+func trimPathPrefix(msg, path string) string {
+	return strings.TrimPrefix(msg, "invalid path "+path+": ")
+}
+
+// methods returns the methods of a path item, sorted
+func methods(item *openapi3.PathItem) []string {
+	return keys(item.Operations())
+}
+
+func keys[V any](m map[string]V) []string {
+	list := make([]string, 0, len(m))
+	for k := range m {
+		list = append(list, k)
+	}
+	sort.Strings(list)
+	return list
+}
+
+// Locate sets the line of each diagnostic from the spec source, YAML or JSON
+func Locate(data []byte, diags []Diagnostic) {
+	pointers := make([]string, len(diags))
+	for i, d := range diags {
+		pointers[i] = d.Location
+	}
+	for i, line := range Lines(data, pointers) {
+		diags[i].Line = line
+	}
+}
+
+// Lines returns the line in the spec source of each JSON pointer. A location
+// missing from the source, such as one in a converted Swagger 2.0 document,
+// gets the line of its closest parent; all are zero if the source cannot be
+// parsed.
+func Lines(data []byte, pointers []string) []int {
+	lines := make([]int, len(pointers))
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil || len(root.Content) == 0 {
+		return lines
+	}
+	for i, p := range pointers {
+		lines[i] = line(root.Content[0], SplitPointer(p))
+	}
+	return lines
+}
+
+// line returns the line of the node at the reference tokens below n
+func line(n *yaml.Node, tokens []string) int {
+	for _, token := range tokens {
+		next := child(n, token)
+		if next == nil {
+			break
+		}
+		n = next
+	}
+	return n.Line
+}
+
+func child(n *yaml.Node, token string) *yaml.Node {
+	switch n.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			if n.Content[i].Value == token {
+				// Point at the key, the value may start on the next line
+				if v := n.Content[i+1]; v.Kind == yaml.MappingNode || v.Kind == yaml.SequenceNode {
+					key := *v
+					key.Line = n.Content[i].Line
+					return &key
+				}
+				return n.Content[i+1]
+			}
+		}
+	case yaml.SequenceNode:
+		if idx, err := strconv.Atoi(token); err == nil && idx >= 0 && idx < len(n.Content) {
+			return n.Content[idx]
+		}
+	}
+	return nil
+}
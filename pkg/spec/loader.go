@@ -6,7 +6,9 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"os"
+	"sort"
 	"strings"
 
 	"github.com/getkin/kin-openapi/openapi2"
@@ -17,7 +19,10 @@ import (
 
 // Loader handles loading and parsing OpenAPI specifications
 type Loader struct {
-	loader *openapi3.Loader
+	loader         *openapi3.Loader
+	skipValidation bool
+	lenient        bool
+	diagnostics    []Diagnostic
 }
 
 // NewLoader creates a new spec loader
@@ -27,6 +32,26 @@ func NewLoader() *Loader {
 	}
 }
 
+// WithoutValidation makes the loader return documents that fail validation,
+// leaving it to the caller to run Validate
+func (l *Loader) WithoutValidation() *Loader {
+	l.skipValidation = true
+	return l
+}
+
+// Lenient makes the loader return documents that fail validation, keeping the
+// problems found for Diagnostics
+func (l *Loader) Lenient() *Loader {
+	l.lenient = true
+	return l
+}
+
+// Diagnostics returns the validation problems of the last document loaded
+// leniently
+func (l *Loader) Diagnostics() []Diagnostic {
+	return l.diagnostics
+}
+
 // LoadFromFile loads an OpenAPI or Swagger spec from a file path
 func (l *Loader) LoadFromFile(ctx context.Context, path string) (*openapi3.T, error) {
 	data, err := os.ReadFile(path)
@@ -34,7 +59,7 @@ func (l *Loader) LoadFromFile(ctx context.Context, path string) (*openapi3.T, er
 		return nil, fmt.Errorf("failed to read spec file: %w", err)
 	}
 
-	return l.loadFromData(ctx, data)
+	return l.loadFromData(ctx, data, "")
 }
 
 // LoadFromURL loads an OpenAPI or Swagger spec from a URL
@@ -54,11 +79,13 @@ func (l *Loader) LoadFromURL(ctx context.Context, specURL string) (*openapi3.T,
 		return nil, fmt.Errorf("failed to read spec: %w", err)
 	}
 
-	return l.loadFromData(ctx, data)
+	return l.loadFromData(ctx, data, specURL)
 }
 
-// loadFromData loads spec from raw data, detecting and converting Swagger 2.0 if needed
-func (l *Loader) loadFromData(ctx context.Context, data []byte) (*openapi3.T, error) {
+// loadFromData loads spec from raw data, detecting and converting Swagger 2.0 if needed.
+// specURL is the location the spec was fetched from, empty for local files.
+func (l *Loader) loadFromData(ctx context.Context, data []byte, specURL string) (*openapi3.T, error) {
+	l.diagnostics = nil
 	var rawMap map[string]interface{}
 
 	if err := json.Unmarshal(data, &rawMap); err != nil {
@@ -68,7 +95,7 @@ func (l *Loader) loadFromData(ctx context.Context, data []byte) (*openapi3.T, er
 	}
 
 	if swagger, ok := rawMap["swagger"].(string); ok && strings.HasPrefix(swagger, "2.") {
-		return l.loadSwagger2(data)
+		return l.loadSwagger2(data, specURL)
 	}
 
 	doc, err := l.loader.LoadFromData(data)
@@ -76,15 +103,48 @@ func (l *Loader) loadFromData(ctx context.Context, data []byte) (*openapi3.T, er
 		return nil, fmt.Errorf("failed to parse OpenAPI spec: %w", err)
 	}
 
-	if err := doc.Validate(ctx); err != nil {
+	if l.skipValidation {
+		return doc, nil
+	}
+	if l.lenient {
+		l.diagnostics = Diagnose(ctx, doc)
+		Locate(data, l.diagnostics)
+		return doc, nil
+	}
+	if err := Validate(ctx, doc); err != nil {
 		return nil, fmt.Errorf("OpenAPI spec validation failed: %w", err)
 	}
 
 	return doc, nil
 }
 
+// Validate validates doc, accepting the mutualTLS security scheme type that
+// kin-openapi does not know about
+func Validate(ctx context.Context, doc *openapi3.T) error {
+	return withoutMutualTLS(doc).Validate(ctx)
+}
+
+// withoutMutualTLS returns a shallow copy of doc without its mutualTLS security
+// schemes. doc itself is left alone, it may be in use elsewhere.
+func withoutMutualTLS(doc *openapi3.T) *openapi3.T {
+	if doc.Components == nil {
+		return doc
+	}
+	components := *doc.Components
+	components.SecuritySchemes = make(openapi3.SecuritySchemes, len(doc.Components.SecuritySchemes))
+	for name, ref := range doc.Components.SecuritySchemes {
+		if ref != nil && ref.Value != nil && ref.Value.Type == "mutualTLS" {
+			continue
+		}
+		components.SecuritySchemes[name] = ref
+	}
+	copied := *doc
+	copied.Components = &components
+	return &copied
+}
+
 // loadSwagger2 loads and converts Swagger 2.0 spec to OpenAPI 3.0
-func (l *Loader) loadSwagger2(data []byte) (*openapi3.T, error) {
+func (l *Loader) loadSwagger2(data []byte, specURL string) (*openapi3.T, error) {
 	var rawData interface{}
 
 	if err := json.Unmarshal(data, &rawData); err != nil {
@@ -111,9 +171,57 @@ func (l *Loader) loadSwagger2(data []byte) (*openapi3.T, error) {
 		return nil, fmt.Errorf("failed to convert Swagger 2.0 to OpenAPI 3.0: %w", err)
 	}
 
+	v3.Servers = swagger2Servers(&v2, specURL)
+
 	return v3, nil
 }
 
+// swagger2Servers builds OpenAPI 3 servers from host, basePath and schemes.
+// As Swagger 2.0 specifies, a missing host or scheme defaults to the one used to
+// serve the spec; without a spec URL a missing host yields a relative server.
+func swagger2Servers(v2 *openapi2.T, specURL string) openapi3.Servers {
+	var source *url.URL
+	if specURL != "" {
+		source, _ = url.Parse(specURL)
+	}
+
+	host := v2.Host
+	if host == "" && source != nil {
+		host = source.Host
+	}
+
+	basePath := "/" + strings.Trim(v2.BasePath, "/")
+
+	if host == "" {
+		return openapi3.Servers{{URL: basePath}}
+	}
+
+	schemes := v2.Schemes
+	if len(schemes) == 0 {
+		if source != nil && source.Scheme != "" {
+			schemes = []string{source.Scheme}
+		} else {
+			schemes = []string{"https"}
+		}
+	}
+
+	// Prefer the scheme the spec was served over, then https
+	preferred := "https"
+	if source != nil && source.Scheme != "" {
+		preferred = source.Scheme
+	}
+	sort.SliceStable(schemes, func(i, j int) bool {
+		return schemes[i] == preferred && schemes[j] != preferred
+	})
+
+	var servers openapi3.Servers
+	for _, scheme := range schemes {
+		u := url.URL{Scheme: scheme, Host: host, Path: basePath}
+		servers = append(servers, &openapi3.Server{URL: strings.TrimSuffix(u.String(), "/")})
+	}
+	return servers
+}
+
 // convertYAMLMapToJSON converts map[interface{}]interface{} to map[string]interface{}
 // This is necessary because YAML unmarshaling creates maps with interface{} keys,
 // but JSON requires string keys